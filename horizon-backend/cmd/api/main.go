@@ -7,7 +7,9 @@ import (
 	"horizon-backend/internal/auth"
 	"horizon-backend/internal/controller"
 	"horizon-backend/internal/db"
+	"horizon-backend/internal/email"
 	"horizon-backend/internal/middleware"
+	"horizon-backend/internal/realtime"
 	"horizon-backend/internal/service"
 	"log"
 	"net/http"
@@ -34,12 +36,42 @@ func main() {
 	// Initialize query client
 	queries := db.New(pool)
 
+	// Initialize realtime hub
+	hub := realtime.NewHub(pool, queries)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	defer stopHub()
+	go hub.Run(hubCtx)
+
 	// Initialize services
 	healthService := service.NewHealthService(queries)
 	userService := service.NewUserService(queries)
-	notificationService := service.NewNotificationService(queries)
-	postService := service.NewPostService(queries, pool, userService, notificationService)
-	followService := service.NewFollowService(queries, notificationService)
+	webhookService := service.NewWebhookService(queries, cfg.WebhookAllowPrivateNetworks)
+	filterService := service.NewFilterService(queries)
+	notificationService := service.NewNotificationService(queries, pool, hub, webhookService, filterService, cfg.NotificationGroupWindow)
+	postService := service.NewPostService(queries, pool, userService, notificationService, filterService, hub, webhookService, cfg.PostEditWindow)
+	followService := service.NewFollowService(queries, notificationService, hub, webhookService)
+	blockService := service.NewBlockService(queries, pool, followService)
+	muteService := service.NewMuteService(queries)
+	suggestionService := service.NewSuggestionService(queries, pool, cfg.SuggestionRefreshInterval)
+	messageService := service.NewMessageService(queries, pool, hub)
+	mailer := email.NewMailer(cfg.SMTP)
+	digestService := service.NewDigestService(queries, pool, notificationService, mailer, cfg.PublicURL, cfg.AppURL)
+
+	// Send email digests and webhook deliveries and refresh follow
+	// suggestions in the background
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if cfg.WebhookPollInterval > 0 {
+		go webhookService.Run(jobsCtx, cfg.WebhookPollInterval)
+	}
+	if cfg.SuggestionCheckInterval > 0 {
+		go suggestionService.Run(jobsCtx, cfg.SuggestionCheckInterval)
+	}
+	if mailer.Enabled() && cfg.DigestCheckInterval > 0 {
+		go digestService.Run(jobsCtx, cfg.DigestCheckInterval)
+	} else {
+		log.Printf("Email digests are off; set SMTP_HOST to send them")
+	}
 
 	// Initialize auth provider
 	authProvider := auth.NewLocalAuthProvider(queries, cfg)
@@ -60,11 +92,20 @@ func main() {
 	userController := controller.NewUserController(userService, s3Service)
 	postController := controller.NewPostController(postService, userService, s3Service.GetClient(), cfg.S3BucketName)
 	followController := controller.NewFollowController(followService, userService)
+	blockController := controller.NewBlockController(blockService, userService)
+	muteController := controller.NewMuteController(muteService, userService)
+	suggestionController := controller.NewSuggestionController(suggestionService)
 	authController := controller.NewAuthController(authProvider, userService)
 	notificationController := controller.NewNotificationController(notificationService)
+	messageController := controller.NewMessageController(messageService, userService)
+	gatewayController := controller.NewGatewayController(hub, postService, followService, messageService)
+	digestController := controller.NewDigestController(digestService)
+	webhookController := controller.NewWebhookController(webhookService)
+	filterController := controller.NewFilterController(filterService)
 
 	// Initialize middleware
 	authMiddleware := middleware.AuthMiddleware(authProvider)
+	streamAuthMiddleware := middleware.StreamAuthMiddleware(authProvider)
 
 	// Initialize Echo
 	e := echo.New()
@@ -102,12 +143,45 @@ func main() {
 	userGroup.POST("/:username/follow", followController.FollowUser, authMiddleware)
 	userGroup.DELETE("/:username/follow", followController.UnfollowUser, authMiddleware)
 	userGroup.POST("/:username/accept-follow", followController.AcceptFollowRequest, authMiddleware)
+	userGroup.POST("/:username/reject-follow", followController.RejectFollowRequest, authMiddleware)
+	userGroup.DELETE("/:username/follow-request", followController.CancelFollowRequest, authMiddleware)
+	userGroup.GET("/me/follow-requests", followController.GetFollowRequests, authMiddleware)
+
+	// Block routes
+	userGroup.GET("/me/blocks", blockController.GetBlockedUsers, authMiddleware)
+	userGroup.POST("/:username/block", blockController.BlockUser, authMiddleware)
+	userGroup.DELETE("/:username/block", blockController.UnblockUser, authMiddleware)
+
+	// Mute routes
+	userGroup.GET("/me/mutes", muteController.GetMutedUsers, authMiddleware)
+	userGroup.POST("/:username/mute", muteController.MuteUser, authMiddleware)
+	userGroup.DELETE("/:username/mute", muteController.UnmuteUser, authMiddleware)
+
+	// Suggestion routes
+	userGroup.GET("/me/suggestions", suggestionController.GetSuggestions, authMiddleware)
 
 	// Bookmark routes
 	userGroup.GET("/me/bookmarks", postController.GetUserBookmarks, authMiddleware)
 	userGroup.POST("/me/bookmarks/:postId", postController.BookmarkPost, authMiddleware)
 	userGroup.DELETE("/me/bookmarks/:postId", postController.UnbookmarkPost, authMiddleware)
 
+	// Mention routes
+	userGroup.GET("/me/mentions", postController.GetUserMentions, authMiddleware)
+
+	// Notification settings routes
+	userGroup.GET("/me/settings/notifications", notificationController.GetSettings, authMiddleware)
+	userGroup.PUT("/me/settings/notifications", notificationController.UpdateSettings, authMiddleware)
+	userGroup.GET("/me/settings/email-digest", digestController.GetSettings, authMiddleware)
+	userGroup.PUT("/me/settings/email-digest", digestController.UpdateSettings, authMiddleware)
+	userGroup.POST("/me/settings/email-digest/test", digestController.SendTest, authMiddleware)
+	userGroup.GET("/me/settings/filters", filterController.GetSettings, authMiddleware)
+	userGroup.PUT("/me/settings/filters", filterController.UpdateSettings, authMiddleware)
+
+	// Direct message routes
+	userGroup.GET("/:username/messages", messageController.GetMessages, authMiddleware)
+	userGroup.POST("/:username/messages", messageController.SendMessage, authMiddleware)
+	userGroup.PUT("/:username/messages/read", messageController.MarkAsRead, authMiddleware)
+
 	// Post routes
 	postGroup := e.Group("/api/posts")
 	postGroup.GET("", postController.GetPosts, authMiddleware)
@@ -115,11 +189,29 @@ func main() {
 	postGroup.GET("/upload-url", postController.GetUploadURL, authMiddleware)
 	postGroup.GET("/:id", postController.GetPostByID, authMiddleware)
 	postGroup.PUT("/:id", postController.UpdatePostContent, authMiddleware)
+	postGroup.GET("/:id/revisions", postController.GetPostRevisions, authMiddleware)
+	postGroup.PUT("/:id/reply-policy", postController.UpdateReplyPolicy, authMiddleware)
 	postGroup.DELETE("/:id", postController.DeletePost, authMiddleware)
 	postGroup.GET("/:id/replies", postController.GetPostReplies, authMiddleware)
+	postGroup.GET("/:id/quotes", postController.GetPostQuotes, authMiddleware)
+	postGroup.GET("/:id/thread", postController.GetThread, authMiddleware)
 	postGroup.POST("/:id/likes", postController.LikePost, authMiddleware)
 	postGroup.DELETE("/:id/likes", postController.UnlikePost, authMiddleware)
 	postGroup.GET("/:id/likes/status", postController.HasLiked, authMiddleware)
+	postGroup.GET("/:id/reposts", postController.GetPostReposters, authMiddleware)
+	postGroup.POST("/:id/reposts", postController.CreateRepost, authMiddleware)
+	postGroup.DELETE("/:id/reposts", postController.DeleteRepost, authMiddleware)
+	postGroup.POST("/:id/mute", muteController.MuteConversation, authMiddleware)
+	postGroup.DELETE("/:id/mute", muteController.UnmuteConversation, authMiddleware)
+
+	// Hashtag routes
+	hashtagGroup := e.Group("/api/hashtags")
+	hashtagGroup.GET("/trending", postController.GetTrendingHashtags, authMiddleware)
+	hashtagGroup.GET("/:tag/posts", postController.GetHashtagPosts, authMiddleware)
+
+	// Timeline routes
+	timelineGroup := e.Group("/api/timeline")
+	timelineGroup.GET("/home", postController.GetHomeTimeline, authMiddleware)
 
 	// Notification routes
 	notificationGroup := e.Group("/api/notifications")
@@ -127,6 +219,44 @@ func main() {
 	notificationGroup.GET("/unread-count", notificationController.GetUnreadCount, authMiddleware)
 	notificationGroup.PUT("/:id/read", notificationController.MarkAsRead, authMiddleware)
 	notificationGroup.PUT("/mark-all-read", notificationController.MarkAllAsRead, authMiddleware)
+	notificationGroup.GET("/stream", notificationController.Stream, streamAuthMiddleware)
+
+	// Conversation routes
+	conversationGroup := e.Group("/api/conversations")
+	conversationGroup.GET("", messageController.GetConversations, authMiddleware)
+	conversationGroup.POST("", messageController.CreateConversation, authMiddleware)
+	conversationGroup.GET("/unread-count", messageController.GetUnreadCount, authMiddleware)
+	conversationGroup.GET("/:id", messageController.GetConversation, authMiddleware)
+	conversationGroup.PUT("/:id", messageController.UpdateConversation, authMiddleware)
+	conversationGroup.GET("/:id/messages", messageController.GetConversationMessages, authMiddleware)
+	conversationGroup.POST("/:id/messages", messageController.SendConversationMessage, authMiddleware)
+	conversationGroup.PUT("/:id/read", messageController.MarkConversationAsRead, authMiddleware)
+	conversationGroup.POST("/:id/members", messageController.AddMembers, authMiddleware)
+	conversationGroup.DELETE("/:id/members/:username", messageController.RemoveMember, authMiddleware)
+
+	// Webhook routes
+	webhookGroup := e.Group("/api/webhooks")
+	webhookGroup.GET("", webhookController.GetWebhooks, authMiddleware)
+	webhookGroup.POST("", webhookController.CreateWebhook, authMiddleware)
+	webhookGroup.GET("/:id", webhookController.GetWebhook, authMiddleware)
+	webhookGroup.PUT("/:id", webhookController.UpdateWebhook, authMiddleware)
+	webhookGroup.DELETE("/:id", webhookController.DeleteWebhook, authMiddleware)
+	webhookGroup.GET("/:id/deliveries", webhookController.GetDeliveries, authMiddleware)
+
+	// Filter routes
+	filterGroup := e.Group("/api/filters")
+	filterGroup.GET("", filterController.GetFilters, authMiddleware)
+	filterGroup.POST("", filterController.CreateFilter, authMiddleware)
+	filterGroup.GET("/:id", filterController.GetFilter, authMiddleware)
+	filterGroup.PUT("/:id", filterController.UpdateFilter, authMiddleware)
+	filterGroup.DELETE("/:id", filterController.DeleteFilter, authMiddleware)
+
+	// Realtime gateway
+	e.GET("/api/ws", gatewayController.Connect, streamAuthMiddleware)
+
+	// Email unsubscribe links carry a token instead of requiring login
+	e.GET("/api/email/unsubscribe", digestController.ConfirmUnsubscribe)
+	e.POST("/api/email/unsubscribe", digestController.Unsubscribe)
 
 	// Start server
 	serverAddr := fmt.Sprintf(":%s", cfg.ServerPort)
@@ -152,6 +282,11 @@ func main() {
 
 	log.Println("Shutting down server...")
 
+	// Stopping the hub ends open streams and WebSocket connections so
+	// shutdown doesn't wait on them
+	stopHub()
+	stopJobs()
+
 	// Wait for interrupt signal
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,15 @@ type NeonAuthConfig struct {
 	ApiKey    string
 }
 
+// SMTPConfig holds configuration for sending email
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
 // Config holds application configuration
 type Config struct {
 	DBHost             string
@@ -30,6 +40,42 @@ type Config struct {
 	JWTSecret          string
 	Environment        string
 	NeonAuth           NeonAuthConfig
+
+	// PostEditWindow is how long after creation a post can be edited; zero
+	// allows edits at any time
+	PostEditWindow time.Duration
+
+	// NotificationGroupWindow is how long a notification group keeps
+	// collecting likes, reposts and follows after its first one
+	NotificationGroupWindow time.Duration
+
+	// SMTP is the server email is sent through; email is off when no host
+	// is set
+	SMTP SMTPConfig
+
+	// PublicURL is where the API is reached, for links in emails
+	PublicURL string
+
+	// AppURL is where the web app is reached, for links in emails
+	AppURL string
+
+	// DigestCheckInterval is how often due email digests are sent
+	DigestCheckInterval time.Duration
+
+	// WebhookPollInterval is how often queued webhook deliveries are sent
+	WebhookPollInterval time.Duration
+
+	// WebhookAllowPrivateNetworks lets webhooks point at loopback and
+	// private addresses; only meant for development
+	WebhookAllowPrivateNetworks bool
+
+	// SuggestionCheckInterval is how often stale follow suggestions are
+	// recomputed
+	SuggestionCheckInterval time.Duration
+
+	// SuggestionRefreshInterval is how old follow suggestions get before
+	// they're recomputed
+	SuggestionRefreshInterval time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -89,6 +135,24 @@ func Load() *Config {
 			ProjectID: getEnv("NEON_AUTH_PROJECT_ID", ""),
 			ApiKey:    getEnv("NEON_AUTH_API_KEY", ""),
 		},
+		PostEditWindow:          getEnvAsDuration("POST_EDIT_WINDOW", time.Hour),
+		NotificationGroupWindow: getEnvAsDuration("NOTIFICATION_GROUP_WINDOW", 24*time.Hour),
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnvAsInt("SMTP_PORT", 587),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "Horizon <no-reply@localhost>"),
+		},
+		PublicURL:           getEnv("PUBLIC_URL", "http://localhost:"+getEnv("SERVER_PORT", "8080")),
+		AppURL:              getEnv("APP_URL", "http://localhost:5173"),
+		DigestCheckInterval: getEnvAsDuration("DIGEST_CHECK_INTERVAL", 5*time.Minute),
+		WebhookPollInterval: getEnvAsDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+
+		WebhookAllowPrivateNetworks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", env != "production"),
+
+		SuggestionCheckInterval:   getEnvAsDuration("SUGGESTION_CHECK_INTERVAL", time.Minute),
+		SuggestionRefreshInterval: getEnvAsDuration("SUGGESTION_REFRESH_INTERVAL", 6*time.Hour),
 	}
 }
 
@@ -123,6 +187,19 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return value
 }
 
+// getEnvAsDuration gets an environment variable as a duration (e.g. "30m") or returns a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(valueStr)
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	return value
+}
+
 // maskString masks a string for logging, showing only the first and last 4 characters
 func maskString(s string) string {
 	if len(s) < 8 {
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_posts_created_id;
+DROP INDEX IF EXISTS idx_posts_user_created_id;
+DROP INDEX IF EXISTS idx_posts_parent_created_id;
+DROP INDEX IF EXISTS idx_post_likes_user_created;
+DROP INDEX IF EXISTS idx_bookmarks_user_created;
+DROP INDEX IF EXISTS idx_follows_followed_created;
+DROP INDEX IF EXISTS idx_follows_follower_created;
+DROP INDEX IF EXISTS idx_notifications_user_created_id;
+DROP INDEX IF EXISTS idx_post_hashtags_tag_created;
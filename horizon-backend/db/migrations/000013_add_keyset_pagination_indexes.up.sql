@@ -0,0 +1,10 @@
+-- Composite indexes backing (created_at, id) keyset pagination on list endpoints
+CREATE INDEX idx_posts_created_id ON posts(created_at DESC, id DESC);
+CREATE INDEX idx_posts_user_created_id ON posts(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_posts_parent_created_id ON posts(reply_to_post_id, created_at DESC, id DESC);
+CREATE INDEX idx_post_likes_user_created ON post_likes(user_id, created_at DESC, post_id DESC);
+CREATE INDEX idx_bookmarks_user_created ON bookmarks(user_id, created_at DESC, post_id DESC);
+CREATE INDEX idx_follows_followed_created ON follows(followed_id, created_at DESC, follower_id DESC);
+CREATE INDEX idx_follows_follower_created ON follows(follower_id, created_at DESC, followed_id DESC);
+CREATE INDEX idx_notifications_user_created_id ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_post_hashtags_tag_created ON post_hashtags(hashtag, created_at DESC);
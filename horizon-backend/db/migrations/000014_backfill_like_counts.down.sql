@@ -0,0 +1 @@
+-- The backfilled counts are correct; there's nothing to undo
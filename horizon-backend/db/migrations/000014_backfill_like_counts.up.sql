@@ -0,0 +1,3 @@
+-- like_count was only ever decremented; bring it in line with the post_likes table
+UPDATE posts p
+SET like_count = (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = p.id);
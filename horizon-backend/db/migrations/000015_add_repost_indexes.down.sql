@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_reposts_post_created;
+DROP INDEX IF EXISTS idx_reposts_reposter_created;
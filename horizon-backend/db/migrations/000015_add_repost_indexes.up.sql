@@ -0,0 +1,7 @@
+-- Indexes backing repost timelines and the who-reposted listing
+CREATE INDEX idx_reposts_reposter_created ON reposts(reposter_id, created_at DESC, post_id DESC);
+CREATE INDEX idx_reposts_post_created ON reposts(post_id, created_at DESC, reposter_id DESC);
+
+-- repost_count was never maintained; bring it in line with the reposts table
+UPDATE posts p
+SET repost_count = (SELECT COUNT(*) FROM reposts r WHERE r.post_id = p.id);
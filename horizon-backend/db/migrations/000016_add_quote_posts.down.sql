@@ -0,0 +1,6 @@
+-- Postgres can't drop an enum value, so 'quote' stays in notification_type
+DELETE FROM notifications WHERE type = 'quote';
+
+DROP INDEX IF EXISTS idx_posts_quoted_created_id;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_quote_check;
+ALTER TABLE posts DROP COLUMN IF EXISTS quoted_post_id;
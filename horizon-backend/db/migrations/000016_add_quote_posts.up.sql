@@ -0,0 +1,6 @@
+ALTER TABLE posts ADD COLUMN quoted_post_id UUID REFERENCES posts(id) ON DELETE SET NULL;
+ALTER TABLE posts ADD CONSTRAINT posts_quote_check CHECK (id <> quoted_post_id);
+
+CREATE INDEX idx_posts_quoted_created_id ON posts(quoted_post_id, created_at DESC, id DESC) WHERE quoted_post_id IS NOT NULL;
+
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'quote';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_conversation_created;
+ALTER TABLE posts DROP COLUMN IF EXISTS conversation_id;
@@ -0,0 +1,18 @@
+-- conversation_id points at the root post of a reply chain; root posts point at themselves
+ALTER TABLE posts ADD COLUMN conversation_id UUID REFERENCES posts(id) ON DELETE SET NULL;
+
+WITH RECURSIVE chains AS (
+    SELECT id, id AS root_id
+    FROM posts
+    WHERE reply_to_post_id IS NULL
+    UNION ALL
+    SELECT p.id, c.root_id
+    FROM posts p
+    JOIN chains c ON p.reply_to_post_id = c.id
+)
+UPDATE posts
+SET conversation_id = chains.root_id
+FROM chains
+WHERE posts.id = chains.id;
+
+CREATE INDEX idx_posts_conversation_created ON posts(conversation_id, created_at);
@@ -0,0 +1,6 @@
+ALTER TABLE posts ADD COLUMN allow_replies BOOLEAN NOT NULL DEFAULT true;
+
+UPDATE posts SET allow_replies = false WHERE reply_policy <> 'everyone';
+
+ALTER TABLE posts DROP COLUMN reply_policy;
+DROP TYPE IF EXISTS reply_policy;
@@ -0,0 +1,8 @@
+CREATE TYPE reply_policy AS ENUM ('everyone', 'following', 'mentioned');
+
+ALTER TABLE posts ADD COLUMN reply_policy reply_policy NOT NULL DEFAULT 'everyone';
+
+-- Posts that didn't allow replies keep only the mentioned users able to reply
+UPDATE posts SET reply_policy = 'mentioned' WHERE allow_replies = false;
+
+ALTER TABLE posts DROP COLUMN allow_replies;
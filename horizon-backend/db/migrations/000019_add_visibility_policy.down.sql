@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS can_view_post(UUID, UUID, BOOLEAN, BOOLEAN);
+DROP FUNCTION IF EXISTS can_view_account(UUID, UUID, BOOLEAN);
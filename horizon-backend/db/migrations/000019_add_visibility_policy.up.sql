@@ -0,0 +1,27 @@
+-- Single source of truth for who may see an account's content. Public
+-- content is visible to everyone; private content only to its owner and
+-- to accepted followers. Anonymous viewers (NULL) only see public content.
+CREATE OR REPLACE FUNCTION can_view_account(viewer_id UUID, account_id UUID, is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT NOT is_private
+        OR COALESCE(account_id = viewer_id, false)
+        OR EXISTS (
+            SELECT 1 FROM follows f
+            WHERE f.follower_id = viewer_id
+            AND f.followed_id = account_id
+            AND f.is_accepted = true
+        )
+$$;
+
+-- A post is private when either the post itself or its author's account is
+-- private
+CREATE OR REPLACE FUNCTION can_view_post(viewer_id UUID, author_id UUID, post_is_private BOOLEAN, author_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT can_view_account(viewer_id, author_id, post_is_private OR author_is_private)
+$$;
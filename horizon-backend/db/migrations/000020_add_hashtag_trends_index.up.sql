@@ -0,0 +1,2 @@
+-- Trending hashtags scan recent usage across all tags
+CREATE INDEX idx_post_hashtags_created ON post_hashtags(created_at);
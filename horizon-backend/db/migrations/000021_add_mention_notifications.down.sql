@@ -0,0 +1,2 @@
+-- Postgres can't drop an enum value, so 'mention' stays in notification_type
+DELETE FROM notifications WHERE type = 'mention';
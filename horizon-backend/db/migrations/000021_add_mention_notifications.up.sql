@@ -0,0 +1 @@
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'mention';
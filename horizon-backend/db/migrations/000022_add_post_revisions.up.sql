@@ -0,0 +1,13 @@
+ALTER TABLE posts ADD COLUMN edited_at TIMESTAMPTZ;
+ALTER TABLE posts ADD COLUMN edit_count INTEGER NOT NULL DEFAULT 0;
+
+-- Previous versions of a post's content. created_at is when that version was
+-- written: the post's creation time for the original, the edit time after.
+CREATE TABLE post_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_post_revisions_post_created_id ON post_revisions(post_id, created_at DESC, id DESC);
@@ -0,0 +1,6 @@
+DROP FUNCTION IF EXISTS can_message_account(UUID, UUID, BOOLEAN);
+
+DROP INDEX IF EXISTS idx_messages_receiver_unread;
+DROP INDEX IF EXISTS idx_messages_conversation_created_id;
+DROP INDEX IF EXISTS idx_messages_receiver_created_id;
+DROP INDEX IF EXISTS idx_messages_sender_created_id;
@@ -0,0 +1,36 @@
+-- Indexes backing the conversation list, message history and unread counts
+CREATE INDEX idx_messages_sender_created_id ON messages(sender_id, created_at DESC, id DESC);
+CREATE INDEX idx_messages_receiver_created_id ON messages(receiver_id, created_at DESC, id DESC);
+CREATE INDEX idx_messages_conversation_created_id ON messages(
+    LEAST(sender_id, receiver_id),
+    GREATEST(sender_id, receiver_id),
+    created_at DESC,
+    id DESC
+);
+CREATE INDEX idx_messages_receiver_unread ON messages(receiver_id, sender_id) WHERE read = false;
+
+-- Single source of truth for who may send a direct message to an account.
+-- Public accounts accept messages from anyone. Private accounts accept them
+-- from accepted followers, from accounts they follow and from people they
+-- have already messaged, so they can always get replies.
+CREATE OR REPLACE FUNCTION can_message_account(from_id UUID, to_id UUID, to_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(from_id <> to_id, false)
+        AND (
+            can_view_account(from_id, to_id, to_is_private)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = to_id
+                AND f.followed_id = from_id
+                AND f.is_accepted = true
+            )
+            OR EXISTS (
+                SELECT 1 FROM messages m
+                WHERE m.sender_id = to_id
+                AND m.receiver_id = from_id
+            )
+        )
+$$;
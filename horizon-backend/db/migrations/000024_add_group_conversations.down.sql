@@ -0,0 +1,63 @@
+-- Group conversations can't be represented as sender/receiver pairs and are
+-- dropped
+ALTER TABLE messages ADD COLUMN receiver_id UUID REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE messages ADD COLUMN read BOOLEAN NOT NULL DEFAULT false;
+
+DELETE FROM messages m
+USING conversations c
+WHERE m.conversation_id = c.id
+AND c.is_group;
+
+UPDATE messages m
+SET receiver_id = CASE WHEN m.sender_id = c.direct_user1_id THEN c.direct_user2_id ELSE c.direct_user1_id END
+FROM conversations c
+WHERE m.conversation_id = c.id;
+
+UPDATE messages m
+SET read = true
+FROM conversation_members cm
+WHERE cm.conversation_id = m.conversation_id
+AND cm.user_id = m.receiver_id
+AND m.created_at <= cm.last_read_at;
+
+ALTER TABLE messages ALTER COLUMN receiver_id SET NOT NULL;
+ALTER TABLE messages ADD CONSTRAINT messages_check CHECK (sender_id <> receiver_id);
+
+DROP INDEX IF EXISTS idx_messages_conversation_id_created;
+ALTER TABLE messages DROP COLUMN conversation_id;
+
+CREATE INDEX idx_messages_conversation ON messages(LEAST(sender_id, receiver_id), GREATEST(sender_id, receiver_id), created_at);
+CREATE INDEX idx_messages_receiver_created_id ON messages(receiver_id, created_at DESC, id DESC);
+CREATE INDEX idx_messages_conversation_created_id ON messages(
+    LEAST(sender_id, receiver_id),
+    GREATEST(sender_id, receiver_id),
+    created_at DESC,
+    id DESC
+);
+CREATE INDEX idx_messages_receiver_unread ON messages(receiver_id, sender_id) WHERE read = false;
+
+DROP TABLE IF EXISTS conversation_members;
+DROP TABLE IF EXISTS conversations;
+DROP TYPE IF EXISTS conversation_role;
+
+CREATE OR REPLACE FUNCTION can_message_account(from_id UUID, to_id UUID, to_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(from_id <> to_id, false)
+        AND (
+            can_view_account(from_id, to_id, to_is_private)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = to_id
+                AND f.followed_id = from_id
+                AND f.is_accepted = true
+            )
+            OR EXISTS (
+                SELECT 1 FROM messages m
+                WHERE m.sender_id = to_id
+                AND m.receiver_id = from_id
+            )
+        )
+$$;
@@ -0,0 +1,105 @@
+CREATE TYPE conversation_role AS ENUM ('owner', 'member');
+
+-- A conversation is either one-to-one or a group. One-to-one conversations
+-- are unique per pair of users, stored with the smaller id first; only
+-- groups have a title.
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    is_group BOOLEAN NOT NULL DEFAULT false,
+    title VARCHAR(100),
+    direct_user1_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    direct_user2_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT conversations_kind_check CHECK (
+        (is_group AND direct_user1_id IS NULL AND direct_user2_id IS NULL)
+        OR (NOT is_group AND title IS NULL AND direct_user1_id < direct_user2_id)
+    ),
+    CONSTRAINT conversations_direct_users_key UNIQUE (direct_user1_id, direct_user2_id)
+);
+
+CREATE INDEX idx_conversations_activity_id ON conversations(last_activity_at DESC, id DESC);
+
+-- Members of a conversation. last_read_at is the member's read cursor:
+-- messages created after it are unread.
+CREATE TABLE conversation_members (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role conversation_role NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_read_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    last_read_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX idx_conversation_members_user ON conversation_members(user_id);
+
+-- Existing messages become one-to-one conversations
+INSERT INTO conversations (direct_user1_id, direct_user2_id, created_at, updated_at, last_activity_at)
+SELECT LEAST(sender_id, receiver_id), GREATEST(sender_id, receiver_id), MIN(created_at), MIN(created_at), MAX(created_at)
+FROM messages
+GROUP BY LEAST(sender_id, receiver_id), GREATEST(sender_id, receiver_id);
+
+ALTER TABLE messages ADD COLUMN conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE;
+
+UPDATE messages m
+SET conversation_id = c.id
+FROM conversations c
+WHERE c.direct_user1_id = LEAST(m.sender_id, m.receiver_id)
+AND c.direct_user2_id = GREATEST(m.sender_id, m.receiver_id);
+
+-- Each member's read cursor sits just before the first message they haven't
+-- read, or at the latest message if they've read them all
+INSERT INTO conversation_members (conversation_id, user_id, joined_at, last_read_at)
+SELECT c.id, member.user_id, c.created_at, COALESCE(
+    (
+        SELECT MIN(m.created_at) - INTERVAL '1 microsecond'
+        FROM messages m
+        WHERE m.conversation_id = c.id
+        AND m.receiver_id = member.user_id
+        AND m.read = false
+    ),
+    c.last_activity_at
+)
+FROM conversations c
+CROSS JOIN LATERAL (VALUES (c.direct_user1_id), (c.direct_user2_id)) AS member(user_id);
+
+ALTER TABLE messages ALTER COLUMN conversation_id SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_messages_conversation;
+DROP INDEX IF EXISTS idx_messages_conversation_created_id;
+DROP INDEX IF EXISTS idx_messages_receiver_created_id;
+DROP INDEX IF EXISTS idx_messages_receiver_unread;
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_check;
+ALTER TABLE messages DROP COLUMN receiver_id;
+ALTER TABLE messages DROP COLUMN read;
+
+CREATE INDEX idx_messages_conversation_id_created ON messages(conversation_id, created_at DESC, id DESC);
+
+-- People a private account has already messaged are those it has sent a
+-- message to in a one-to-one conversation
+CREATE OR REPLACE FUNCTION can_message_account(from_id UUID, to_id UUID, to_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(from_id <> to_id, false)
+        AND (
+            can_view_account(from_id, to_id, to_is_private)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = to_id
+                AND f.followed_id = from_id
+                AND f.is_accepted = true
+            )
+            OR EXISTS (
+                SELECT 1 FROM conversations c
+                JOIN messages m ON m.conversation_id = c.id
+                WHERE c.direct_user1_id = LEAST(from_id, to_id)
+                AND c.direct_user2_id = GREATEST(from_id, to_id)
+                AND m.sender_id = to_id
+            )
+        )
+$$;
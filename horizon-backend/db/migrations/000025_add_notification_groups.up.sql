@@ -0,0 +1,62 @@
+-- Notifications of the same type about the same post that arrive within a
+-- window are collapsed into a group. Groups carry the read state.
+CREATE TABLE notification_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    post_id UUID REFERENCES posts(id),
+    -- Notifications with the same key join the group while its window is
+    -- open; NULL for types that are never grouped
+    group_key TEXT,
+    read BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_groups_user_updated ON notification_groups(user_id, updated_at DESC, id DESC);
+CREATE INDEX idx_notification_groups_open ON notification_groups(user_id, group_key, created_at DESC) WHERE group_key IS NOT NULL;
+CREATE INDEX idx_notification_groups_unread ON notification_groups(user_id) WHERE read = false;
+
+ALTER TABLE notifications ADD COLUMN group_id UUID REFERENCES notification_groups(id) ON DELETE CASCADE;
+
+-- Group existing likes, reposts and follows by day. Each group takes the id
+-- of its first notification.
+WITH keyed AS (
+    SELECT
+        n.id,
+        n.user_id,
+        n.type,
+        n.post_id,
+        n.read,
+        n.created_at,
+        CASE
+            WHEN n.type IN ('like', 'repost') THEN n.type || ':' || n.post_id
+            WHEN n.type = 'follow' THEN 'follow'
+        END AS group_key,
+        FIRST_VALUE(n.id) OVER (
+            PARTITION BY
+                n.user_id,
+                CASE
+                    WHEN n.type IN ('like', 'repost') THEN n.type || ':' || n.post_id
+                    WHEN n.type = 'follow' THEN 'follow'
+                    ELSE n.id::text
+                END,
+                date_trunc('day', n.created_at)
+            ORDER BY n.created_at, n.id
+        ) AS group_id
+    FROM notifications n
+),
+new_groups AS (
+    INSERT INTO notification_groups (id, user_id, type, post_id, group_key, read, created_at, updated_at)
+    SELECT group_id, user_id, type, post_id, group_key, bool_and(read), MIN(created_at), MAX(created_at)
+    FROM keyed
+    GROUP BY group_id, user_id, type, post_id, group_key
+)
+UPDATE notifications n
+SET group_id = k.group_id
+FROM keyed k
+WHERE k.id = n.id;
+
+ALTER TABLE notifications ALTER COLUMN group_id SET NOT NULL;
+
+CREATE INDEX idx_notifications_group_created ON notifications(group_id, created_at DESC, id DESC);
@@ -0,0 +1,14 @@
+-- How a user receives each type of notification. Types without a row use
+-- the defaults: enabled, from anyone, not muted.
+CREATE TABLE notification_settings (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    -- Disabled notifications aren't created
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    -- Only create notifications caused by accounts the user follows
+    only_from_following BOOLEAN NOT NULL DEFAULT false,
+    -- Muted notifications are listed but arrive read and aren't pushed
+    muted BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, type)
+);
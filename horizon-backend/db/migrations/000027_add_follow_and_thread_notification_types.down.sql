@@ -0,0 +1,3 @@
+-- Postgres can't drop an enum value, so the new types stay in notification_type
+DELETE FROM notification_settings WHERE type IN ('follow_request', 'follow_accepted', 'thread_reply');
+DELETE FROM notification_groups WHERE type IN ('follow_request', 'follow_accepted', 'thread_reply');
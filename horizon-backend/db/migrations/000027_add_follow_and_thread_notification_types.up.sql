@@ -0,0 +1,3 @@
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'follow_request';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'follow_accepted';
+ALTER TYPE notification_type ADD VALUE IF NOT EXISTS 'thread_reply';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS email_digest_settings;
+DROP TYPE IF EXISTS digest_frequency;
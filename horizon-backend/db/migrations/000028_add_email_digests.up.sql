@@ -0,0 +1,19 @@
+CREATE TYPE digest_frequency AS ENUM ('off', 'daily', 'weekly');
+
+-- When each user is emailed a digest of their unread notifications. hour is
+-- the local hour in timezone; weekday (0 = Sunday) only applies to weekly
+-- digests.
+CREATE TABLE email_digest_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    frequency digest_frequency NOT NULL DEFAULT 'off',
+    hour INTEGER NOT NULL DEFAULT 9 CHECK (hour BETWEEN 0 AND 23),
+    weekday INTEGER NOT NULL DEFAULT 1 CHECK (weekday BETWEEN 0 AND 6),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    unsubscribe_token TEXT NOT NULL UNIQUE,
+    last_sent_at TIMESTAMPTZ,
+    next_send_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_digest_settings_due ON email_digest_settings (next_send_at) WHERE frequency <> 'off';
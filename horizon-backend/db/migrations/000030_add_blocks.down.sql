@@ -0,0 +1,41 @@
+CREATE OR REPLACE FUNCTION can_view_account(viewer_id UUID, account_id UUID, is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT NOT is_private
+        OR COALESCE(account_id = viewer_id, false)
+        OR EXISTS (
+            SELECT 1 FROM follows f
+            WHERE f.follower_id = viewer_id
+            AND f.followed_id = account_id
+            AND f.is_accepted = true
+        )
+$$;
+
+CREATE OR REPLACE FUNCTION can_message_account(from_id UUID, to_id UUID, to_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(from_id <> to_id, false)
+        AND (
+            can_view_account(from_id, to_id, to_is_private)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = to_id
+                AND f.followed_id = from_id
+                AND f.is_accepted = true
+            )
+            OR EXISTS (
+                SELECT 1 FROM conversations c
+                JOIN messages m ON m.conversation_id = c.id
+                WHERE c.direct_user1_id = LEAST(from_id, to_id)
+                AND c.direct_user2_id = GREATEST(from_id, to_id)
+                AND m.sender_id = to_id
+            )
+        )
+$$;
+
+DROP FUNCTION IF EXISTS is_blocked_between(UUID, UUID);
+DROP TABLE IF EXISTS blocks;
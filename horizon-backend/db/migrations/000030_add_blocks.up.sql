@@ -0,0 +1,78 @@
+-- Blocks. A blocked account and its blocker can't see each other's content,
+-- follow, reply to, mention, like or message each other.
+CREATE TABLE blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT blocks_check CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked ON blocks (blocked_id, blocker_id);
+CREATE INDEX idx_blocks_blocker_created ON blocks (blocker_id, created_at DESC, blocked_id DESC);
+
+-- Whether either of two accounts has blocked the other. Accounts on either
+-- side of a block don't see or interact with each other.
+CREATE OR REPLACE FUNCTION is_blocked_between(user_a UUID, user_b UUID)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM blocks b
+        WHERE (b.blocker_id = user_a AND b.blocked_id = user_b)
+        OR (b.blocker_id = user_b AND b.blocked_id = user_a)
+    )
+$$;
+
+-- Single source of truth for who may see an account's content. Public
+-- content is visible to everyone; private content only to its owner and
+-- to accepted followers. Anonymous viewers (NULL) only see public content.
+-- Nothing is visible across a block.
+CREATE OR REPLACE FUNCTION can_view_account(viewer_id UUID, account_id UUID, is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT (
+            NOT is_private
+            OR COALESCE(account_id = viewer_id, false)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = viewer_id
+                AND f.followed_id = account_id
+                AND f.is_accepted = true
+            )
+        )
+        AND NOT is_blocked_between(viewer_id, account_id)
+$$;
+
+-- Single source of truth for who may send a direct message to an account.
+-- Public accounts accept messages from anyone. Private accounts accept them
+-- from accepted followers, from accounts they follow and from people they
+-- have already messaged one-to-one, so they can always get replies. No one
+-- can message across a block.
+CREATE OR REPLACE FUNCTION can_message_account(from_id UUID, to_id UUID, to_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(from_id <> to_id, false)
+        AND NOT is_blocked_between(from_id, to_id)
+        AND (
+            can_view_account(from_id, to_id, to_is_private)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = to_id
+                AND f.followed_id = from_id
+                AND f.is_accepted = true
+            )
+            OR EXISTS (
+                SELECT 1 FROM conversations c
+                JOIN messages m ON m.conversation_id = c.id
+                WHERE c.direct_user1_id = LEAST(from_id, to_id)
+                AND c.direct_user2_id = GREATEST(from_id, to_id)
+                AND m.sender_id = to_id
+            )
+        )
+$$;
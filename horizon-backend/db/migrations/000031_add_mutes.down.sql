@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS conversation_mutes;
+DROP FUNCTION IF EXISTS is_account_muted(UUID, UUID);
+DROP TABLE IF EXISTS user_mutes;
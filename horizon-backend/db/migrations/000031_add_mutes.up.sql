@@ -0,0 +1,37 @@
+-- Muted accounts. Their posts are left out of the muter's home and hashtag
+-- timelines until the mute expires; mutes without an expiry last until
+-- they're lifted.
+CREATE TABLE user_mutes (
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (muter_id, muted_id),
+    CONSTRAINT user_mutes_check CHECK (muter_id <> muted_id)
+);
+
+CREATE INDEX idx_user_mutes_muter_created ON user_mutes (muter_id, created_at DESC, muted_id DESC);
+
+-- Whether a user has muted an account, counting only mutes that haven't
+-- expired
+CREATE OR REPLACE FUNCTION is_account_muted(user_id UUID, account_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM user_mutes m
+        WHERE m.muter_id = user_id
+        AND m.muted_id = account_id
+        AND (m.expires_at IS NULL OR m.expires_at > NOW())
+    )
+$$;
+
+-- Muted threads, keyed by their root post. Replies in them don't notify the
+-- user.
+CREATE TABLE conversation_mutes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, conversation_id)
+);
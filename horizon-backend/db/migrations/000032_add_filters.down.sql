@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS filter_settings;
+DROP TYPE IF EXISTS filter_action;
+DROP TABLE IF EXISTS filters;
@@ -0,0 +1,26 @@
+-- Muted words and phrases. A filter matches its phrase, or the regular
+-- expression in it when is_regex is set, in each of its scopes until it
+-- expires.
+CREATE TABLE filters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phrase TEXT NOT NULL,
+    is_regex BOOLEAN NOT NULL DEFAULT false,
+    whole_word BOOLEAN NOT NULL DEFAULT true,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_filters_user ON filters (user_id, created_at DESC, id DESC);
+
+CREATE TYPE filter_action AS ENUM ('hide', 'warn');
+
+-- What happens to content a user's filters match: hidden outright, or kept
+-- and marked as filtered
+CREATE TABLE filter_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    action filter_action NOT NULL DEFAULT 'hide',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS follow_suggestion_refreshes;
+DROP TABLE IF EXISTS follow_suggestions;
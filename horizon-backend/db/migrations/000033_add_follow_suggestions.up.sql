@@ -0,0 +1,24 @@
+-- Precomputed who-to-follow suggestions, best first by score. mutual_ids
+-- holds a few of the mutual_count accounts the user follows that follow the
+-- suggestion, to explain it.
+CREATE TABLE follow_suggestions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    suggested_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    mutual_count INTEGER NOT NULL DEFAULT 0,
+    mutual_ids UUID[] NOT NULL DEFAULT '{}',
+    shared_follower_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, suggested_id),
+    CONSTRAINT follow_suggestions_check CHECK (user_id <> suggested_id)
+);
+
+CREATE INDEX idx_follow_suggestions_user_score ON follow_suggestions (user_id, score DESC, suggested_id);
+
+-- When each user's suggestions were last computed
+CREATE TABLE follow_suggestion_refreshes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    refreshed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_follow_suggestion_refreshes_refreshed ON follow_suggestion_refreshes (refreshed_at);
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"net/http"
+
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type BlockController struct {
+	blockService *service.BlockService
+	userService  *service.UserService
+}
+
+func NewBlockController(blockService *service.BlockService, userService *service.UserService) *BlockController {
+	return &BlockController{
+		blockService: blockService,
+		userService:  userService,
+	}
+}
+
+// BlockUser handles POST /api/users/:username/block
+func (c *BlockController) BlockUser(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get user to block
+	user, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	if err := c.blockService.BlockUser(ctx.Request().Context(), currentUser.ID, user.ID); err != nil {
+		if err.Error() == "cannot block yourself" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to block user")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// UnblockUser handles DELETE /api/users/:username/block
+func (c *BlockController) UnblockUser(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get user to unblock
+	user, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	if err := c.blockService.UnblockUser(ctx.Request().Context(), currentUser.ID, user.ID); err != nil {
+		if err.Error() == "block not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "you haven't blocked this user")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unblock user")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// GetBlockedUsers handles GET /api/users/me/blocks
+func (c *BlockController) GetBlockedUsers(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	blocked, err := c.blockService.GetBlockedUsers(ctx.Request().Context(), currentUser.ID, page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get blocked users")
+	}
+
+	return ctx.JSON(http.StatusOK, blocked)
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	hmiddleware "horizon-backend/internal/middleware"
+	"horizon-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unsubscribeConfirmPage asks for confirmation before unsubscribing, so link
+// scanners that follow the email's links don't unsubscribe anyone. The form
+// posts back to the same URL, token included.
+const unsubscribeConfirmPage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe from Horizon digests</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:48px auto;padding:0 16px;">
+<h1 style="font-size:20px;">Unsubscribe from Horizon digests?</h1>
+<p>You'll stop getting email digests of your notifications. You can turn them back on in your settings.</p>
+<form method="post"><button type="submit">Unsubscribe</button></form>
+</body>
+</html>`
+
+const unsubscribeDonePage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribed from Horizon digests</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:48px auto;padding:0 16px;">
+<h1 style="font-size:20px;">You've been unsubscribed</h1>
+<p>You won't get any more email digests. You can turn them back on in your settings.</p>
+</body>
+</html>`
+
+type DigestController struct {
+	digestService *service.DigestService
+}
+
+func NewDigestController(digestService *service.DigestService) *DigestController {
+	return &DigestController{
+		digestService: digestService,
+	}
+}
+
+// GetSettings handles GET /api/users/me/settings/email-digest
+func (c *DigestController) GetSettings(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	settings, err := c.digestService.GetSettings(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get digest settings")
+	}
+
+	return ctx.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /api/users/me/settings/email-digest. Fields left
+// out of the request keep their current values.
+func (c *DigestController) UpdateSettings(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	settings, err := c.digestService.GetSettings(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update digest settings")
+	}
+
+	// Decoding onto the current settings keeps the fields not given
+	if err := ctx.Bind(settings); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	updated, err := c.digestService.UpdateSettings(ctx.Request().Context(), userID.Bytes, *settings)
+	if err != nil {
+		switch err.Error() {
+		case "invalid digest frequency", "invalid digest hour", "invalid digest weekday", "invalid timezone":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to update digest settings")
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+// SendTest handles POST /api/users/me/settings/email-digest/test. It emails
+// the user their digest right away.
+func (c *DigestController) SendTest(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.digestService.SendTestDigest(ctx.Request().Context(), userID.Bytes); err != nil {
+		if err.Error() == "email is not configured" {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "email is not configured")
+		}
+		log.Printf("Error sending test digest: %v", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to send digest")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ConfirmUnsubscribe handles GET /api/email/unsubscribe, the unsubscribe link
+// in digest emails
+func (c *DigestController) ConfirmUnsubscribe(ctx echo.Context) error {
+	if ctx.QueryParam("token") == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid unsubscribe token")
+	}
+	return ctx.HTML(http.StatusOK, unsubscribeConfirmPage)
+}
+
+// Unsubscribe handles POST /api/email/unsubscribe, from the confirmation page
+// or a mail client's one-click unsubscribe
+func (c *DigestController) Unsubscribe(ctx echo.Context) error {
+	err := c.digestService.Unsubscribe(ctx.Request().Context(), ctx.QueryParam("token"))
+	if err != nil {
+		if err.Error() == "invalid unsubscribe token" {
+			return echo.NewHTTPError(http.StatusNotFound, "invalid unsubscribe token")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unsubscribe")
+	}
+
+	// Mail clients unsubscribing in the background don't need a page
+	if strings.HasPrefix(ctx.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationForm) && ctx.FormValue("List-Unsubscribe") == "One-Click" {
+		return ctx.NoContent(http.StatusOK)
+	}
+
+	return ctx.HTML(http.StatusOK, unsubscribeDonePage)
+}
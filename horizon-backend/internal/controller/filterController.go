@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	hmiddleware "horizon-backend/internal/middleware"
+	"horizon-backend/internal/model"
+	"horizon-backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+type FilterController struct {
+	filterService *service.FilterService
+}
+
+func NewFilterController(filterService *service.FilterService) *FilterController {
+	return &FilterController{
+		filterService: filterService,
+	}
+}
+
+// GetFilters handles GET /api/filters
+func (c *FilterController) GetFilters(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	filters, err := c.filterService.GetFilters(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get filters")
+	}
+
+	return ctx.JSON(http.StatusOK, filters)
+}
+
+// CreateFilter handles POST /api/filters. Plain phrases match whole words
+// unless whole_word is false.
+func (c *FilterController) CreateFilter(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	request := model.Filter{WholeWord: true}
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	filter, err := c.filterService.CreateFilter(ctx.Request().Context(), userID.Bytes, request)
+	if err != nil {
+		return filterError(err)
+	}
+
+	return ctx.JSON(http.StatusCreated, filter)
+}
+
+// GetFilter handles GET /api/filters/:id
+func (c *FilterController) GetFilter(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var filterID pgtype.UUID
+	if err := filterID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid filter ID format")
+	}
+
+	filter, err := c.filterService.GetFilter(ctx.Request().Context(), userID.Bytes, filterID.Bytes)
+	if err != nil {
+		return filterError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, filter)
+}
+
+// UpdateFilter handles PUT /api/filters/:id. Fields left out of the request
+// keep their current values.
+func (c *FilterController) UpdateFilter(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var filterID pgtype.UUID
+	if err := filterID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid filter ID format")
+	}
+
+	filter, err := c.filterService.GetFilter(ctx.Request().Context(), userID.Bytes, filterID.Bytes)
+	if err != nil {
+		return filterError(err)
+	}
+
+	// Decoding onto the current values keeps the fields not given
+	request := *filter
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	updated, err := c.filterService.UpdateFilter(ctx.Request().Context(), userID.Bytes, filterID.Bytes, request)
+	if err != nil {
+		return filterError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteFilter handles DELETE /api/filters/:id
+func (c *FilterController) DeleteFilter(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var filterID pgtype.UUID
+	if err := filterID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid filter ID format")
+	}
+
+	if err := c.filterService.DeleteFilter(ctx.Request().Context(), userID.Bytes, filterID.Bytes); err != nil {
+		return filterError(err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// GetSettings handles GET /api/users/me/settings/filters
+func (c *FilterController) GetSettings(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	settings, err := c.filterService.GetSettings(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get filter settings")
+	}
+
+	return ctx.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /api/users/me/settings/filters
+func (c *FilterController) UpdateSettings(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var request model.FilterSettings
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	updated, err := c.filterService.UpdateSettings(ctx.Request().Context(), userID.Bytes, request)
+	if err != nil {
+		if err.Error() == "invalid filter action" {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update filter settings")
+	}
+
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+func filterError(err error) error {
+	switch {
+	case err.Error() == "phrase is required", err.Error() == "invalid regular expression",
+		err.Error() == "at least one scope is required", err.Error() == "filter expiry must be in the future",
+		strings.HasPrefix(err.Error(), "invalid filter scope:"):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case err.Error() == "phrase is too long":
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("phrase must be at most %d characters", model.MaxFilterPhraseLength))
+	case err.Error() == "too many filters":
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("a user can have at most %d filters", model.MaxFilters))
+	case err.Error() == "filter not found":
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to manage filter")
+	}
+}
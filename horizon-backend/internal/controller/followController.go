@@ -3,9 +3,9 @@ package controller
 import (
 	"fmt"
 	"net/http"
-	"strconv"
 
 	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
 	"horizon-backend/internal/service"
 
 	"github.com/labstack/echo/v4"
@@ -32,31 +32,6 @@ func GetUserFromContext(ctx echo.Context) (*model.User, error) {
 	return user, nil
 }
 
-// GetPaginationParams gets pagination parameters from the request
-func GetPaginationParams(ctx echo.Context) (limit, offset int32) {
-	limitStr := ctx.QueryParam("limit")
-	offsetStr := ctx.QueryParam("offset")
-
-	limit = 10 // Default limit
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = int32(parsedLimit)
-			if limit > 50 {
-				limit = 50 // Cap at 50
-			}
-		}
-	}
-
-	offset = 0 // Default offset
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
-		}
-	}
-
-	return limit, offset
-}
-
 // FollowUser handles the follow user request
 func (c *FollowController) FollowUser(ctx echo.Context) error {
 	// Get current user from context
@@ -80,6 +55,9 @@ func (c *FollowController) FollowUser(ctx echo.Context) error {
 	// Follow the user
 	response, err := c.followService.FollowUser(ctx.Request().Context(), currentUser.ID.Bytes, userToFollow.ID.Bytes)
 	if err != nil {
+		if err.Error() == "cannot follow this user" {
+			return echo.NewHTTPError(http.StatusForbidden, "you can't follow this user")
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to follow user: %v", err))
 	}
 
@@ -117,6 +95,12 @@ func (c *FollowController) UnfollowUser(ctx echo.Context) error {
 
 // GetFollowers handles the get followers request
 func (c *FollowController) GetFollowers(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
 	// Get username from path parameter
 	username := ctx.Param("username")
 	if username == "" {
@@ -130,12 +114,22 @@ func (c *FollowController) GetFollowers(ctx echo.Context) error {
 	}
 
 	// Get pagination parameters
-	limit, offset := GetPaginationParams(ctx)
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
 	// Get followers
-	followers, err := c.followService.GetFollowers(ctx.Request().Context(), user.ID, limit, offset)
+	followers, err := c.followService.GetFollowers(ctx.Request().Context(), currentUser.ID, user.ID, page)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get followers")
+		switch err.Error() {
+		case "account is private":
+			return echo.NewHTTPError(http.StatusForbidden, "this account is private")
+		case "user not found":
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get followers")
+		}
 	}
 
 	return ctx.JSON(http.StatusOK, followers)
@@ -143,6 +137,12 @@ func (c *FollowController) GetFollowers(ctx echo.Context) error {
 
 // GetFollowing handles the get following request
 func (c *FollowController) GetFollowing(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
 	// Get username from path parameter
 	username := ctx.Param("username")
 	if username == "" {
@@ -156,12 +156,22 @@ func (c *FollowController) GetFollowing(ctx echo.Context) error {
 	}
 
 	// Get pagination parameters
-	limit, offset := GetPaginationParams(ctx)
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
 	// Get following
-	following, err := c.followService.GetFollowing(ctx.Request().Context(), user.ID, limit, offset)
+	following, err := c.followService.GetFollowing(ctx.Request().Context(), currentUser.ID, user.ID, page)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get following")
+		switch err.Error() {
+		case "account is private":
+			return echo.NewHTTPError(http.StatusForbidden, "this account is private")
+		case "user not found":
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get following")
+		}
 	}
 
 	return ctx.JSON(http.StatusOK, following)
@@ -183,10 +193,7 @@ func (c *FollowController) GetFollowStatus(ctx echo.Context) error {
 
 	// Check if user is trying to check follow status with themselves
 	if username == currentUser.Username {
-		return ctx.JSON(http.StatusOK, &service.FollowStatus{
-			IsFollowing: false,
-			IsAccepted:  false,
-		})
+		return ctx.JSON(http.StatusOK, &service.FollowStatus{State: service.FollowStateNone})
 	}
 
 	// Get target user
@@ -233,8 +240,99 @@ func (c *FollowController) AcceptFollowRequest(ctx echo.Context) error {
 	// Accept follow request
 	err = c.followService.AcceptFollowRequest(ctx.Request().Context(), followerUser.ID, currentUser.ID)
 	if err != nil {
+		if err.Error() == "follow request not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "follow request not found")
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to accept follow request")
 	}
 
 	return ctx.NoContent(http.StatusOK)
 }
+
+// GetFollowRequests handles GET /api/users/me/follow-requests, the requests
+// waiting for the current user to accept or reject them
+func (c *FollowController) GetFollowRequests(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	requests, err := c.followService.GetPendingFollowRequests(ctx.Request().Context(), currentUser.ID, page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get follow requests")
+	}
+
+	return ctx.JSON(http.StatusOK, requests)
+}
+
+// RejectFollowRequest handles the reject follow request
+func (c *FollowController) RejectFollowRequest(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get username from path parameter
+	username := ctx.Param("username")
+	if username == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "username is required")
+	}
+
+	// Get follower user
+	followerUser, err := c.userService.GetUserByUsername(ctx.Request().Context(), username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	// Reject follow request
+	err = c.followService.RejectFollowRequest(ctx.Request().Context(), followerUser.ID, currentUser.ID)
+	if err != nil {
+		if err.Error() == "follow request not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "follow request not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to reject follow request")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// CancelFollowRequest handles the cancel follow request, withdrawing the
+// current user's request to follow a private account
+func (c *FollowController) CancelFollowRequest(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get username from path parameter
+	username := ctx.Param("username")
+	if username == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "username is required")
+	}
+
+	// Get the user the request was sent to
+	followedUser, err := c.userService.GetUserByUsername(ctx.Request().Context(), username)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	// Cancel follow request
+	err = c.followService.CancelFollowRequest(ctx.Request().Context(), currentUser.ID, followedUser.ID)
+	if err != nil {
+		if err.Error() == "follow request not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "follow request not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to cancel follow request")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -0,0 +1,409 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	hmiddleware "horizon-backend/internal/middleware"
+	"horizon-backend/internal/realtime"
+	"horizon-backend/internal/service"
+	"horizon-backend/internal/util"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// gatewayMaxTopics caps how many topics a connection can subscribe to
+	gatewayMaxTopics = 200
+
+	// gatewayMaxMessageSize caps the size of client messages
+	gatewayMaxMessageSize = 4096
+
+	// gatewayHeartbeat is how often idle connections are pinged
+	gatewayHeartbeat = 25 * time.Second
+
+	// gatewayWriteTimeout is how long a write may block before the client is
+	// considered gone
+	gatewayWriteTimeout = 10 * time.Second
+
+	// homeTimelineTopic is the client topic for new posts in the home timeline
+	homeTimelineTopic = "timeline:home"
+)
+
+// GatewayController serves the realtime WebSocket gateway
+type GatewayController struct {
+	hub            *realtime.Hub
+	postService    *service.PostService
+	followService  *service.FollowService
+	messageService *service.MessageService
+}
+
+func NewGatewayController(hub *realtime.Hub, postService *service.PostService, followService *service.FollowService, messageService *service.MessageService) *GatewayController {
+	return &GatewayController{
+		hub:            hub,
+		postService:    postService,
+		followService:  followService,
+		messageService: messageService,
+	}
+}
+
+// gatewayRequest is a message from the client
+type gatewayRequest struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+}
+
+// gatewayMessage is a message to the client
+type gatewayMessage struct {
+	Type    string `json:"type"`
+	Topic   string `json:"topic,omitempty"`
+	Data    any    `json:"data,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Connect handles GET /api/ws. Clients subscribe to post:<id>,
+// timeline:home and dm:<conversation id> topics and receive their events.
+func (c *GatewayController) Connect(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	server := websocket.Server{
+		// Connections authenticate with a token rather than cookies, so any
+		// origin may connect, as with CORS
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// Services read the viewer from the context
+			reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
+			session := &gatewaySession{
+				controller: c,
+				ctx:        reqCtx,
+				userID:     userID,
+				ws:         ws,
+				sub:        c.hub.Subscribe(),
+				topics:     make(map[string]bool),
+			}
+			defer session.sub.Close()
+
+			session.serve()
+		},
+	}
+	server.ServeHTTP(ctx.Response(), ctx.Request())
+
+	return nil
+}
+
+// gatewaySession is a single client connection
+type gatewaySession struct {
+	controller *GatewayController
+	ctx        context.Context
+	userID     pgtype.UUID
+	ws         *websocket.Conn
+	sub        *realtime.Subscription
+
+	// topics are the client topics subscribed to
+	topics map[string]bool
+
+	// followed are the accounts whose author topics back timeline:home
+	followed map[[16]byte]bool
+}
+
+func (s *gatewaySession) serve() {
+	s.ws.MaxPayloadBytes = gatewayMaxMessageSize
+
+	// Write is only used for heartbeats; messages are sent as JSON
+	s.ws.PayloadType = websocket.PingFrame
+
+	requests := make(chan gatewayRequest)
+	done := make(chan struct{})
+	defer close(done)
+	go s.read(requests, done)
+
+	heartbeat := time.NewTicker(gatewayHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+
+		case <-heartbeat.C:
+			s.ws.SetWriteDeadline(time.Now().Add(gatewayWriteTimeout))
+			if _, err := s.ws.Write(nil); err != nil {
+				return
+			}
+
+		case req, ok := <-requests:
+			if !ok {
+				return
+			}
+			if err := s.handleRequest(req); err != nil {
+				return
+			}
+
+		case event, ok := <-s.sub.Events():
+			if !ok {
+				// Dropped for falling behind; the client reconnects
+				return
+			}
+			if err := s.handleEvent(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read passes client messages to the session until the connection closes
+func (s *gatewaySession) read(requests chan<- gatewayRequest, done <-chan struct{}) {
+	defer close(requests)
+
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(s.ws, &raw); err != nil {
+			return
+		}
+
+		var req gatewayRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			req = gatewayRequest{Type: "invalid"}
+		}
+
+		select {
+		case requests <- req:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (s *gatewaySession) send(msg gatewayMessage) error {
+	s.ws.SetWriteDeadline(time.Now().Add(gatewayWriteTimeout))
+	return websocket.JSON.Send(s.ws, msg)
+}
+
+func (s *gatewaySession) handleRequest(req gatewayRequest) error {
+	switch req.Type {
+	case "subscribe":
+		topic, err := normalizeTopic(req.Topic)
+		if err != nil {
+			return s.send(gatewayMessage{Type: "error", Topic: req.Topic, Message: err.Error()})
+		}
+		if s.topics[topic] {
+			return s.send(gatewayMessage{Type: "subscribed", Topic: topic})
+		}
+		if len(s.topics) >= gatewayMaxTopics {
+			return s.send(gatewayMessage{Type: "error", Topic: topic, Message: "too many subscriptions"})
+		}
+		if err := s.subscribe(topic); err != nil {
+			return s.send(gatewayMessage{Type: "error", Topic: topic, Message: err.Error()})
+		}
+		s.topics[topic] = true
+		return s.send(gatewayMessage{Type: "subscribed", Topic: topic})
+
+	case "unsubscribe":
+		topic, err := normalizeTopic(req.Topic)
+		if err != nil {
+			return s.send(gatewayMessage{Type: "error", Topic: req.Topic, Message: err.Error()})
+		}
+		if s.topics[topic] {
+			s.unsubscribe(topic)
+			delete(s.topics, topic)
+		}
+		return s.send(gatewayMessage{Type: "unsubscribed", Topic: topic})
+
+	case "ping":
+		return s.send(gatewayMessage{Type: "pong"})
+
+	default:
+		return s.send(gatewayMessage{Type: "error", Message: "invalid message"})
+	}
+}
+
+// normalizeTopic returns the canonical form of a client topic, which is also
+// the hub topic for post and conversation topics
+func normalizeTopic(topic string) (string, error) {
+	if topic == homeTimelineTopic {
+		return topic, nil
+	}
+
+	kind, rawID, _ := strings.Cut(topic, ":")
+	id, err := util.GetUUIDFromString(rawID)
+	if err != nil {
+		return "", errGatewayInvalidTopic
+	}
+
+	switch kind {
+	case "post":
+		return realtime.PostTopic(id), nil
+	case "dm":
+		return realtime.ConversationTopic(id), nil
+	default:
+		return "", errGatewayInvalidTopic
+	}
+}
+
+// subscribe checks that the user can see a client topic and subscribes to
+// the hub topics behind it
+func (s *gatewaySession) subscribe(topic string) error {
+	if topic == homeTimelineTopic {
+		followed, err := s.controller.followService.GetFollowedUserIDs(s.ctx, s.userID)
+		if err != nil {
+			log.Printf("Error subscribing to home timeline: %v", err)
+			return errGatewayInternal
+		}
+
+		s.followed = map[[16]byte]bool{s.userID.Bytes: true}
+		for _, id := range followed {
+			s.followed[id.Bytes] = true
+		}
+
+		hubTopics := []string{realtime.UserTopic(s.userID.Bytes)}
+		for id := range s.followed {
+			hubTopics = append(hubTopics, realtime.AuthorTopic(id))
+		}
+		s.sub.Add(hubTopics...)
+		return nil
+	}
+
+	kind, rawID, _ := strings.Cut(topic, ":")
+	id, err := util.GetUUIDFromString(rawID)
+	if err != nil {
+		return errGatewayInvalidTopic
+	}
+	pgID := pgtype.UUID{Bytes: id, Valid: true}
+
+	switch kind {
+	case "post":
+		if _, err := s.controller.postService.GetPostById(s.ctx, pgID); err != nil {
+			if err.Error() == "post not found" {
+				return errGatewayNotFound
+			}
+			log.Printf("Error subscribing to post: %v", err)
+			return errGatewayInternal
+		}
+		s.sub.Add(realtime.PostTopic(id))
+		return nil
+
+	case "dm":
+		if _, err := s.controller.messageService.GetConversation(s.ctx, s.userID, pgID); err != nil {
+			if err.Error() == "conversation not found" {
+				return errGatewayNotFound
+			}
+			log.Printf("Error subscribing to conversation: %v", err)
+			return errGatewayInternal
+		}
+		s.sub.Add(realtime.ConversationTopic(id))
+		return nil
+
+	default:
+		return errGatewayInvalidTopic
+	}
+}
+
+func (s *gatewaySession) unsubscribe(topic string) {
+	if topic == homeTimelineTopic {
+		hubTopics := []string{realtime.UserTopic(s.userID.Bytes)}
+		for id := range s.followed {
+			hubTopics = append(hubTopics, realtime.AuthorTopic(id))
+		}
+		s.sub.Remove(hubTopics...)
+		s.followed = nil
+		return
+	}
+
+	s.sub.Remove(topic)
+}
+
+// handleEvent turns a hub event into a message for the client. Events only
+// carry ids; what's sent is loaded as the user so it respects visibility and
+// membership at the time of the event.
+func (s *gatewaySession) handleEvent(event realtime.Event) error {
+	switch event.Type {
+	case realtime.EventPost:
+		var data service.PostEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("Error decoding post event: %v", err)
+			return nil
+		}
+		post, err := s.controller.postService.GetHomeTimelinePost(s.ctx, s.userID, data)
+		if err != nil {
+			if err.Error() != "post not found" {
+				log.Printf("Error getting streamed post: %v", err)
+			}
+			return nil
+		}
+		if post == nil {
+			// Muted or filtered out of the home timeline
+			return nil
+		}
+		return s.send(gatewayMessage{Type: realtime.EventPost, Topic: homeTimelineTopic, Data: post})
+
+	case realtime.EventFollow:
+		var data service.FollowEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("Error decoding follow event: %v", err)
+			return nil
+		}
+		s.updateFollowed(data)
+		return nil
+
+	case realtime.EventPostCounts, realtime.EventPostDeleted:
+		return s.send(gatewayMessage{Type: event.Type, Topic: event.Topic, Data: event.Data})
+
+	case realtime.EventMessage:
+		var data service.MessageEvent
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Printf("Error decoding message event: %v", err)
+			return nil
+		}
+		message, err := s.controller.messageService.GetMessage(s.ctx, s.userID, data.ConversationID, data.ID)
+		if err != nil {
+			// Removed from the conversation; stop listening to it
+			if err.Error() == "conversation not found" {
+				s.unsubscribe(event.Topic)
+				delete(s.topics, event.Topic)
+				return s.send(gatewayMessage{Type: "unsubscribed", Topic: event.Topic})
+			}
+			if err.Error() != "message not found" {
+				log.Printf("Error getting streamed message: %v", err)
+			}
+			return nil
+		}
+		return s.send(gatewayMessage{Type: realtime.EventMessage, Topic: event.Topic, Data: message})
+	}
+
+	// Other events on the user topic belong to the notification stream
+	return nil
+}
+
+// updateFollowed keeps the home timeline's author topics in step with the
+// accounts the user follows
+func (s *gatewaySession) updateFollowed(event service.FollowEvent) {
+	if !s.topics[homeTimelineTopic] || event.UserID.Bytes == s.userID.Bytes {
+		return
+	}
+
+	if event.Following {
+		s.followed[event.UserID.Bytes] = true
+		s.sub.Add(realtime.AuthorTopic(event.UserID.Bytes))
+	} else {
+		delete(s.followed, event.UserID.Bytes)
+		s.sub.Remove(realtime.AuthorTopic(event.UserID.Bytes))
+	}
+}
+
+var (
+	errGatewayInvalidTopic = errors.New("invalid topic")
+	errGatewayNotFound     = errors.New("not found")
+	errGatewayInternal     = errors.New("subscription failed")
+)
@@ -0,0 +1,364 @@
+package controller
+
+import (
+	"net/http"
+
+	hmiddleware "horizon-backend/internal/middleware"
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+type MessageController struct {
+	messageService *service.MessageService
+	userService    *service.UserService
+}
+
+func NewMessageController(messageService *service.MessageService, userService *service.UserService) *MessageController {
+	return &MessageController{
+		messageService: messageService,
+		userService:    userService,
+	}
+}
+
+// GetConversations handles GET /api/conversations
+func (c *MessageController) GetConversations(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get pagination params
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	conversations, err := c.messageService.GetConversations(ctx.Request().Context(), userID, page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get conversations: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, conversations)
+}
+
+// CreateConversation handles POST /api/conversations
+func (c *MessageController) CreateConversation(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var request struct {
+		Title     string   `json:"title"`
+		Usernames []string `json:"usernames"`
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	conversation, err := c.messageService.CreateGroupConversation(ctx.Request().Context(), userID, request.Title, request.Usernames)
+	if err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.JSON(http.StatusCreated, conversation)
+}
+
+// GetUnreadCount handles GET /api/conversations/unread-count
+func (c *MessageController) GetUnreadCount(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	count, err := c.messageService.GetUnreadCount(ctx.Request().Context(), userID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get unread count")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]int64{"count": count})
+}
+
+// GetConversation handles GET /api/conversations/:id
+func (c *MessageController) GetConversation(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var conversationID pgtype.UUID
+	if err := conversationID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid conversation ID format")
+	}
+
+	conversation, err := c.messageService.GetConversation(ctx.Request().Context(), userID, conversationID)
+	if err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, conversation)
+}
+
+// UpdateConversation handles PUT /api/conversations/:id
+func (c *MessageController) UpdateConversation(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var conversationID pgtype.UUID
+	if err := conversationID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid conversation ID format")
+	}
+
+	var request struct {
+		Title string `json:"title"`
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	conversation, err := c.messageService.UpdateConversationTitle(ctx.Request().Context(), userID, conversationID, request.Title)
+	if err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, conversation)
+}
+
+// AddMembers handles POST /api/conversations/:id/members
+func (c *MessageController) AddMembers(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var conversationID pgtype.UUID
+	if err := conversationID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid conversation ID format")
+	}
+
+	var request struct {
+		Usernames []string `json:"usernames"`
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	conversation, err := c.messageService.AddMembers(ctx.Request().Context(), userID, conversationID, request.Usernames)
+	if err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, conversation)
+}
+
+// RemoveMember handles DELETE /api/conversations/:id/members/:username.
+// Removing yourself leaves the conversation.
+func (c *MessageController) RemoveMember(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var conversationID pgtype.UUID
+	if err := conversationID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid conversation ID format")
+	}
+
+	member, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	if err := c.messageService.RemoveMember(ctx.Request().Context(), userID, conversationID, member.ID); err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// GetConversationMessages handles GET /api/conversations/:id/messages
+func (c *MessageController) GetConversationMessages(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var conversationID pgtype.UUID
+	if err := conversationID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid conversation ID format")
+	}
+
+	// Get pagination params
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	messages, err := c.messageService.GetMessages(ctx.Request().Context(), userID, conversationID, page)
+	if err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, messages)
+}
+
+// SendConversationMessage handles POST /api/conversations/:id/messages
+func (c *MessageController) SendConversationMessage(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var conversationID pgtype.UUID
+	if err := conversationID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid conversation ID format")
+	}
+
+	var request struct {
+		Content string `json:"content"`
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	message, err := c.messageService.SendMessage(ctx.Request().Context(), userID, conversationID, request.Content)
+	if err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.JSON(http.StatusCreated, message)
+}
+
+// MarkConversationAsRead handles PUT /api/conversations/:id/read. The read
+// cursor moves to message_id when given, otherwise to the latest message.
+func (c *MessageController) MarkConversationAsRead(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var conversationID pgtype.UUID
+	if err := conversationID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid conversation ID format")
+	}
+
+	var request struct {
+		MessageID pgtype.UUID `json:"message_id"`
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	if err := c.messageService.MarkAsRead(ctx.Request().Context(), userID, conversationID, request.MessageID); err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// GetMessages handles GET /api/users/:username/messages
+func (c *MessageController) GetMessages(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get the other user
+	otherUser, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	// Get pagination params
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	messages, err := c.messageService.GetDirectMessages(ctx.Request().Context(), userID, otherUser.ID, page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get messages: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, messages)
+}
+
+// SendMessage handles POST /api/users/:username/messages
+func (c *MessageController) SendMessage(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var request struct {
+		Content string `json:"content"`
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	// Get the recipient
+	recipient, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	message, err := c.messageService.SendDirectMessage(ctx.Request().Context(), userID, recipient.ID, request.Content)
+	if err != nil {
+		return conversationError(err)
+	}
+
+	return ctx.JSON(http.StatusCreated, message)
+}
+
+// MarkAsRead handles PUT /api/users/:username/messages/read
+func (c *MessageController) MarkAsRead(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get the other user
+	otherUser, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	if err := c.messageService.MarkDirectConversationAsRead(ctx.Request().Context(), userID, otherUser.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to mark messages as read")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// conversationError maps MessageService errors to HTTP errors
+func conversationError(err error) error {
+	switch err.Error() {
+	case "message content is required", "message is too long", "cannot message yourself",
+		"title is too long", "a group needs at least one other member", "conversation is full",
+		"direct conversations don't have a title", "cannot add members to a direct conversation",
+		"cannot leave a direct conversation":
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case "conversation not found", "user not found", "member not found":
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case "cannot message this user":
+		return echo.NewHTTPError(http.StatusForbidden, "this user doesn't accept messages from you")
+	case "cannot add this user":
+		return echo.NewHTTPError(http.StatusForbidden, "a user you added doesn't accept messages from you")
+	case "only the owner can change the title", "only the owner can manage members":
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
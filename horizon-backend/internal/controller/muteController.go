@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+type MuteController struct {
+	muteService *service.MuteService
+	userService *service.UserService
+}
+
+func NewMuteController(muteService *service.MuteService, userService *service.UserService) *MuteController {
+	return &MuteController{
+		muteService: muteService,
+		userService: userService,
+	}
+}
+
+// MuteUser handles POST /api/users/:username/mute. An optional expires_at
+// in the body ends the mute at that time.
+func (c *MuteController) MuteUser(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var request struct {
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	// Get user to mute
+	user, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	if err := c.muteService.MuteUser(ctx.Request().Context(), currentUser.ID, user.ID, request.ExpiresAt); err != nil {
+		switch err.Error() {
+		case "cannot mute yourself", "mute expiry must be in the future":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to mute user")
+		}
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// UnmuteUser handles DELETE /api/users/:username/mute
+func (c *MuteController) UnmuteUser(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get user to unmute
+	user, err := c.userService.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	if err := c.muteService.UnmuteUser(ctx.Request().Context(), currentUser.ID, user.ID); err != nil {
+		if err.Error() == "mute not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "you haven't muted this user")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to unmute user")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// GetMutedUsers handles GET /api/users/me/mutes
+func (c *MuteController) GetMutedUsers(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	muted, err := c.muteService.GetMutedUsers(ctx.Request().Context(), currentUser.ID, page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get muted users")
+	}
+
+	return ctx.JSON(http.StatusOK, muted)
+}
+
+// MuteConversation handles POST /api/posts/:id/mute, muting the post's thread
+func (c *MuteController) MuteConversation(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var postID pgtype.UUID
+	if err := postID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
+
+	if err := c.muteService.MuteConversation(ctx.Request().Context(), currentUser.ID, postID); err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to mute conversation")
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// UnmuteConversation handles DELETE /api/posts/:id/mute
+func (c *MuteController) UnmuteConversation(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var postID pgtype.UUID
+	if err := postID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
+
+	if err := c.muteService.UnmuteConversation(ctx.Request().Context(), currentUser.ID, postID); err != nil {
+		switch err.Error() {
+		case "post not found":
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		case "mute not found":
+			return echo.NewHTTPError(http.StatusNotFound, "you haven't muted this conversation")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to unmute conversation")
+		}
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -1,20 +1,31 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
-	"strings"
+	"time"
 
 	hmiddleware "horizon-backend/internal/middleware"
 	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/realtime"
 	"horizon-backend/internal/service"
 	"horizon-backend/internal/util"
 
 	"github.com/labstack/echo/v4"
 )
 
+const (
+	// streamReplayLimit caps how many missed notifications a reconnecting
+	// stream replays before telling the client to refetch instead
+	streamReplayLimit = 100
+
+	// streamHeartbeat keeps idle streams from being closed by proxies
+	streamHeartbeat = 25 * time.Second
+)
+
 type NotificationController struct {
 	notificationService *service.NotificationService
 }
@@ -34,44 +45,20 @@ func (c *NotificationController) GetNotifications(ctx echo.Context) error {
 	}
 
 	// Get pagination params
-	limit := int32(20)
-	offset := int32(0)
-	if limitStr := ctx.QueryParam("limit"); limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil {
-			limit = int32(l)
-		} else {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid limit parameter: %v", err))
-		}
-	}
-	if offsetStr := ctx.QueryParam("offset"); offsetStr != "" {
-		if o, err := strconv.ParseInt(offsetStr, 10, 32); err == nil {
-			offset = int32(o)
-		} else {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid offset parameter: %v", err))
-		}
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	// Get notifications
-	notifications, err := c.notificationService.GetNotifications(ctx.Request().Context(), userID.Bytes, limit, offset)
+	notifications, err := c.notificationService.GetNotifications(ctx.Request().Context(), userID.Bytes, page)
 	if err != nil {
 		// Log the detailed error
 		log.Printf("Error getting notifications: %v", err)
 
-		// Check for specific error types and return appropriate status codes
-		if strings.Contains(err.Error(), "invalid notification ID") ||
-			strings.Contains(err.Error(), "invalid user ID") ||
-			strings.Contains(err.Error(), "invalid actor ID") {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("data integrity error: %v", err))
-		}
-
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to get notifications: %v", err))
 	}
 
-	// Always return a JSON array, even if empty
-	if notifications == nil {
-		notifications = []*model.Notification{}
-	}
-
 	return ctx.JSON(http.StatusOK, notifications)
 }
 
@@ -92,8 +79,15 @@ func (c *NotificationController) GetUnreadCount(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, map[string]int64{"count": count})
 }
 
-// MarkAsRead handles PUT /api/notifications/:id/read
+// MarkAsRead handles PUT /api/notifications/:id/read. The id is a
+// notification group's.
 func (c *NotificationController) MarkAsRead(ctx echo.Context) error {
+	// Get user ID from context using the middleware helper
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
 	// Get notification ID from URL
 	notificationID, err := util.GetUUIDFromString(ctx.Param("id"))
 	if err != nil {
@@ -101,8 +95,11 @@ func (c *NotificationController) MarkAsRead(ctx echo.Context) error {
 	}
 
 	// Mark as read
-	err = c.notificationService.MarkAsRead(ctx.Request().Context(), notificationID)
+	err = c.notificationService.MarkAsRead(ctx.Request().Context(), userID.Bytes, notificationID)
 	if err != nil {
+		if err.Error() == "notification not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "notification not found")
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to mark notification as read")
 	}
 
@@ -125,3 +122,239 @@ func (c *NotificationController) MarkAllAsRead(ctx echo.Context) error {
 
 	return ctx.NoContent(http.StatusNoContent)
 }
+
+// GetSettings handles GET /api/users/me/settings/notifications
+func (c *NotificationController) GetSettings(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	settings, err := c.notificationService.GetSettings(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get notification settings")
+	}
+
+	return ctx.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /api/users/me/settings/notifications. Only the
+// types and fields in the request are changed.
+func (c *NotificationController) UpdateSettings(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var request map[model.NotificationType]struct {
+		Enabled           *bool `json:"enabled"`
+		OnlyFromFollowing *bool `json:"only_from_following"`
+		Muted             *bool `json:"muted"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	current, err := c.notificationService.GetSettings(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update notification settings")
+	}
+
+	settings := make(model.NotificationSettings, len(request))
+	for notificationType, update := range request {
+		if !notificationType.IsValid() {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid notification type: %s", notificationType))
+		}
+
+		setting := current[notificationType]
+		if update.Enabled != nil {
+			setting.Enabled = *update.Enabled
+		}
+		if update.OnlyFromFollowing != nil {
+			setting.OnlyFromFollowing = *update.OnlyFromFollowing
+		}
+		if update.Muted != nil {
+			setting.Muted = *update.Muted
+		}
+		settings[notificationType] = setting
+	}
+
+	updated, err := c.notificationService.UpdateSettings(ctx.Request().Context(), userID.Bytes, settings)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to update notification settings")
+	}
+
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+// Stream handles GET /api/notifications/stream. It sends the user's new
+// notifications and unread count as Server-Sent Events. Each notification's
+// event id is a cursor; clients reconnecting with Last-Event-ID are sent the
+// notifications they missed.
+func (c *NotificationController) Stream(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var last pagination.Cursor
+	lastEventID := ctx.Request().Header.Get("Last-Event-ID")
+	if lastEventID != "" {
+		cursor, _, err := pagination.Decode(lastEventID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid Last-Event-ID")
+		}
+		last = cursor
+	}
+
+	reqCtx := ctx.Request().Context()
+
+	// Subscribe before replaying so nothing created in between is missed;
+	// live events for notifications the replay already sent are skipped
+	sub := c.notificationService.Subscribe(userID.Bytes)
+	defer sub.Close()
+
+	var missed []*model.Notification
+	var tooFarBehind bool
+	if lastEventID != "" {
+		notifications, more, err := c.notificationService.GetNotificationsSince(reqCtx, userID.Bytes, last, streamReplayLimit)
+		if err != nil {
+			log.Printf("Error replaying notifications: %v", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to get notifications")
+		}
+		missed, tooFarBehind = notifications, more
+	}
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	// Notifications sent by the replay, whose live events are skipped
+	replayed := make(map[[16]byte]bool, len(missed))
+	if tooFarBehind {
+		// Too far behind to replay; the client reloads its notifications
+		if err := writeEvent(res, "", "reset", struct{}{}); err != nil {
+			return nil
+		}
+	} else {
+		for _, notification := range missed {
+			if err := c.sendNotification(res, notification); err != nil {
+				return nil
+			}
+			replayed[notification.ID.Bytes] = true
+		}
+	}
+
+	if err := c.sendUnreadCount(ctx, res); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-reqCtx.Done():
+			return nil
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind; the client reconnects with
+				// its Last-Event-ID
+				return nil
+			}
+
+			var err error
+			switch event.Type {
+			case realtime.EventNotification:
+				err = c.streamNotification(ctx, res, event, replayed)
+			case realtime.EventUnreadCount:
+				err = c.sendUnreadCount(ctx, res)
+			}
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func (c *NotificationController) streamNotification(ctx echo.Context, res *echo.Response, event realtime.Event, replayed map[[16]byte]bool) error {
+	var data struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		log.Printf("Error decoding notification event: %v", err)
+		return nil
+	}
+	notificationID, err := util.GetUUIDFromString(data.ID)
+	if err != nil {
+		log.Printf("Error decoding notification event: %v", err)
+		return nil
+	}
+	if replayed[notificationID] {
+		// Created while replaying, so the replay already sent it
+		delete(replayed, notificationID)
+		return nil
+	}
+
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	notification, err := c.notificationService.GetNotification(ctx.Request().Context(), userID.Bytes, notificationID)
+	if err != nil {
+		if err.Error() != "notification not found" {
+			log.Printf("Error getting streamed notification: %v", err)
+		}
+		return nil
+	}
+
+	if err := c.sendNotification(res, notification); err != nil {
+		return err
+	}
+	return c.sendUnreadCount(ctx, res)
+}
+
+// sendNotification writes a notification with its cursor as the event ID, so a
+// reconnecting client resumes right after it
+func (c *NotificationController) sendNotification(res *echo.Response, notification *model.Notification) error {
+	cursor := pagination.NewCursor(notification.CreatedAt, notification.ID)
+	return writeEvent(res, cursor.Encode(pagination.Newer), realtime.EventNotification, notification)
+}
+
+func (c *NotificationController) sendUnreadCount(ctx echo.Context, res *echo.Response) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	count, err := c.notificationService.GetUnreadCount(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		log.Printf("Error getting unread count: %v", err)
+		return nil
+	}
+
+	return writeEvent(res, "", realtime.EventUnreadCount, map[string]int64{"count": count})
+}
+
+// writeEvent writes a single Server-Sent Event and flushes it to the client
+func writeEvent(res *echo.Response, id, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	if id != "" {
+		if _, err := fmt.Fprintf(res, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", eventType, payload); err != nil {
+		return err
+	}
+
+	res.Flush()
+	return nil
+}
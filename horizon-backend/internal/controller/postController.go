@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"horizon-backend/internal/middleware"
 	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
 	"horizon-backend/internal/service"
 	"net/http"
 	"path/filepath"
@@ -38,10 +39,12 @@ func NewPostController(postService *service.PostService, userService service.Aut
 
 func (c *PostController) CreatePost(ctx echo.Context) error {
 	var request struct {
-		Content       string      `json:"content"`
-		IsPrivate     bool        `json:"is_private"`
-		ReplyToPostID pgtype.UUID `json:"reply_to_post_id"`
-		MediaUrls     []string    `json:"media_urls"`
+		Content       string            `json:"content"`
+		IsPrivate     bool              `json:"is_private"`
+		ReplyToPostID pgtype.UUID       `json:"reply_to_post_id"`
+		MediaUrls     []string          `json:"media_urls"`
+		QuotedPostID  pgtype.UUID       `json:"quoted_post_id"`
+		ReplyPolicy   model.ReplyPolicy `json:"reply_policy"`
 	}
 
 	if err := ctx.Bind(&request); err != nil {
@@ -60,17 +63,30 @@ func (c *PostController) CreatePost(ctx echo.Context) error {
 		IsPrivate:     request.IsPrivate,
 		ReplyToPostID: request.ReplyToPostID,
 		MediaUrls:     request.MediaUrls,
+		QuotedPostID:  request.QuotedPostID,
+		ReplyPolicy:   request.ReplyPolicy,
 	}
 
 	createdPost, err := c.postService.CreatePost(ctx.Request().Context(), &post)
 
 	if err != nil {
+		switch err.Error() {
+		case "quoted post not found":
+			return echo.NewHTTPError(http.StatusNotFound, "quoted post not found")
+		case "parent post not found":
+			return echo.NewHTTPError(http.StatusNotFound, "parent post not found")
+		case "replies to this post are restricted":
+			return echo.NewHTTPError(http.StatusForbidden, "you are not allowed to reply to this post")
+		case "invalid reply policy":
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid reply policy")
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create post: "+err.Error())
 	}
 
 	return ctx.JSON(http.StatusCreated, createdPost)
 }
 
+// UpdatePostContent edits the content of the authenticated user's post
 func (c *PostController) UpdatePostContent(ctx echo.Context) error {
 	postIdStr := ctx.Param("id")
 
@@ -80,19 +96,126 @@ func (c *PostController) UpdatePostContent(ctx echo.Context) error {
 	}
 
 	var request struct {
-		ID      pgtype.UUID `json:"id"`
-		Content string      `json:"content"`
-		UserID  pgtype.UUID `json:"user_id"`
+		Content string `json:"content"`
+	}
+
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	// The author is always the authenticated user
+	userID := middleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	updatedPost, err := c.postService.UpdatePostContent(ctx.Request().Context(), postId, userID, request.Content)
+	if err != nil {
+		switch err.Error() {
+		case "updated content cannot be empty":
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case "post not found":
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		case "unauthorized: post doesn't belong to you":
+			return echo.NewHTTPError(http.StatusForbidden, "you can only edit your own posts")
+		case "edit window has expired":
+			return echo.NewHTTPError(http.StatusForbidden, "this post can no longer be edited")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, updatedPost)
+}
+
+// GetPostRevisions returns the earlier versions of an edited post
+func (c *PostController) GetPostRevisions(ctx echo.Context) error {
+	// Get post ID from path parameter
+	postIDStr := ctx.Param("id")
+	if postIDStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "post ID is required")
+	}
+
+	// Convert string ID to UUID
+	postUUID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
+
+	// Convert to pgtype.UUID
+	postID := pgtype.UUID{
+		Bytes: postUUID,
+		Valid: true,
+	}
+
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
+
+	revisions, err := c.postService.GetPostRevisions(reqCtx, postID, page)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get post revisions: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, revisions)
+}
+
+// UpdateReplyPolicy changes who may reply to a post
+func (c *PostController) UpdateReplyPolicy(ctx echo.Context) error {
+	// Get post ID from path parameter
+	postIDStr := ctx.Param("id")
+	if postIDStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "post ID is required")
+	}
+
+	// Convert string ID to UUID
+	postUUID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
+
+	// Convert to pgtype.UUID
+	postID := pgtype.UUID{
+		Bytes: postUUID,
+		Valid: true,
+	}
+
+	var request struct {
+		ReplyPolicy model.ReplyPolicy `json:"reply_policy"`
 	}
 
 	if err := ctx.Bind(&request); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
 	}
 
-	updatedPost, err := c.postService.UpdatePostContent(ctx.Request().Context(), postId, request.UserID, request.Content)
+	// Get user ID from context
+	userID := middleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
 
+	// Update the policy
+	updatedPost, err := c.postService.UpdateReplyPolicy(ctx.Request().Context(), postID, userID, request.ReplyPolicy)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		switch err.Error() {
+		case "invalid reply policy":
+			return echo.NewHTTPError(http.StatusBadRequest, "reply_policy must be one of everyone, following, mentioned")
+		case "post not found":
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		case "unauthorized: post doesn't belong to you":
+			return echo.NewHTTPError(http.StatusForbidden, "you can only change the reply policy of your own posts")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to update reply policy: "+err.Error())
+		}
 	}
 
 	return ctx.JSON(http.StatusOK, updatedPost)
@@ -127,6 +250,9 @@ func (c *PostController) LikePost(ctx echo.Context) error {
 	// Like the post
 	err = c.postService.LikePost(ctx.Request().Context(), postID.Bytes, userID.Bytes)
 	if err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("failed to like post: %v", err))
 	}
 
@@ -206,28 +332,136 @@ func (c *PostController) UnlikePost(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, map[string]string{"message": "post unliked"})
 }
 
-// GetPosts retrieves a paginated list of posts
-func (c *PostController) GetPosts(ctx echo.Context) error {
-	// Get pagination parameters from query string
-	limitStr := ctx.QueryParam("limit")
-	offsetStr := ctx.QueryParam("offset")
+// CreateRepost reposts a post
+func (c *PostController) CreateRepost(ctx echo.Context) error {
+	// Get post ID from path parameter
+	postIDStr := ctx.Param("id")
+	if postIDStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "post ID is required")
+	}
 
-	var limit int32 = 10 // Default limit
-	var offset int32 = 0 // Default offset
+	// Convert string ID to UUID
+	postUUID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
 
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = int32(parsedLimit)
-			if limit > 50 {
-				limit = 50 // Cap at 50 to prevent abuse
-			}
+	// Convert to pgtype.UUID
+	postID := pgtype.UUID{
+		Bytes: postUUID,
+		Valid: true,
+	}
+
+	// Get user ID from context
+	userID := middleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Repost the post
+	err = c.postService.CreateRepost(ctx.Request().Context(), postID.Bytes, userID.Bytes)
+	if err != nil {
+		switch err.Error() {
+		case "post not found":
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		case "cannot repost your own post":
+			return echo.NewHTTPError(http.StatusBadRequest, "cannot repost your own post")
+		case "post already reposted":
+			return echo.NewHTTPError(http.StatusConflict, "post already reposted")
+		default:
+			return echo.NewHTTPError(http.StatusInternalServerError, "failed to repost post: "+err.Error())
 		}
 	}
 
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
+	return ctx.NoContent(http.StatusCreated)
+}
+
+// DeleteRepost removes the current user's repost of a post
+func (c *PostController) DeleteRepost(ctx echo.Context) error {
+	// Get post ID from path parameter
+	postIDStr := ctx.Param("id")
+	if postIDStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "post ID is required")
+	}
+
+	// Convert string ID to UUID
+	postUUID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
+
+	// Convert to pgtype.UUID
+	postID := pgtype.UUID{
+		Bytes: postUUID,
+		Valid: true,
+	}
+
+	// Get user ID from context
+	userID := middleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Undo the repost
+	err = c.postService.DeleteRepost(ctx.Request().Context(), postID, userID)
+	if err != nil {
+		if err.Error() == "repost not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "repost not found")
 		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to undo repost: "+err.Error())
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// GetPostReposters lists the users who reposted a post
+func (c *PostController) GetPostReposters(ctx echo.Context) error {
+	// Get post ID from path parameter
+	postIDStr := ctx.Param("id")
+	if postIDStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "post ID is required")
+	}
+
+	// Convert string ID to UUID
+	postUUID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
+
+	// Convert to pgtype.UUID
+	postID := pgtype.UUID{
+		Bytes: postUUID,
+		Valid: true,
+	}
+
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
+
+	// Get reposters from service with the user context
+	reposters, err := c.postService.GetPostReposters(reqCtx, postID, page)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get reposters: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, reposters)
+}
+
+// GetPosts retrieves a page of posts
+func (c *PostController) GetPosts(ctx echo.Context) error {
+	// Get pagination parameters from query string
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	// Create a context with the user ID
@@ -235,7 +469,7 @@ func (c *PostController) GetPosts(ctx echo.Context) error {
 	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
 
 	// Get posts from service with the user context
-	posts, err := c.postService.GetPosts(reqCtx, limit, offset)
+	posts, err := c.postService.GetPosts(reqCtx, page)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get posts: "+err.Error())
 	}
@@ -243,6 +477,92 @@ func (c *PostController) GetPosts(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, posts)
 }
 
+// GetHashtagPosts retrieves a page of posts tagged with a hashtag
+func (c *PostController) GetHashtagPosts(ctx echo.Context) error {
+	tag := ctx.Param("tag")
+	if tag == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "hashtag is required")
+	}
+
+	// Get pagination parameters from query string
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
+
+	posts, err := c.postService.GetHashtagPosts(reqCtx, tag, page)
+	if err != nil {
+		if err.Error() == "invalid hashtag" {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid hashtag")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get hashtag posts: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, posts)
+}
+
+// GetTrendingHashtags retrieves the hashtags whose usage is growing fastest
+func (c *PostController) GetTrendingHashtags(ctx echo.Context) error {
+	window := service.DefaultTrendingWindow
+	if raw := ctx.QueryParam("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid trending window")
+		}
+		window = parsed
+	}
+
+	limit := int32(10)
+	if raw := ctx.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, pagination.ErrInvalidLimit.Error())
+		}
+		if parsed < int(pagination.MaxLimit) {
+			limit = int32(parsed)
+		} else {
+			limit = pagination.MaxLimit
+		}
+	}
+
+	trending, err := c.postService.GetTrendingHashtags(ctx.Request().Context(), window, limit)
+	if err != nil {
+		if err.Error() == "invalid trending window" {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("window must be between %s and %s", service.MinTrendingWindow, service.MaxTrendingWindow))
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get trending hashtags: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]interface{}{"data": trending})
+}
+
+// GetHomeTimeline retrieves the authenticated user's home timeline
+func (c *PostController) GetHomeTimeline(ctx echo.Context) error {
+	// Get user ID from context
+	userID := middleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Get timeline from service
+	posts, err := c.postService.GetHomeTimeline(ctx.Request().Context(), userID, page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get home timeline: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, posts)
+}
+
 // GetUserPosts retrieves posts by a specific user
 func (c *PostController) GetUserPosts(ctx echo.Context) error {
 	// Get username from path parameter
@@ -252,25 +572,9 @@ func (c *PostController) GetUserPosts(ctx echo.Context) error {
 	}
 
 	// Get pagination parameters
-	limitStr := ctx.QueryParam("limit")
-	offsetStr := ctx.QueryParam("offset")
-
-	var limit int32 = 10 // Default limit
-	var offset int32 = 0 // Default offset
-
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = int32(parsedLimit)
-			if limit > 50 {
-				limit = 50 // Cap at 50
-			}
-		}
-	}
-
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
-		}
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	// Create a context with the user ID
@@ -278,7 +582,7 @@ func (c *PostController) GetUserPosts(ctx echo.Context) error {
 	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
 
 	// Get posts from service
-	posts, err := c.postService.GetUserPostsByUsername(reqCtx, username, limit, offset)
+	posts, err := c.postService.GetUserPostsByUsername(reqCtx, username, page)
 	if err != nil {
 		if err.Error() == "user not found" {
 			return echo.NewHTTPError(http.StatusNotFound, "user not found")
@@ -316,7 +620,7 @@ func (c *PostController) GetPostByID(ctx echo.Context) error {
 	// Get post from service with the user context
 	post, err := c.postService.GetPostById(reqCtx, postId)
 	if err != nil {
-		if err.Error() == "failed to find post: no rows in result set" {
+		if err.Error() == "post not found" {
 			return echo.NewHTTPError(http.StatusNotFound, "post not found")
 		}
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get post: "+err.Error())
@@ -346,34 +650,109 @@ func (c *PostController) GetPostReplies(ctx echo.Context) error {
 	}
 
 	// Get pagination parameters
-	limitStr := ctx.QueryParam("limit")
-	offsetStr := ctx.QueryParam("offset")
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
-	var limit int32 = 10 // Default limit
-	var offset int32 = 0 // Default offset
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
 
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = int32(parsedLimit)
-			if limit > 50 {
-				limit = 50 // Cap at 50
-			}
+	// Get replies from service
+	replies, err := c.postService.GetPostReplies(reqCtx, postID, page)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
 		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get post replies: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, replies)
+}
+
+// GetPostQuotes retrieves the posts quoting a specific post
+func (c *PostController) GetPostQuotes(ctx echo.Context) error {
+	// Get post ID from path parameter
+	postIDStr := ctx.Param("id")
+	if postIDStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "post ID is required")
+	}
+
+	// Convert string ID to UUID
+	postUUID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
+	}
+
+	// Convert to pgtype.UUID
+	postID := pgtype.UUID{
+		Bytes: postUUID,
+		Valid: true,
 	}
 
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
+
+	// Get quotes from service
+	quotes, err := c.postService.GetPostQuotes(reqCtx, postID, page)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
 		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get post quotes: "+err.Error())
 	}
 
-	// Get replies from service
-	replies, err := c.postService.GetPostReplies(ctx.Request().Context(), postID, limit, offset)
+	return ctx.JSON(http.StatusOK, quotes)
+}
+
+// GetThread retrieves the conversation thread around a post
+func (c *PostController) GetThread(ctx echo.Context) error {
+	// Get post ID from path parameter
+	postIDStr := ctx.Param("id")
+	if postIDStr == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "post ID is required")
+	}
+
+	// Convert string ID to UUID
+	postUUID, err := uuid.Parse(postIDStr)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get post replies: "+err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID format")
 	}
 
-	return ctx.JSON(http.StatusOK, replies)
+	// Convert to pgtype.UUID
+	postID := pgtype.UUID{
+		Bytes: postUUID,
+		Valid: true,
+	}
+
+	// Get pagination parameters for the direct replies
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
+
+	// Get thread from service
+	thread, err := c.postService.GetThread(reqCtx, postID, page)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return echo.NewHTTPError(http.StatusNotFound, "post not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get thread: "+err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, thread)
 }
 
 // DeletePost deletes a post
@@ -426,38 +805,26 @@ func (c *PostController) GetUserReplies(ctx echo.Context) error {
 	}
 
 	// Get pagination parameters
-	limitStr := ctx.QueryParam("limit")
-	offsetStr := ctx.QueryParam("offset")
-
-	var limit int32 = 10 // Default limit
-	var offset int32 = 0 // Default offset
-
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = int32(parsedLimit)
-			if limit > 50 {
-				limit = 50 // Cap at 50
-			}
-		}
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
-		}
-	}
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
 
 	// Get user's replies with their parent posts
-	replies, err := c.postService.GetUserRepliesByUsername(ctx.Request().Context(), username, limit, offset)
+	replies, err := c.postService.GetUserRepliesByUsername(reqCtx, username, page)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get user replies")
 	}
 
 	// Convert to response format
-	response := make([]map[string]interface{}, 0, len(replies))
-	for _, reply := range replies {
+	response := make([]map[string]interface{}, 0, len(replies.Data))
+	for _, reply := range replies.Data {
 		// Get the parent post
-		parentPost, err := c.postService.GetPostById(ctx.Request().Context(), reply.ReplyToPostID)
+		parentPost, err := c.postService.GetPostById(reqCtx, reply.ReplyToPostID)
 		if err != nil {
 			if err != sql.ErrNoRows {
 				return echo.NewHTTPError(http.StatusInternalServerError, "failed to get parent post")
@@ -475,12 +842,14 @@ func (c *PostController) GetUserReplies(ctx echo.Context) error {
 			"user_id":          reply.UserID,
 			"reply_to_post_id": reply.ReplyToPostID,
 			"is_private":       reply.IsPrivate,
-			"allow_replies":    reply.AllowReplies,
+			"reply_policy":     reply.ReplyPolicy,
 			"media_urls":       reply.MediaUrls,
 			"like_count":       reply.LikeCount,
 			"repost_count":     reply.RepostCount,
 			"reply_count":      reply.ReplyCount,
 			"has_liked":        reply.HasLiked,
+			"has_bookmarked":   reply.HasBookmarked,
+			"has_reposted":     reply.HasReposted,
 			"user": map[string]interface{}{
 				"id":           reply.UserID,
 				"username":     reply.Username,
@@ -498,12 +867,14 @@ func (c *PostController) GetUserReplies(ctx echo.Context) error {
 			"user_id":          parentPost.UserID,
 			"reply_to_post_id": parentPost.ReplyToPostID,
 			"is_private":       parentPost.IsPrivate,
-			"allow_replies":    parentPost.AllowReplies,
+			"reply_policy":     parentPost.ReplyPolicy,
 			"media_urls":       parentPost.MediaUrls,
 			"like_count":       parentPost.LikeCount,
 			"repost_count":     parentPost.RepostCount,
 			"reply_count":      parentPost.ReplyCount,
 			"has_liked":        parentPost.HasLiked,
+			"has_bookmarked":   parentPost.HasBookmarked,
+			"has_reposted":     parentPost.HasReposted,
 			"user": map[string]interface{}{
 				"id":           parentPost.UserID,
 				"username":     parentPost.Username,
@@ -518,7 +889,11 @@ func (c *PostController) GetUserReplies(ctx echo.Context) error {
 		})
 	}
 
-	return ctx.JSON(http.StatusOK, response)
+	return ctx.JSON(http.StatusOK, &pagination.Page[map[string]interface{}]{
+		Data:       response,
+		NextCursor: replies.NextCursor,
+		PrevCursor: replies.PrevCursor,
+	})
 }
 
 // GetUserLikedPosts retrieves posts liked by a specific user
@@ -530,29 +905,17 @@ func (c *PostController) GetUserLikedPosts(ctx echo.Context) error {
 	}
 
 	// Get pagination parameters
-	limitStr := ctx.QueryParam("limit")
-	offsetStr := ctx.QueryParam("offset")
-
-	var limit int32 = 10 // Default limit
-	var offset int32 = 0 // Default offset
-
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = int32(parsedLimit)
-			if limit > 50 {
-				limit = 50 // Cap at 50
-			}
-		}
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
-		}
-	}
+	// Create a context with the user ID
+	userID := middleware.GetUserIDFromContext(ctx)
+	reqCtx := context.WithValue(ctx.Request().Context(), "user_id", userID)
 
 	// Get user's liked posts
-	posts, err := c.postService.GetUserLikedPostsByUsername(ctx.Request().Context(), username, limit, offset)
+	posts, err := c.postService.GetUserLikedPostsByUsername(reqCtx, username, page)
 	if err != nil {
 		if err.Error() == "failed to find user: no rows in result set" {
 			return echo.NewHTTPError(http.StatusNotFound, "user not found")
@@ -641,31 +1004,36 @@ func (c *PostController) GetUserBookmarks(ctx echo.Context) error {
 	userIDStr := uuid.UUID(userID.Bytes).String()
 
 	// Get pagination parameters
-	limitStr := ctx.QueryParam("limit")
-	offsetStr := ctx.QueryParam("offset")
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 
-	var limit int32 = 10 // Default limit
-	var offset int32 = 0 // Default offset
+	// Get bookmarked posts
+	posts, err := c.postService.GetUserBookmarks(ctx.Request().Context(), userIDStr, page)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get bookmarked posts")
+	}
 
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = int32(parsedLimit)
-			if limit > 50 {
-				limit = 50 // Cap at 50
-			}
-		}
+	return ctx.JSON(http.StatusOK, posts)
+}
+
+// GetUserMentions retrieves the posts that mention the authenticated user
+func (c *PostController) GetUserMentions(ctx echo.Context) error {
+	userID := middleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "user not authenticated")
 	}
 
-	if offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
-		}
+	// Get pagination parameters
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	// Get bookmarked posts
-	posts, err := c.postService.GetUserBookmarks(ctx.Request().Context(), userIDStr, limit, offset)
+	posts, err := c.postService.GetUserMentions(ctx.Request().Context(), userID, page)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get bookmarked posts")
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get mentions")
 	}
 
 	return ctx.JSON(http.StatusOK, posts)
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"horizon-backend/internal/db"
+	"horizon-backend/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+// privatePostDB serves a single private post, which only its author can see,
+// and no reposts
+type privatePostDB struct {
+	authorID pgtype.UUID
+}
+
+func (d privatePostDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (d privatePostDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return &emptyRows{}, nil
+}
+
+func (d privatePostDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	if strings.Contains(sql, "-- name: GetPostByID ") && args[1] == d.authorID {
+		return visibleRow{}
+	}
+	return visibleRow{err: pgx.ErrNoRows}
+}
+
+type visibleRow struct {
+	err error
+}
+
+func (r visibleRow) Scan(...any) error {
+	return r.err
+}
+
+type emptyRows struct{}
+
+func (r *emptyRows) Close()                                       {}
+func (r *emptyRows) Err() error                                   { return nil }
+func (r *emptyRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *emptyRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *emptyRows) Next() bool                                   { return false }
+func (r *emptyRows) Scan(...any) error                            { return nil }
+func (r *emptyRows) Values() ([]any, error)                       { return nil, nil }
+func (r *emptyRows) RawValues() [][]byte                          { return nil }
+func (r *emptyRows) Conn() *pgx.Conn                              { return nil }
+
+func newTestPostService(queries *db.Queries) *service.PostService {
+	return service.NewPostService(queries, nil, nil, nil, nil, nil, nil, 0)
+}
+
+func TestGetPostRepostersPrivatePost(t *testing.T) {
+	authorID := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	postID := uuid.New()
+
+	tests := []struct {
+		name     string
+		viewerID pgtype.UUID
+		want     int
+	}{
+		{"author", authorID, http.StatusOK},
+		{"other user", pgtype.UUID{Bytes: uuid.New(), Valid: true}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := NewPostController(newTestPostService(db.New(privatePostDB{authorID: authorID})), nil, nil, "")
+
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(postID.String())
+			ctx.Set("user_id", tt.viewerID)
+
+			code := http.StatusOK
+			if err := controller.GetPostReposters(ctx); err != nil {
+				var httpErr *echo.HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("GetPostReposters() error = %v", err)
+				}
+				code = httpErr.Code
+			}
+			if code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
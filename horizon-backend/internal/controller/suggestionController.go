@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SuggestionController struct {
+	suggestionService *service.SuggestionService
+}
+
+func NewSuggestionController(suggestionService *service.SuggestionService) *SuggestionController {
+	return &SuggestionController{
+		suggestionService: suggestionService,
+	}
+}
+
+// GetSuggestions handles GET /api/users/me/suggestions
+func (c *SuggestionController) GetSuggestions(ctx echo.Context) error {
+	// Get current user from context
+	currentUser, err := GetUserFromContext(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	limit := int32(10)
+	if raw := ctx.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, pagination.ErrInvalidLimit.Error())
+		}
+		if parsed < int(pagination.MaxLimit) {
+			limit = int32(parsed)
+		} else {
+			limit = pagination.MaxLimit
+		}
+	}
+
+	suggestions, err := c.suggestionService.GetSuggestions(ctx.Request().Context(), currentUser.ID, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get follow suggestions")
+	}
+
+	return ctx.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	hmiddleware "horizon-backend/internal/middleware"
+	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/service"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookController struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookController(webhookService *service.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+// GetWebhooks handles GET /api/webhooks
+func (c *WebhookController) GetWebhooks(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	webhooks, err := c.webhookService.GetWebhooks(ctx.Request().Context(), userID.Bytes)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get webhooks")
+	}
+
+	return ctx.JSON(http.StatusOK, webhooks)
+}
+
+// CreateWebhook handles POST /api/webhooks. The response is the only one
+// that includes the webhook's secret.
+func (c *WebhookController) CreateWebhook(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var request struct {
+		URL    string               `json:"url"`
+		Events []model.WebhookEvent `json:"events"`
+	}
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	webhook, err := c.webhookService.CreateWebhook(ctx.Request().Context(), userID.Bytes, request.URL, request.Events)
+	if err != nil {
+		return webhookError(err)
+	}
+
+	return ctx.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhook handles GET /api/webhooks/:id
+func (c *WebhookController) GetWebhook(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var webhookID pgtype.UUID
+	if err := webhookID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook ID format")
+	}
+
+	webhook, err := c.webhookService.GetWebhook(ctx.Request().Context(), userID.Bytes, webhookID.Bytes)
+	if err != nil {
+		return webhookError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook handles PUT /api/webhooks/:id. Fields left out of the
+// request keep their current values.
+func (c *WebhookController) UpdateWebhook(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var webhookID pgtype.UUID
+	if err := webhookID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook ID format")
+	}
+
+	webhook, err := c.webhookService.GetWebhook(ctx.Request().Context(), userID.Bytes, webhookID.Bytes)
+	if err != nil {
+		return webhookError(err)
+	}
+
+	// Decoding onto the current values keeps the fields not given
+	request := struct {
+		URL    string               `json:"url"`
+		Events []model.WebhookEvent `json:"events"`
+		Active bool                 `json:"active"`
+	}{webhook.URL, webhook.Events, webhook.Active}
+	if err := ctx.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request format")
+	}
+
+	updated, err := c.webhookService.UpdateWebhook(ctx.Request().Context(), userID.Bytes, webhookID.Bytes, request.URL, request.Events, request.Active)
+	if err != nil {
+		return webhookError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/:id
+func (c *WebhookController) DeleteWebhook(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var webhookID pgtype.UUID
+	if err := webhookID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook ID format")
+	}
+
+	if err := c.webhookService.DeleteWebhook(ctx.Request().Context(), userID.Bytes, webhookID.Bytes); err != nil {
+		return webhookError(err)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// GetDeliveries handles GET /api/webhooks/:id/deliveries, the webhook's
+// delivery log
+func (c *WebhookController) GetDeliveries(ctx echo.Context) error {
+	userID := hmiddleware.GetUserIDFromContext(ctx)
+	if !userID.Valid {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+	}
+
+	var webhookID pgtype.UUID
+	if err := webhookID.Scan(ctx.Param("id")); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid webhook ID format")
+	}
+
+	page, err := pagination.FromRequest(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	deliveries, err := c.webhookService.GetDeliveries(ctx.Request().Context(), userID.Bytes, webhookID.Bytes, page)
+	if err != nil {
+		return webhookError(err)
+	}
+
+	return ctx.JSON(http.StatusOK, deliveries)
+}
+
+func webhookError(err error) error {
+	switch {
+	case err.Error() == "invalid webhook url", err.Error() == "at least one event is required",
+		strings.HasPrefix(err.Error(), "invalid webhook event:"):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case err.Error() == "too many webhooks":
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("a user can have at most %d webhooks", model.MaxWebhooks))
+	case err.Error() == "webhook not found":
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, "failed to manage webhook")
+	}
+}
@@ -42,29 +42,73 @@ func (q *Queries) DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams)
 }
 
 const getUserBookmarkedPosts = `-- name: GetUserBookmarkedPosts :many
-SELECT p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count
+SELECT p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count, b.created_at AS bookmarked_at, u.username, u.display_name, u.avatar_url
 FROM posts p
+JOIN users u ON p.user_id = u.id
 JOIN bookmarks b ON b.post_id = p.id
 WHERE b.user_id = $1
-ORDER BY b.created_at DESC
-LIMIT $2 OFFSET $3
+AND p.deleted_at IS NULL
+AND can_view_post($1, p.user_id, p.is_private, u.is_private)
+AND (b.created_at, p.id) < ($2::timestamptz, $3::uuid)
+AND (b.created_at, p.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN b.created_at END,
+    CASE WHEN $6::boolean THEN p.id END,
+    b.created_at DESC,
+    p.id DESC
+LIMIT $7
 `
 
 type GetUserBookmarkedPostsParams struct {
-	UserID pgtype.UUID `json:"user_id"`
-	Limit  int32       `json:"limit"`
-	Offset int32       `json:"offset"`
+	UserID          pgtype.UUID        `json:"user_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+type GetUserBookmarkedPostsRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	BookmarkedAt   pgtype.Timestamptz `json:"bookmarked_at"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
 }
 
-func (q *Queries) GetUserBookmarkedPosts(ctx context.Context, arg GetUserBookmarkedPostsParams) ([]Post, error) {
-	rows, err := q.db.Query(ctx, getUserBookmarkedPosts, arg.UserID, arg.Limit, arg.Offset)
+func (q *Queries) GetUserBookmarkedPosts(ctx context.Context, arg GetUserBookmarkedPostsParams) ([]GetUserBookmarkedPostsRow, error) {
+	rows, err := q.db.Query(ctx, getUserBookmarkedPosts,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetUserBookmarkedPostsRow
 	for rows.Next() {
-		var i Post
+		var i GetUserBookmarkedPostsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
@@ -74,10 +118,18 @@ func (q *Queries) GetUserBookmarkedPosts(ctx context.Context, arg GetUserBookmar
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
+			&i.BookmarkedAt,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -14,7 +14,7 @@ import (
 const acceptFollow = `-- name: AcceptFollow :one
 UPDATE follows
 SET is_accepted = true
-WHERE follower_id = $1 AND followed_id = $2
+WHERE follower_id = $1 AND followed_id = $2 AND is_accepted = false
 RETURNING follower_id, followed_id, is_accepted, created_at
 `
 
@@ -37,9 +37,10 @@ func (q *Queries) AcceptFollow(ctx context.Context, arg AcceptFollowParams) (Fol
 
 const createFollow = `-- name: CreateFollow :one
 INSERT INTO follows (follower_id, followed_id, is_accepted)
-VALUES ($1, $2, CASE WHEN (
-    SELECT is_private FROM users WHERE id = $2
-) THEN false ELSE true END)
+SELECT $1::uuid, $2::uuid, CASE WHEN (
+    SELECT is_private FROM users WHERE id = $2::uuid
+) THEN false ELSE true END
+WHERE NOT is_blocked_between($1::uuid, $2::uuid)
 RETURNING follower_id, followed_id, is_accepted, created_at
 `
 
@@ -48,6 +49,8 @@ type CreateFollowParams struct {
 	FollowedID pgtype.UUID `json:"followed_id"`
 }
 
+// Follows an account, or asks to when it's private. No rows when there's a
+// block between the two.
 func (q *Queries) CreateFollow(ctx context.Context, arg CreateFollowParams) (Follow, error) {
 	row := q.db.QueryRow(ctx, createFollow, arg.FollowerID, arg.FollowedID)
 	var i Follow
@@ -75,12 +78,67 @@ func (q *Queries) DeleteFollow(ctx context.Context, arg DeleteFollowParams) erro
 	return err
 }
 
+const deleteFollowRequest = `-- name: DeleteFollowRequest :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followed_id = $2 AND is_accepted = false
+`
+
+type DeleteFollowRequestParams struct {
+	FollowerID pgtype.UUID `json:"follower_id"`
+	FollowedID pgtype.UUID `json:"followed_id"`
+}
+
+// Removes a follow that is still waiting to be accepted
+func (q *Queries) DeleteFollowRequest(ctx context.Context, arg DeleteFollowRequestParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFollowRequest, arg.FollowerID, arg.FollowedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteFollowsBetween = `-- name: DeleteFollowsBetween :many
+DELETE FROM follows
+WHERE (follower_id = $1 AND followed_id = $2)
+    OR (follower_id = $2 AND followed_id = $1)
+RETURNING follower_id, followed_id, is_accepted, created_at
+`
+
+type DeleteFollowsBetweenParams struct {
+	UserID      pgtype.UUID `json:"user_id"`
+	OtherUserID pgtype.UUID `json:"other_user_id"`
+}
+
+// Removes the follows and follow requests between two users, in both
+// directions
+func (q *Queries) DeleteFollowsBetween(ctx context.Context, arg DeleteFollowsBetweenParams) ([]Follow, error) {
+	rows, err := q.db.Query(ctx, deleteFollowsBetween, arg.UserID, arg.OtherUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Follow
+	for rows.Next() {
+		var i Follow
+		if err := rows.Scan(
+			&i.FollowerID,
+			&i.FollowedID,
+			&i.IsAccepted,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFollowStatus = `-- name: GetFollowStatus :one
-SELECT EXISTS (
-    SELECT 1 FROM follows
-    WHERE follower_id = $1 AND followed_id = $2
-    AND is_accepted = true
-) as is_following
+SELECT is_accepted FROM follows
+WHERE follower_id = $1 AND followed_id = $2
 `
 
 type GetFollowStatusParams struct {
@@ -88,11 +146,40 @@ type GetFollowStatusParams struct {
 	FollowedID pgtype.UUID `json:"followed_id"`
 }
 
+// Whether a follow was accepted; no rows when there's no follow or request
 func (q *Queries) GetFollowStatus(ctx context.Context, arg GetFollowStatusParams) (bool, error) {
 	row := q.db.QueryRow(ctx, getFollowStatus, arg.FollowerID, arg.FollowedID)
-	var is_following bool
-	err := row.Scan(&is_following)
-	return is_following, err
+	var is_accepted bool
+	err := row.Scan(&is_accepted)
+	return is_accepted, err
+}
+
+const getFollowedUserIDs = `-- name: GetFollowedUserIDs :many
+SELECT followed_id FROM follows
+WHERE follower_id = $1 AND is_accepted = true
+AND NOT is_account_muted(follower_id, followed_id)
+`
+
+// Accounts whose posts appear in a user's home timeline: accepted follows
+// that aren't muted
+func (q *Queries) GetFollowedUserIDs(ctx context.Context, followerID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getFollowedUserIDs, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var followed_id pgtype.UUID
+		if err := rows.Scan(&followed_id); err != nil {
+			return nil, err
+		}
+		items = append(items, followed_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getFollowers = `-- name: GetFollowers :many
@@ -109,14 +196,24 @@ FROM users u
 JOIN follows f ON u.id = f.follower_id
 WHERE f.followed_id = $1
 AND f.is_accepted = true
-ORDER BY f.created_at DESC
-LIMIT $2 OFFSET $3
+AND (f.created_at, u.id) < ($2::timestamptz, $3::uuid)
+AND (f.created_at, u.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN f.created_at END,
+    CASE WHEN $6::boolean THEN u.id END,
+    f.created_at DESC,
+    u.id DESC
+LIMIT $7
 `
 
 type GetFollowersParams struct {
-	FollowedID pgtype.UUID `json:"followed_id"`
-	Limit      int32       `json:"limit"`
-	Offset     int32       `json:"offset"`
+	FollowedID      pgtype.UUID        `json:"followed_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetFollowersRow struct {
@@ -131,7 +228,15 @@ type GetFollowersRow struct {
 }
 
 func (q *Queries) GetFollowers(ctx context.Context, arg GetFollowersParams) ([]GetFollowersRow, error) {
-	rows, err := q.db.Query(ctx, getFollowers, arg.FollowedID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getFollowers,
+		arg.FollowedID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -187,14 +292,24 @@ FROM users u
 JOIN follows f ON u.id = f.followed_id
 WHERE f.follower_id = $1
 AND f.is_accepted = true
-ORDER BY f.created_at DESC
-LIMIT $2 OFFSET $3
+AND (f.created_at, u.id) < ($2::timestamptz, $3::uuid)
+AND (f.created_at, u.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN f.created_at END,
+    CASE WHEN $6::boolean THEN u.id END,
+    f.created_at DESC,
+    u.id DESC
+LIMIT $7
 `
 
 type GetFollowingParams struct {
-	FollowerID pgtype.UUID `json:"follower_id"`
-	Limit      int32       `json:"limit"`
-	Offset     int32       `json:"offset"`
+	FollowerID      pgtype.UUID        `json:"follower_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetFollowingRow struct {
@@ -209,7 +324,15 @@ type GetFollowingRow struct {
 }
 
 func (q *Queries) GetFollowing(ctx context.Context, arg GetFollowingParams) ([]GetFollowingRow, error) {
-	rows, err := q.db.Query(ctx, getFollowing, arg.FollowerID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getFollowing,
+		arg.FollowerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -263,14 +386,24 @@ SELECT
 FROM follows f
 JOIN users u ON f.follower_id = u.id
 WHERE f.followed_id = $1 AND f.is_accepted = false AND u.deleted_at IS NULL
-ORDER BY f.created_at DESC
-LIMIT $2 OFFSET $3
+AND (f.created_at, u.id) < ($2::timestamptz, $3::uuid)
+AND (f.created_at, u.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN f.created_at END,
+    CASE WHEN $6::boolean THEN u.id END,
+    f.created_at DESC,
+    u.id DESC
+LIMIT $7
 `
 
 type GetPendingFollowRequestsParams struct {
-	FollowedID pgtype.UUID `json:"followed_id"`
-	Limit      int32       `json:"limit"`
-	Offset     int32       `json:"offset"`
+	FollowedID      pgtype.UUID        `json:"followed_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetPendingFollowRequestsRow struct {
@@ -284,7 +417,15 @@ type GetPendingFollowRequestsRow struct {
 }
 
 func (q *Queries) GetPendingFollowRequests(ctx context.Context, arg GetPendingFollowRequestsParams) ([]GetPendingFollowRequestsRow, error) {
-	rows, err := q.db.Query(ctx, getPendingFollowRequests, arg.FollowedID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getPendingFollowRequests,
+		arg.FollowedID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -11,13 +11,145 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ConversationRole string
+
+const (
+	ConversationRoleOwner  ConversationRole = "owner"
+	ConversationRoleMember ConversationRole = "member"
+)
+
+func (e *ConversationRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ConversationRole(s)
+	case string:
+		*e = ConversationRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ConversationRole: %T", src)
+	}
+	return nil
+}
+
+type NullConversationRole struct {
+	ConversationRole ConversationRole `json:"conversation_role"`
+	Valid            bool             `json:"valid"` // Valid is true if ConversationRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullConversationRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.ConversationRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ConversationRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullConversationRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ConversationRole), nil
+}
+
+type DigestFrequency string
+
+const (
+	DigestFrequencyOff    DigestFrequency = "off"
+	DigestFrequencyDaily  DigestFrequency = "daily"
+	DigestFrequencyWeekly DigestFrequency = "weekly"
+)
+
+func (e *DigestFrequency) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DigestFrequency(s)
+	case string:
+		*e = DigestFrequency(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DigestFrequency: %T", src)
+	}
+	return nil
+}
+
+type NullDigestFrequency struct {
+	DigestFrequency DigestFrequency `json:"digest_frequency"`
+	Valid           bool            `json:"valid"` // Valid is true if DigestFrequency is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDigestFrequency) Scan(value interface{}) error {
+	if value == nil {
+		ns.DigestFrequency, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DigestFrequency.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDigestFrequency) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DigestFrequency), nil
+}
+
+type FilterAction string
+
+const (
+	FilterActionHide FilterAction = "hide"
+	FilterActionWarn FilterAction = "warn"
+)
+
+func (e *FilterAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FilterAction(s)
+	case string:
+		*e = FilterAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FilterAction: %T", src)
+	}
+	return nil
+}
+
+type NullFilterAction struct {
+	FilterAction FilterAction `json:"filter_action"`
+	Valid        bool         `json:"valid"` // Valid is true if FilterAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFilterAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.FilterAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FilterAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFilterAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FilterAction), nil
+}
+
 type NotificationType string
 
 const (
-	NotificationTypeLike   NotificationType = "like"
-	NotificationTypeRepost NotificationType = "repost"
-	NotificationTypeReply  NotificationType = "reply"
-	NotificationTypeFollow NotificationType = "follow"
+	NotificationTypeLike           NotificationType = "like"
+	NotificationTypeRepost         NotificationType = "repost"
+	NotificationTypeReply          NotificationType = "reply"
+	NotificationTypeFollow         NotificationType = "follow"
+	NotificationTypeQuote          NotificationType = "quote"
+	NotificationTypeMention        NotificationType = "mention"
+	NotificationTypeFollowRequest  NotificationType = "follow_request"
+	NotificationTypeFollowAccepted NotificationType = "follow_accepted"
+	NotificationTypeThreadReply    NotificationType = "thread_reply"
 )
 
 func (e *NotificationType) Scan(src interface{}) error {
@@ -55,12 +187,161 @@ func (ns NullNotificationType) Value() (driver.Value, error) {
 	return string(ns.NotificationType), nil
 }
 
+type ReplyPolicy string
+
+const (
+	ReplyPolicyEveryone  ReplyPolicy = "everyone"
+	ReplyPolicyFollowing ReplyPolicy = "following"
+	ReplyPolicyMentioned ReplyPolicy = "mentioned"
+)
+
+func (e *ReplyPolicy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReplyPolicy(s)
+	case string:
+		*e = ReplyPolicy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReplyPolicy: %T", src)
+	}
+	return nil
+}
+
+type NullReplyPolicy struct {
+	ReplyPolicy ReplyPolicy `json:"reply_policy"`
+	Valid       bool        `json:"valid"` // Valid is true if ReplyPolicy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReplyPolicy) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReplyPolicy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReplyPolicy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReplyPolicy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReplyPolicy), nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus `json:"webhook_delivery_status"`
+	Valid                 bool                  `json:"valid"` // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
+type Block struct {
+	BlockerID pgtype.UUID        `json:"blocker_id"`
+	BlockedID pgtype.UUID        `json:"blocked_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type Bookmark struct {
 	UserID    pgtype.UUID        `json:"user_id"`
 	PostID    pgtype.UUID        `json:"post_id"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
+type Conversation struct {
+	ID             pgtype.UUID        `json:"id"`
+	IsGroup        bool               `json:"is_group"`
+	Title          pgtype.Text        `json:"title"`
+	DirectUser1ID  pgtype.UUID        `json:"direct_user1_id"`
+	DirectUser2ID  pgtype.UUID        `json:"direct_user2_id"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	LastActivityAt pgtype.Timestamptz `json:"last_activity_at"`
+}
+
+type ConversationMember struct {
+	ConversationID    pgtype.UUID        `json:"conversation_id"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	Role              ConversationRole   `json:"role"`
+	JoinedAt          pgtype.Timestamptz `json:"joined_at"`
+	LastReadMessageID pgtype.UUID        `json:"last_read_message_id"`
+	LastReadAt        pgtype.Timestamptz `json:"last_read_at"`
+}
+
+type ConversationMute struct {
+	UserID         pgtype.UUID        `json:"user_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type EmailDigestSetting struct {
+	UserID           pgtype.UUID        `json:"user_id"`
+	Frequency        DigestFrequency    `json:"frequency"`
+	Hour             int32              `json:"hour"`
+	Weekday          int32              `json:"weekday"`
+	Timezone         string             `json:"timezone"`
+	UnsubscribeToken string             `json:"unsubscribe_token"`
+	LastSentAt       pgtype.Timestamptz `json:"last_sent_at"`
+	NextSendAt       pgtype.Timestamptz `json:"next_send_at"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Filter struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Phrase    string             `json:"phrase"`
+	IsRegex   bool               `json:"is_regex"`
+	WholeWord bool               `json:"whole_word"`
+	Scopes    []string           `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type FilterSetting struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Action    FilterAction       `json:"action"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Follow struct {
 	FollowerID pgtype.UUID        `json:"follower_id"`
 	FollowedID pgtype.UUID        `json:"followed_id"`
@@ -68,6 +349,21 @@ type Follow struct {
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
+type FollowSuggestion struct {
+	UserID              pgtype.UUID        `json:"user_id"`
+	SuggestedID         pgtype.UUID        `json:"suggested_id"`
+	Score               float64            `json:"score"`
+	MutualCount         int32              `json:"mutual_count"`
+	MutualIds           []pgtype.UUID      `json:"mutual_ids"`
+	SharedFollowerCount int32              `json:"shared_follower_count"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+}
+
+type FollowSuggestionRefresh struct {
+	UserID      pgtype.UUID        `json:"user_id"`
+	RefreshedAt pgtype.Timestamptz `json:"refreshed_at"`
+}
+
 type Medium struct {
 	ID         pgtype.UUID        `json:"id"`
 	PostID     pgtype.UUID        `json:"post_id"`
@@ -83,12 +379,11 @@ type Mention struct {
 }
 
 type Message struct {
-	ID         pgtype.UUID        `json:"id"`
-	SenderID   pgtype.UUID        `json:"sender_id"`
-	ReceiverID pgtype.UUID        `json:"receiver_id"`
-	Content    string             `json:"content"`
-	Read       bool               `json:"read"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	ID             pgtype.UUID        `json:"id"`
+	SenderID       pgtype.UUID        `json:"sender_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
 }
 
 type Notification struct {
@@ -102,21 +397,46 @@ type Notification struct {
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
 	DeletedAt    pgtype.Timestamptz `json:"deleted_at"`
+	GroupID      pgtype.UUID        `json:"group_id"`
+}
+
+type NotificationGroup struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Type      NotificationType   `json:"type"`
+	PostID    pgtype.UUID        `json:"post_id"`
+	GroupKey  pgtype.Text        `json:"group_key"`
+	Read      bool               `json:"read"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type NotificationSetting struct {
+	UserID            pgtype.UUID        `json:"user_id"`
+	Type              NotificationType   `json:"type"`
+	Enabled           bool               `json:"enabled"`
+	OnlyFromFollowing bool               `json:"only_from_following"`
+	Muted             bool               `json:"muted"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
 }
 
 type Post struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
 }
 
 type PostHashtag struct {
@@ -131,6 +451,13 @@ type PostLike struct {
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
+type PostRevision struct {
+	ID        pgtype.UUID        `json:"id"`
+	PostID    pgtype.UUID        `json:"post_id"`
+	Content   string             `json:"content"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type Repost struct {
 	PostID           pgtype.UUID        `json:"post_id"`
 	ReposterID       pgtype.UUID        `json:"reposter_id"`
@@ -156,8 +483,40 @@ type User struct {
 	LastLogin     pgtype.Timestamptz `json:"last_login"`
 }
 
+type UserMute struct {
+	MuterID   pgtype.UUID        `json:"muter_id"`
+	MutedID   pgtype.UUID        `json:"muted_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type UserRelationship struct {
 	FollowerID  pgtype.UUID        `json:"follower_id"`
 	FollowingID pgtype.UUID        `json:"following_id"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
+
+type Webhook struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	Url       string             `json:"url"`
+	Secret    string             `json:"secret"`
+	Events    []string           `json:"events"`
+	Active    bool               `json:"active"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             pgtype.UUID           `json:"id"`
+	WebhookID      pgtype.UUID           `json:"webhook_id"`
+	Event          string                `json:"event"`
+	Payload        []byte                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                 `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz    `json:"next_attempt_at"`
+	LastAttemptAt  pgtype.Timestamptz    `json:"last_attempt_at"`
+	ResponseStatus pgtype.Int4           `json:"response_status"`
+	Error          pgtype.Text           `json:"error"`
+	CreatedAt      pgtype.Timestamptz    `json:"created_at"`
+}
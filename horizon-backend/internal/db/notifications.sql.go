@@ -17,16 +17,20 @@ INSERT INTO notifications (
     actor_id,
     post_id,
     parent_post_id,
-    type
+    type,
+    group_id,
+    read
 )
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5::notification_type
+    $5::notification_type,
+    $6,
+    $7
 )
-RETURNING id, user_id, actor_id, post_id, parent_post_id, type, read, created_at, updated_at, deleted_at
+RETURNING id, user_id, actor_id, post_id, parent_post_id, type, read, created_at, updated_at, deleted_at, group_id
 `
 
 type CreateNotificationParams struct {
@@ -35,6 +39,8 @@ type CreateNotificationParams struct {
 	PostID           pgtype.UUID      `json:"post_id"`
 	ParentPostID     pgtype.UUID      `json:"parent_post_id"`
 	NotificationType NotificationType `json:"notification_type"`
+	GroupID          pgtype.UUID      `json:"group_id"`
+	Read             bool             `json:"read"`
 }
 
 func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
@@ -44,6 +50,8 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 		arg.PostID,
 		arg.ParentPostID,
 		arg.NotificationType,
+		arg.GroupID,
+		arg.Read,
 	)
 	var i Notification
 	err := row.Scan(
@@ -57,10 +65,84 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.GroupID,
 	)
 	return i, err
 }
 
+const createNotificationGroup = `-- name: CreateNotificationGroup :one
+INSERT INTO notification_groups (
+    user_id,
+    type,
+    post_id,
+    group_key,
+    read
+)
+VALUES (
+    $1,
+    $2::notification_type,
+    $3,
+    $4,
+    $5
+)
+RETURNING id, user_id, type, post_id, group_key, read, created_at, updated_at
+`
+
+type CreateNotificationGroupParams struct {
+	UserID           pgtype.UUID      `json:"user_id"`
+	NotificationType NotificationType `json:"notification_type"`
+	PostID           pgtype.UUID      `json:"post_id"`
+	GroupKey         pgtype.Text      `json:"group_key"`
+	Read             bool             `json:"read"`
+}
+
+func (q *Queries) CreateNotificationGroup(ctx context.Context, arg CreateNotificationGroupParams) (NotificationGroup, error) {
+	row := q.db.QueryRow(ctx, createNotificationGroup,
+		arg.UserID,
+		arg.NotificationType,
+		arg.PostID,
+		arg.GroupKey,
+		arg.Read,
+	)
+	var i NotificationGroup
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Type,
+		&i.PostID,
+		&i.GroupKey,
+		&i.Read,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteFollowRequestNotifications = `-- name: DeleteFollowRequestNotifications :execrows
+UPDATE notifications
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = $1
+    AND actor_id = $2
+    AND type = 'follow_request'
+    AND deleted_at IS NULL
+`
+
+type DeleteFollowRequestNotificationsParams struct {
+	UserID  pgtype.UUID `json:"user_id"`
+	ActorID pgtype.UUID `json:"actor_id"`
+}
+
+// Withdraws the follow request notifications an actor sent a user, once the
+// request is gone
+func (q *Queries) DeleteFollowRequestNotifications(ctx context.Context, arg DeleteFollowRequestNotificationsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFollowRequestNotifications, arg.UserID, arg.ActorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteNotification = `-- name: DeleteNotification :exec
 UPDATE notifications
 SET deleted_at = NOW()
@@ -72,32 +154,360 @@ func (q *Queries) DeleteNotification(ctx context.Context, id pgtype.UUID) error
 	return err
 }
 
-const getNotifications = `-- name: GetNotifications :many
+const getNotificationForUser = `-- name: GetNotificationForUser :one
 SELECT 
-    n.id, n.user_id, n.actor_id, n.post_id, n.parent_post_id, n.type, n.read, n.created_at, n.updated_at, n.deleted_at,
-    actor.username as actor_username,
+    n.id, n.user_id, n.actor_id, n.post_id, n.parent_post_id, n.type, n.read, n.created_at, n.updated_at, n.deleted_at, n.group_id,
+    COALESCE(actor.username, '') as actor_username,
     actor.display_name as actor_display_name,
     actor.avatar_url as actor_avatar_url,
     p.content as post_content,
     pp.content as parent_post_content
 FROM notifications n
-JOIN users actor ON n.actor_id = actor.id
-LEFT JOIN posts p ON n.post_id = p.id
-LEFT JOIN posts pp ON n.parent_post_id = pp.id
-WHERE n.user_id = $1 
+LEFT JOIN users actor ON n.actor_id = actor.id AND actor.deleted_at IS NULL
+LEFT JOIN posts p ON n.post_id = p.id AND p.deleted_at IS NULL
+LEFT JOIN posts pp ON n.parent_post_id = pp.id AND pp.deleted_at IS NULL
+WHERE n.id = $1
+    AND n.user_id = $2
     AND n.deleted_at IS NULL
-ORDER BY n.created_at DESC
-LIMIT $2
-OFFSET $3
+    AND NOT is_blocked_between(n.user_id, n.actor_id)
 `
 
-type GetNotificationsParams struct {
+type GetNotificationForUserParams struct {
+	ID     pgtype.UUID `json:"id"`
 	UserID pgtype.UUID `json:"user_id"`
-	Limit  int32       `json:"limit"`
-	Offset int32       `json:"offset"`
 }
 
-type GetNotificationsRow struct {
+type GetNotificationForUserRow struct {
+	ID                pgtype.UUID        `json:"id"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	ActorID           pgtype.UUID        `json:"actor_id"`
+	PostID            pgtype.UUID        `json:"post_id"`
+	ParentPostID      pgtype.UUID        `json:"parent_post_id"`
+	Type              NotificationType   `json:"type"`
+	Read              bool               `json:"read"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt         pgtype.Timestamptz `json:"deleted_at"`
+	GroupID           pgtype.UUID        `json:"group_id"`
+	ActorUsername     string             `json:"actor_username"`
+	ActorDisplayName  pgtype.Text        `json:"actor_display_name"`
+	ActorAvatarUrl    pgtype.Text        `json:"actor_avatar_url"`
+	PostContent       pgtype.Text        `json:"post_content"`
+	ParentPostContent pgtype.Text        `json:"parent_post_content"`
+}
+
+// A single notification of a user, with the same fields as GetNotificationsSince
+func (q *Queries) GetNotificationForUser(ctx context.Context, arg GetNotificationForUserParams) (GetNotificationForUserRow, error) {
+	row := q.db.QueryRow(ctx, getNotificationForUser, arg.ID, arg.UserID)
+	var i GetNotificationForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ActorID,
+		&i.PostID,
+		&i.ParentPostID,
+		&i.Type,
+		&i.Read,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.GroupID,
+		&i.ActorUsername,
+		&i.ActorDisplayName,
+		&i.ActorAvatarUrl,
+		&i.PostContent,
+		&i.ParentPostContent,
+	)
+	return i, err
+}
+
+const getNotificationGroupActors = `-- name: GetNotificationGroupActors :many
+SELECT
+    a.group_id,
+    u.id,
+    u.username,
+    u.display_name,
+    u.avatar_url
+FROM (
+    SELECT
+        n.group_id,
+        n.actor_id,
+        ROW_NUMBER() OVER (PARTITION BY n.group_id ORDER BY MAX(n.created_at) DESC, n.actor_id) AS position
+    FROM notifications n
+    WHERE n.group_id = ANY($1::uuid[]) AND n.deleted_at IS NULL
+    AND NOT is_blocked_between(n.user_id, n.actor_id)
+    GROUP BY n.group_id, n.actor_id
+) a
+JOIN users u ON u.id = a.actor_id AND u.deleted_at IS NULL
+WHERE a.position <= $2::int
+ORDER BY a.group_id, a.position
+`
+
+type GetNotificationGroupActorsParams struct {
+	GroupIds     []pgtype.UUID `json:"group_ids"`
+	PreviewLimit int32         `json:"preview_limit"`
+}
+
+type GetNotificationGroupActorsRow struct {
+	GroupID     pgtype.UUID `json:"group_id"`
+	ID          pgtype.UUID `json:"id"`
+	Username    string      `json:"username"`
+	DisplayName pgtype.Text `json:"display_name"`
+	AvatarUrl   pgtype.Text `json:"avatar_url"`
+}
+
+// The most recent distinct actors of each group, up to preview_limit per group
+func (q *Queries) GetNotificationGroupActors(ctx context.Context, arg GetNotificationGroupActorsParams) ([]GetNotificationGroupActorsRow, error) {
+	rows, err := q.db.Query(ctx, getNotificationGroupActors, arg.GroupIds, arg.PreviewLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationGroupActorsRow
+	for rows.Next() {
+		var i GetNotificationGroupActorsRow
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationGroups = `-- name: GetNotificationGroups :many
+SELECT
+    g.id, g.user_id, g.type, g.post_id, g.group_key, g.read, g.created_at, g.updated_at,
+    latest.id AS latest_notification_id,
+    latest.parent_post_id,
+    (
+        SELECT COUNT(DISTINCT n.actor_id)
+        FROM notifications n
+        WHERE n.group_id = g.id AND n.deleted_at IS NULL
+        AND NOT is_blocked_between(n.user_id, n.actor_id)
+    ) AS actor_count,
+    p.content AS post_content,
+    pp.content AS parent_post_content
+FROM notification_groups g
+JOIN LATERAL (
+    SELECT n.id, n.parent_post_id
+    FROM notifications n
+    WHERE n.group_id = g.id AND n.deleted_at IS NULL
+    AND NOT is_blocked_between(n.user_id, n.actor_id)
+    ORDER BY n.created_at DESC, n.id DESC
+    LIMIT 1
+) latest ON true
+LEFT JOIN posts p ON g.post_id = p.id AND p.deleted_at IS NULL
+LEFT JOIN posts pp ON latest.parent_post_id = pp.id AND pp.deleted_at IS NULL
+WHERE g.user_id = $1
+    AND (g.updated_at, g.id) < ($2::timestamptz, $3::uuid)
+    AND (g.updated_at, g.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN g.updated_at END,
+    CASE WHEN $6::boolean THEN g.id END,
+    g.updated_at DESC,
+    g.id DESC
+LIMIT $7
+`
+
+type GetNotificationGroupsParams struct {
+	UserID          pgtype.UUID        `json:"user_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+type GetNotificationGroupsRow struct {
+	ID                   pgtype.UUID        `json:"id"`
+	UserID               pgtype.UUID        `json:"user_id"`
+	Type                 NotificationType   `json:"type"`
+	PostID               pgtype.UUID        `json:"post_id"`
+	GroupKey             pgtype.Text        `json:"group_key"`
+	Read                 bool               `json:"read"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	LatestNotificationID pgtype.UUID        `json:"latest_notification_id"`
+	ParentPostID         pgtype.UUID        `json:"parent_post_id"`
+	ActorCount           int64              `json:"actor_count"`
+	PostContent          pgtype.Text        `json:"post_content"`
+	ParentPostContent    pgtype.Text        `json:"parent_post_content"`
+}
+
+// Notification groups of a user, most recently active first, with their
+// latest notification and how many different actors they have
+func (q *Queries) GetNotificationGroups(ctx context.Context, arg GetNotificationGroupsParams) ([]GetNotificationGroupsRow, error) {
+	rows, err := q.db.Query(ctx, getNotificationGroups,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationGroupsRow
+	for rows.Next() {
+		var i GetNotificationGroupsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.PostID,
+			&i.GroupKey,
+			&i.Read,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LatestNotificationID,
+			&i.ParentPostID,
+			&i.ActorCount,
+			&i.PostContent,
+			&i.ParentPostContent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationPolicy = `-- name: GetNotificationPolicy :one
+SELECT
+    (
+        NOT is_blocked_between($1, $2)
+        AND NOT (
+            $3::notification_type IN ('reply', 'thread_reply')
+            AND EXISTS (
+                SELECT 1 FROM posts p
+                JOIN conversation_mutes cm ON cm.conversation_id = COALESCE(p.conversation_id, p.id)
+                WHERE p.id = $4
+                AND cm.user_id = $1
+            )
+        )
+        AND COALESCE(s.enabled, true)
+        AND (
+            NOT COALESCE(s.only_from_following, false)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = $1
+                AND f.followed_id = $2
+                AND f.is_accepted = true
+            )
+        )
+    )::boolean AS allowed,
+    COALESCE(s.muted, false)::boolean AS muted
+FROM (SELECT 1) AS one
+LEFT JOIN notification_settings s ON s.user_id = $1 AND s.type = $3::notification_type
+`
+
+type GetNotificationPolicyParams struct {
+	UserID           pgtype.UUID      `json:"user_id"`
+	ActorID          pgtype.UUID      `json:"actor_id"`
+	NotificationType NotificationType `json:"notification_type"`
+	PostID           pgtype.UUID      `json:"post_id"`
+}
+
+type GetNotificationPolicyRow struct {
+	Allowed bool `json:"allowed"`
+	Muted   bool `json:"muted"`
+}
+
+// How a user's settings treat a notification of a type from an actor about
+// a post: whether it's created at all, and whether it's muted. Nothing is
+// created across a block, and replies in threads the user muted don't
+// notify them.
+func (q *Queries) GetNotificationPolicy(ctx context.Context, arg GetNotificationPolicyParams) (GetNotificationPolicyRow, error) {
+	row := q.db.QueryRow(ctx, getNotificationPolicy,
+		arg.UserID,
+		arg.ActorID,
+		arg.NotificationType,
+		arg.PostID,
+	)
+	var i GetNotificationPolicyRow
+	err := row.Scan(
+		&i.Allowed,
+		&i.Muted,
+	)
+	return i, err
+}
+
+const getNotificationSettings = `-- name: GetNotificationSettings :many
+SELECT user_id, type, enabled, only_from_following, muted, updated_at FROM notification_settings
+WHERE user_id = $1
+`
+
+func (q *Queries) GetNotificationSettings(ctx context.Context, userID pgtype.UUID) ([]NotificationSetting, error) {
+	rows, err := q.db.Query(ctx, getNotificationSettings, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NotificationSetting
+	for rows.Next() {
+		var i NotificationSetting
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Type,
+			&i.Enabled,
+			&i.OnlyFromFollowing,
+			&i.Muted,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationsSince = `-- name: GetNotificationsSince :many
+SELECT 
+    n.id, n.user_id, n.actor_id, n.post_id, n.parent_post_id, n.type, n.read, n.created_at, n.updated_at, n.deleted_at, n.group_id,
+    COALESCE(actor.username, '') as actor_username,
+    actor.display_name as actor_display_name,
+    actor.avatar_url as actor_avatar_url,
+    p.content as post_content,
+    pp.content as parent_post_content
+FROM notifications n
+LEFT JOIN users actor ON n.actor_id = actor.id AND actor.deleted_at IS NULL
+LEFT JOIN posts p ON n.post_id = p.id AND p.deleted_at IS NULL
+LEFT JOIN posts pp ON n.parent_post_id = pp.id AND pp.deleted_at IS NULL
+WHERE n.user_id = $1 
+    AND n.deleted_at IS NULL
+    AND NOT is_blocked_between(n.user_id, n.actor_id)
+    AND (n.created_at, n.id) > ($2::timestamptz, $3::uuid)
+ORDER BY n.created_at, n.id
+LIMIT $4
+`
+
+type GetNotificationsSinceParams struct {
+	UserID         pgtype.UUID        `json:"user_id"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	PageLimit      int32              `json:"page_limit"`
+}
+
+type GetNotificationsSinceRow struct {
 	ID                pgtype.UUID        `json:"id"`
 	UserID            pgtype.UUID        `json:"user_id"`
 	ActorID           pgtype.UUID        `json:"actor_id"`
@@ -108,22 +518,30 @@ type GetNotificationsRow struct {
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
 	DeletedAt         pgtype.Timestamptz `json:"deleted_at"`
-	ActorUsername     pgtype.Text        `json:"actor_username"`
+	GroupID           pgtype.UUID        `json:"group_id"`
+	ActorUsername     string             `json:"actor_username"`
 	ActorDisplayName  pgtype.Text        `json:"actor_display_name"`
 	ActorAvatarUrl    pgtype.Text        `json:"actor_avatar_url"`
 	PostContent       pgtype.Text        `json:"post_content"`
 	ParentPostContent pgtype.Text        `json:"parent_post_content"`
 }
 
-func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]GetNotificationsRow, error) {
-	rows, err := q.db.Query(ctx, getNotifications, arg.UserID, arg.Limit, arg.Offset)
+// Notifications created after a position, oldest first. Used to replay what
+// a reconnecting stream missed.
+func (q *Queries) GetNotificationsSince(ctx context.Context, arg GetNotificationsSinceParams) ([]GetNotificationsSinceRow, error) {
+	rows, err := q.db.Query(ctx, getNotificationsSince,
+		arg.UserID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetNotificationsRow
+	var items []GetNotificationsSinceRow
 	for rows.Next() {
-		var i GetNotificationsRow
+		var i GetNotificationsSinceRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
@@ -135,6 +553,7 @@ func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsPara
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.DeletedAt,
+			&i.GroupID,
 			&i.ActorUsername,
 			&i.ActorDisplayName,
 			&i.ActorAvatarUrl,
@@ -151,14 +570,53 @@ func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsPara
 	return items, nil
 }
 
+const getOpenNotificationGroup = `-- name: GetOpenNotificationGroup :one
+SELECT id, user_id, type, post_id, group_key, read, created_at, updated_at FROM notification_groups
+WHERE user_id = $1
+    AND group_key = $2
+    AND created_at > $3
+ORDER BY created_at DESC
+LIMIT 1
+FOR UPDATE
+`
+
+type GetOpenNotificationGroupParams struct {
+	UserID      pgtype.UUID        `json:"user_id"`
+	GroupKey    pgtype.Text        `json:"group_key"`
+	OpenedAfter pgtype.Timestamptz `json:"opened_after"`
+}
+
+// The user's most recent group with the key that opened after a time, locked
+// so a new notification can join it
+func (q *Queries) GetOpenNotificationGroup(ctx context.Context, arg GetOpenNotificationGroupParams) (NotificationGroup, error) {
+	row := q.db.QueryRow(ctx, getOpenNotificationGroup, arg.UserID, arg.GroupKey, arg.OpenedAfter)
+	var i NotificationGroup
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Type,
+		&i.PostID,
+		&i.GroupKey,
+		&i.Read,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getUnreadNotificationCount = `-- name: GetUnreadNotificationCount :one
 SELECT COUNT(*)
-FROM notifications
-WHERE user_id = $1 
-    AND read = false 
-    AND deleted_at IS NULL
+FROM notification_groups g
+WHERE g.user_id = $1
+    AND g.read = false
+    AND EXISTS (
+        SELECT 1 FROM notifications n
+        WHERE n.group_id = g.id AND n.deleted_at IS NULL
+        AND NOT is_blocked_between(n.user_id, n.actor_id)
+    )
 `
 
+// Unread notification groups of a user
 func (q *Queries) GetUnreadNotificationCount(ctx context.Context, userID pgtype.UUID) (int64, error) {
 	row := q.db.QueryRow(ctx, getUnreadNotificationCount, userID)
 	var count int64
@@ -167,6 +625,12 @@ func (q *Queries) GetUnreadNotificationCount(ctx context.Context, userID pgtype.
 }
 
 const markAllNotificationsAsRead = `-- name: MarkAllNotificationsAsRead :exec
+WITH marked AS (
+    UPDATE notification_groups
+    SET read = true
+    WHERE user_id = $1
+        AND read = false
+)
 UPDATE notifications
 SET read = true,
     updated_at = NOW()
@@ -180,28 +644,87 @@ func (q *Queries) MarkAllNotificationsAsRead(ctx context.Context, userID pgtype.
 	return err
 }
 
-const markNotificationAsRead = `-- name: MarkNotificationAsRead :one
-UPDATE notifications
-SET read = true,
+const markNotificationGroupAsRead = `-- name: MarkNotificationGroupAsRead :one
+WITH marked AS (
+    UPDATE notification_groups
+    SET read = true
+    WHERE id = $1 AND user_id = $2
+    RETURNING id
+), marked_notifications AS (
+    UPDATE notifications
+    SET read = true,
+        updated_at = NOW()
+    WHERE group_id IN (SELECT id FROM marked)
+        AND read = false
+)
+SELECT id FROM marked
+`
+
+type MarkNotificationGroupAsReadParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// Marks a user's notification group and its notifications as read
+func (q *Queries) MarkNotificationGroupAsRead(ctx context.Context, arg MarkNotificationGroupAsReadParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, markNotificationGroupAsRead, arg.ID, arg.UserID)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const touchNotificationGroup = `-- name: TouchNotificationGroup :exec
+UPDATE notification_groups
+SET read = read AND $1,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, user_id, actor_id, post_id, parent_post_id, type, read, created_at, updated_at, deleted_at
+WHERE id = $2
 `
 
-func (q *Queries) MarkNotificationAsRead(ctx context.Context, id pgtype.UUID) (Notification, error) {
-	row := q.db.QueryRow(ctx, markNotificationAsRead, id)
-	var i Notification
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.ActorID,
-		&i.PostID,
-		&i.ParentPostID,
-		&i.Type,
-		&i.Read,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.DeletedAt,
+type TouchNotificationGroupParams struct {
+	Read bool        `json:"read"`
+	ID   pgtype.UUID `json:"id"`
+}
+
+// A notification joined the group: it moves to the top, and unless the
+// notification arrived read the group is unread again
+func (q *Queries) TouchNotificationGroup(ctx context.Context, arg TouchNotificationGroupParams) error {
+	_, err := q.db.Exec(ctx, touchNotificationGroup, arg.Read, arg.ID)
+	return err
+}
+
+const upsertNotificationSettings = `-- name: UpsertNotificationSettings :exec
+INSERT INTO notification_settings (user_id, type, enabled, only_from_following, muted)
+SELECT $1, s.type::notification_type, s.enabled, s.only_from_following, s.muted
+FROM unnest(
+    $2::text[],
+    $3::boolean[],
+    $4::boolean[],
+    $5::boolean[]
+) AS s(type, enabled, only_from_following, muted)
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    only_from_following = EXCLUDED.only_from_following,
+    muted = EXCLUDED.muted,
+    updated_at = NOW()
+`
+
+type UpsertNotificationSettingsParams struct {
+	UserID            pgtype.UUID `json:"user_id"`
+	Types             []string    `json:"types"`
+	Enabled           []bool      `json:"enabled"`
+	OnlyFromFollowing []bool      `json:"only_from_following"`
+	Muted             []bool      `json:"muted"`
+}
+
+// Saves a user's settings for several types at once; the arrays are matched
+// up by position
+func (q *Queries) UpsertNotificationSettings(ctx context.Context, arg UpsertNotificationSettingsParams) error {
+	_, err := q.db.Exec(ctx, upsertNotificationSettings,
+		arg.UserID,
+		arg.Types,
+		arg.Enabled,
+		arg.OnlyFromFollowing,
+		arg.Muted,
 	)
-	return i, err
+	return err
 }
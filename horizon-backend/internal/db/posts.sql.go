@@ -11,21 +11,74 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const canUserReplyToPost = `-- name: CanUserReplyToPost :one
+SELECT (
+    p.user_id = $1
+    OR p.reply_policy = 'everyone'
+    OR (
+        p.reply_policy = 'following'
+        AND EXISTS (
+            SELECT 1 FROM follows f
+            WHERE f.follower_id = p.user_id
+            AND f.followed_id = $1
+            AND f.is_accepted = true
+        )
+    )
+    OR (
+        p.reply_policy = 'mentioned'
+        AND EXISTS (
+            SELECT 1 FROM mentions m
+            WHERE m.post_id = p.id
+            AND m.mentioned_user_id = $1
+        )
+    )
+)::boolean AS can_reply
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.id = $2 AND p.deleted_at IS NULL
+AND can_view_post($1, p.user_id, p.is_private, u.is_private)
+`
+
+type CanUserReplyToPostParams struct {
+	UserID pgtype.UUID `json:"user_id"`
+	PostID pgtype.UUID `json:"post_id"`
+}
+
+// Whether a user may reply to a post under its reply policy. Authors can
+// always reply to their own posts; posts the user can't see aren't found.
+func (q *Queries) CanUserReplyToPost(ctx context.Context, arg CanUserReplyToPostParams) (bool, error) {
+	row := q.db.QueryRow(ctx, canUserReplyToPost, arg.UserID, arg.PostID)
+	var can_reply bool
+	err := row.Scan(&can_reply)
+	return can_reply, err
+}
+
 const createPost = `-- name: CreatePost :one
-WITH new_post AS (
+WITH new_id AS (
+    SELECT gen_random_uuid() AS id
+), new_post AS (
     INSERT INTO posts (
+        id,
         user_id,
         content,
         is_private,
         reply_to_post_id,
-        media_urls
-    ) VALUES (
-        $1, $2, $3, $4, $5
+        media_urls,
+        quoted_post_id,
+        reply_policy,
+        conversation_id
     )
-    RETURNING id, user_id, content, created_at, updated_at, deleted_at, is_private, reply_to_post_id, allow_replies, media_urls, like_count, repost_count
+    SELECT
+        new_id.id, $1, $2, $3, $4, $5, $6, $7,
+        COALESCE(
+            (SELECT COALESCE(parent.conversation_id, parent.id) FROM posts parent WHERE parent.id = $4),
+            new_id.id
+        )
+    FROM new_id
+    RETURNING id, user_id, content, created_at, updated_at, deleted_at, is_private, reply_to_post_id, media_urls, like_count, repost_count, quoted_post_id, conversation_id, reply_policy, edited_at, edit_count
 )
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
     u.avatar_url
@@ -39,26 +92,34 @@ type CreatePostParams struct {
 	IsPrivate     bool        `json:"is_private"`
 	ReplyToPostID pgtype.UUID `json:"reply_to_post_id"`
 	MediaUrls     []string    `json:"media_urls"`
+	QuotedPostID  pgtype.UUID `json:"quoted_post_id"`
+	ReplyPolicy   ReplyPolicy `json:"reply_policy"`
 }
 
 type CreatePostRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
 }
 
+// The id is generated up front so a root post can point conversation_id at
+// itself; replies inherit the conversation of their parent.
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreatePostRow, error) {
 	row := q.db.QueryRow(ctx, createPost,
 		arg.UserID,
@@ -66,6 +127,8 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreateP
 		arg.IsPrivate,
 		arg.ReplyToPostID,
 		arg.MediaUrls,
+		arg.QuotedPostID,
+		arg.ReplyPolicy,
 	)
 	var i CreatePostRow
 	err := row.Scan(
@@ -77,10 +140,14 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreateP
 		&i.DeletedAt,
 		&i.IsPrivate,
 		&i.ReplyToPostID,
-		&i.AllowReplies,
 		&i.MediaUrls,
 		&i.LikeCount,
 		&i.RepostCount,
+		&i.QuotedPostID,
+		&i.ConversationID,
+		&i.ReplyPolicy,
+		&i.EditedAt,
+		&i.EditCount,
 		&i.Username,
 		&i.DisplayName,
 		&i.AvatarUrl,
@@ -88,6 +155,29 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreateP
 	return i, err
 }
 
+const createPostRevision = `-- name: CreatePostRevision :one
+INSERT INTO post_revisions (post_id, content, created_at)
+SELECT p.id, p.content, COALESCE(p.edited_at, p.created_at)
+FROM posts p
+WHERE p.id = $1 AND p.deleted_at IS NULL
+FOR UPDATE
+RETURNING id, post_id, content, created_at
+`
+
+// Saves the current content of a post as a revision. The row is locked so
+// concurrent edits can't lose a version.
+func (q *Queries) CreatePostRevision(ctx context.Context, id pgtype.UUID) (PostRevision, error) {
+	row := q.db.QueryRow(ctx, createPostRevision, id)
+	var i PostRevision
+	err := row.Scan(
+		&i.ID,
+		&i.PostID,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const decrementLikeCount = `-- name: DecrementLikeCount :one
 UPDATE posts
 SET like_count = GREATEST(0, like_count - 1)
@@ -102,6 +192,20 @@ func (q *Queries) DecrementLikeCount(ctx context.Context, id pgtype.UUID) (int32
 	return like_count, err
 }
 
+const decrementRepostCount = `-- name: DecrementRepostCount :one
+UPDATE posts
+SET repost_count = GREATEST(0, repost_count - 1)
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING repost_count
+`
+
+func (q *Queries) DecrementRepostCount(ctx context.Context, id pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, decrementRepostCount, id)
+	var repost_count int32
+	err := row.Scan(&repost_count)
+	return repost_count, err
+}
+
 const deletePost = `-- name: DeletePost :exec
 UPDATE posts 
 SET deleted_at = NOW()
@@ -121,42 +225,66 @@ func (q *Queries) DeletePost(ctx context.Context, arg DeletePostParams) error {
 
 const getAllPosts = `-- name: GetAllPosts :many
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
     u.avatar_url
 FROM posts p
 JOIN users u ON p.user_id = u.id
 WHERE p.deleted_at IS NULL 
-ORDER BY p.created_at DESC
-LIMIT $1 OFFSET $2
+AND can_view_post($1, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < ($2::timestamptz, $3::uuid)
+AND (p.created_at, p.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN p.created_at END,
+    CASE WHEN $6::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT $7
 `
 
 type GetAllPostsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetAllPostsRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
 }
 
 func (q *Queries) GetAllPosts(ctx context.Context, arg GetAllPostsParams) ([]GetAllPostsRow, error) {
-	rows, err := q.db.Query(ctx, getAllPosts, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getAllPosts,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -173,10 +301,14 @@ func (q *Queries) GetAllPosts(ctx context.Context, arg GetAllPostsParams) ([]Get
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
 			&i.Username,
 			&i.DisplayName,
 			&i.AvatarUrl,
@@ -193,35 +325,45 @@ func (q *Queries) GetAllPosts(ctx context.Context, arg GetAllPostsParams) ([]Get
 
 const getPostByID = `-- name: GetPostByID :one
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
     u.avatar_url
 FROM posts p
 JOIN users u ON p.user_id = u.id
 WHERE p.id = $1 AND p.deleted_at IS NULL
+AND can_view_post($2, p.user_id, p.is_private, u.is_private)
 `
 
+type GetPostByIDParams struct {
+	ID       pgtype.UUID `json:"id"`
+	ViewerID pgtype.UUID `json:"viewer_id"`
+}
+
 type GetPostByIDRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
-}
-
-func (q *Queries) GetPostByID(ctx context.Context, id pgtype.UUID) (GetPostByIDRow, error) {
-	row := q.db.QueryRow(ctx, getPostByID, id)
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+}
+
+func (q *Queries) GetPostByID(ctx context.Context, arg GetPostByIDParams) (GetPostByIDRow, error) {
+	row := q.db.QueryRow(ctx, getPostByID, arg.ID, arg.ViewerID)
 	var i GetPostByIDRow
 	err := row.Scan(
 		&i.ID,
@@ -232,10 +374,14 @@ func (q *Queries) GetPostByID(ctx context.Context, id pgtype.UUID) (GetPostByIDR
 		&i.DeletedAt,
 		&i.IsPrivate,
 		&i.ReplyToPostID,
-		&i.AllowReplies,
 		&i.MediaUrls,
 		&i.LikeCount,
 		&i.RepostCount,
+		&i.QuotedPostID,
+		&i.ConversationID,
+		&i.ReplyPolicy,
+		&i.EditedAt,
+		&i.EditCount,
 		&i.Username,
 		&i.DisplayName,
 		&i.AvatarUrl,
@@ -243,6 +389,36 @@ func (q *Queries) GetPostByID(ctx context.Context, id pgtype.UUID) (GetPostByIDR
 	return i, err
 }
 
+const getPostCounts = `-- name: GetPostCounts :one
+SELECT
+    p.id,
+    p.like_count,
+    p.repost_count,
+    (SELECT COUNT(*) FROM posts replies WHERE replies.reply_to_post_id = p.id AND replies.deleted_at IS NULL) AS reply_count
+FROM posts p
+WHERE p.id = $1 AND p.deleted_at IS NULL
+`
+
+type GetPostCountsRow struct {
+	ID          pgtype.UUID `json:"id"`
+	LikeCount   int32       `json:"like_count"`
+	RepostCount int32       `json:"repost_count"`
+	ReplyCount  int64       `json:"reply_count"`
+}
+
+// Engagement counts of a post, sent to live subscribers when they change
+func (q *Queries) GetPostCounts(ctx context.Context, id pgtype.UUID) (GetPostCountsRow, error) {
+	row := q.db.QueryRow(ctx, getPostCounts, id)
+	var i GetPostCountsRow
+	err := row.Scan(
+		&i.ID,
+		&i.LikeCount,
+		&i.RepostCount,
+		&i.ReplyCount,
+	)
+	return i, err
+}
+
 const getPostLikeCount = `-- name: GetPostLikeCount :one
 SELECT COUNT(*) FROM post_likes pl
 WHERE pl.post_id = $1
@@ -255,9 +431,112 @@ func (q *Queries) GetPostLikeCount(ctx context.Context, postID pgtype.UUID) (int
 	return count, err
 }
 
+const getPostQuotes = `-- name: GetPostQuotes :many
+SELECT 
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
+    u.username,
+    u.display_name,
+    u.avatar_url
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.quoted_post_id = $1
+AND p.deleted_at IS NULL
+AND can_view_post($2, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < ($3::timestamptz, $4::uuid)
+AND (p.created_at, p.id) > ($5::timestamptz, $6::uuid)
+ORDER BY
+    CASE WHEN $7::boolean THEN p.created_at END,
+    CASE WHEN $7::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT $8
+`
+
+type GetPostQuotesParams struct {
+	QuotedPostID    pgtype.UUID        `json:"quoted_post_id"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+type GetPostQuotesRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+}
+
+func (q *Queries) GetPostQuotes(ctx context.Context, arg GetPostQuotesParams) ([]GetPostQuotesRow, error) {
+	rows, err := q.db.Query(ctx, getPostQuotes,
+		arg.QuotedPostID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostQuotesRow
+	for rows.Next() {
+		var i GetPostQuotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.IsPrivate,
+			&i.ReplyToPostID,
+			&i.MediaUrls,
+			&i.LikeCount,
+			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPostReplies = `-- name: GetPostReplies :many
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
     u.avatar_url
@@ -265,36 +544,61 @@ FROM posts p
 JOIN users u ON p.user_id = u.id
 WHERE p.reply_to_post_id = $1 
 AND p.deleted_at IS NULL
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3
+AND can_view_post($2, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < ($3::timestamptz, $4::uuid)
+AND (p.created_at, p.id) > ($5::timestamptz, $6::uuid)
+ORDER BY
+    CASE WHEN $7::boolean THEN p.created_at END,
+    CASE WHEN $7::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT $8
 `
 
 type GetPostRepliesParams struct {
-	ReplyToPostID pgtype.UUID `json:"reply_to_post_id"`
-	Limit         int32       `json:"limit"`
-	Offset        int32       `json:"offset"`
+	ReplyToPostID   pgtype.UUID        `json:"reply_to_post_id"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetPostRepliesRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
 }
 
 func (q *Queries) GetPostReplies(ctx context.Context, arg GetPostRepliesParams) ([]GetPostRepliesRow, error) {
-	rows, err := q.db.Query(ctx, getPostReplies, arg.ReplyToPostID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getPostReplies,
+		arg.ReplyToPostID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -311,10 +615,14 @@ func (q *Queries) GetPostReplies(ctx context.Context, arg GetPostRepliesParams)
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
 			&i.Username,
 			&i.DisplayName,
 			&i.AvatarUrl,
@@ -341,68 +649,280 @@ func (q *Queries) GetPostRepostCount(ctx context.Context, postID pgtype.UUID) (i
 	return count, err
 }
 
-const getPostStats = `-- name: GetPostStats :one
+const getPostReposters = `-- name: GetPostReposters :many
 SELECT 
-    (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = p.id) as like_count,
-    (SELECT COUNT(*) FROM reposts r WHERE r.post_id = p.id) as repost_count,
-    (SELECT COUNT(*) FROM posts replies WHERE replies.reply_to_post_id = p.id AND replies.deleted_at IS NULL) as reply_count
+    u.id,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    u.is_private,
+    r.created_at AS reposted_at
+FROM reposts r
+JOIN users u ON r.reposter_id = u.id
+WHERE r.post_id = $1
+AND u.deleted_at IS NULL
+AND NOT is_blocked_between($2, u.id)
+AND (r.created_at, u.id) < ($3::timestamptz, $4::uuid)
+AND (r.created_at, u.id) > ($5::timestamptz, $6::uuid)
+ORDER BY
+    CASE WHEN $7::boolean THEN r.created_at END,
+    CASE WHEN $7::boolean THEN u.id END,
+    r.created_at DESC,
+    u.id DESC
+LIMIT $8
+`
+
+type GetPostRepostersParams struct {
+	PostID          pgtype.UUID        `json:"post_id"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+type GetPostRepostersRow struct {
+	ID          pgtype.UUID        `json:"id"`
+	Username    string             `json:"username"`
+	DisplayName pgtype.Text        `json:"display_name"`
+	AvatarUrl   pgtype.Text        `json:"avatar_url"`
+	IsPrivate   bool               `json:"is_private"`
+	RepostedAt  pgtype.Timestamptz `json:"reposted_at"`
+}
+
+func (q *Queries) GetPostReposters(ctx context.Context, arg GetPostRepostersParams) ([]GetPostRepostersRow, error) {
+	rows, err := q.db.Query(ctx, getPostReposters,
+		arg.PostID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostRepostersRow
+	for rows.Next() {
+		var i GetPostRepostersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.IsPrivate,
+			&i.RepostedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostRevisions = `-- name: GetPostRevisions :many
+SELECT id, post_id, content, created_at
+FROM post_revisions r
+WHERE r.post_id = $1
+AND (r.created_at, r.id) < ($2::timestamptz, $3::uuid)
+AND (r.created_at, r.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN r.created_at END,
+    CASE WHEN $6::boolean THEN r.id END,
+    r.created_at DESC,
+    r.id DESC
+LIMIT $7
+`
+
+type GetPostRevisionsParams struct {
+	PostID          pgtype.UUID        `json:"post_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+// Earlier versions of a post, newest first
+func (q *Queries) GetPostRevisions(ctx context.Context, arg GetPostRevisionsParams) ([]PostRevision, error) {
+	rows, err := q.db.Query(ctx, getPostRevisions,
+		arg.PostID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostRevision
+	for rows.Next() {
+		var i PostRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.Content,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostViewerState = `-- name: GetPostViewerState :many
+SELECT
+    p.id AS post_id,
+    EXISTS (SELECT 1 FROM post_likes pl WHERE pl.post_id = p.id AND pl.user_id = $1) AS has_liked,
+    EXISTS (SELECT 1 FROM bookmarks b WHERE b.post_id = p.id AND b.user_id = $1) AS has_bookmarked,
+    EXISTS (SELECT 1 FROM reposts r WHERE r.post_id = p.id AND r.reposter_id = $1) AS has_reposted,
+    (SELECT COUNT(*) FROM posts replies WHERE replies.reply_to_post_id = p.id AND replies.deleted_at IS NULL) AS reply_count
 FROM posts p
-WHERE p.id = $1 AND p.deleted_at IS NULL
+WHERE p.id = ANY($2::uuid[])
 `
 
-type GetPostStatsRow struct {
-	LikeCount   int64 `json:"like_count"`
-	RepostCount int64 `json:"repost_count"`
-	ReplyCount  int64 `json:"reply_count"`
+type GetPostViewerStateParams struct {
+	ViewerID pgtype.UUID   `json:"viewer_id"`
+	PostIds  []pgtype.UUID `json:"post_ids"`
 }
 
-func (q *Queries) GetPostStats(ctx context.Context, id pgtype.UUID) (GetPostStatsRow, error) {
-	row := q.db.QueryRow(ctx, getPostStats, id)
-	var i GetPostStatsRow
-	err := row.Scan(&i.LikeCount, &i.RepostCount, &i.ReplyCount)
-	return i, err
+type GetPostViewerStateRow struct {
+	PostID        pgtype.UUID `json:"post_id"`
+	HasLiked      bool        `json:"has_liked"`
+	HasBookmarked bool        `json:"has_bookmarked"`
+	HasReposted   bool        `json:"has_reposted"`
+	ReplyCount    int64       `json:"reply_count"`
+}
+
+// Viewer state for a page of posts, fetched in one round trip
+func (q *Queries) GetPostViewerState(ctx context.Context, arg GetPostViewerStateParams) ([]GetPostViewerStateRow, error) {
+	rows, err := q.db.Query(ctx, getPostViewerState, arg.ViewerID, arg.PostIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostViewerStateRow
+	for rows.Next() {
+		var i GetPostViewerStateRow
+		if err := rows.Scan(
+			&i.PostID,
+			&i.HasLiked,
+			&i.HasBookmarked,
+			&i.HasReposted,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getPostsByUserID = `-- name: GetPostsByUserID :many
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
-    u.avatar_url
-FROM posts p
+    u.avatar_url,
+    t.activity_at,
+    t.reposted_by_id,
+    ru.username AS reposted_by_username,
+    ru.display_name AS reposted_by_display_name
+FROM (
+    SELECT p.id AS post_id, p.created_at AS activity_at, NULL::uuid AS reposted_by_id
+    FROM posts p
+    WHERE p.user_id = $1
+    UNION ALL
+    SELECT r.post_id, r.created_at, r.reposter_id
+    FROM reposts r
+    JOIN users reposter ON reposter.id = r.reposter_id
+    WHERE r.reposter_id = $1
+    AND can_view_account($2, reposter.id, reposter.is_private)
+) t
+JOIN posts p ON p.id = t.post_id
 JOIN users u ON p.user_id = u.id
-WHERE p.user_id = $1 
-AND p.deleted_at IS NULL 
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3
+LEFT JOIN users ru ON ru.id = t.reposted_by_id
+WHERE p.deleted_at IS NULL 
+AND can_view_post($2, p.user_id, p.is_private, u.is_private)
+AND (t.activity_at, p.id) < ($3::timestamptz, $4::uuid)
+AND (t.activity_at, p.id) > ($5::timestamptz, $6::uuid)
+ORDER BY
+    CASE WHEN $7::boolean THEN t.activity_at END,
+    CASE WHEN $7::boolean THEN p.id END,
+    t.activity_at DESC,
+    p.id DESC
+LIMIT $8
 `
 
 type GetPostsByUserIDParams struct {
-	UserID pgtype.UUID `json:"user_id"`
-	Limit  int32       `json:"limit"`
-	Offset int32       `json:"offset"`
+	UserID          pgtype.UUID        `json:"user_id"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetPostsByUserIDRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID                    pgtype.UUID        `json:"id"`
+	UserID                pgtype.UUID        `json:"user_id"`
+	Content               string             `json:"content"`
+	CreatedAt             pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt             pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt             pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate             bool               `json:"is_private"`
+	ReplyToPostID         pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls             []string           `json:"media_urls"`
+	LikeCount             int32              `json:"like_count"`
+	RepostCount           int32              `json:"repost_count"`
+	QuotedPostID          pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID        pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy           ReplyPolicy        `json:"reply_policy"`
+	EditedAt              pgtype.Timestamptz `json:"edited_at"`
+	EditCount             int32              `json:"edit_count"`
+	Username              string             `json:"username"`
+	DisplayName           pgtype.Text        `json:"display_name"`
+	AvatarUrl             pgtype.Text        `json:"avatar_url"`
+	ActivityAt            pgtype.Timestamptz `json:"activity_at"`
+	RepostedByID          pgtype.UUID        `json:"reposted_by_id"`
+	RepostedByUsername    pgtype.Text        `json:"reposted_by_username"`
+	RepostedByDisplayName pgtype.Text        `json:"reposted_by_display_name"`
 }
 
+// Profile timeline: the user's posts plus posts they reposted, ordered by
+// when each entry appeared on the profile
 func (q *Queries) GetPostsByUserID(ctx context.Context, arg GetPostsByUserIDParams) ([]GetPostsByUserIDRow, error) {
-	rows, err := q.db.Query(ctx, getPostsByUserID, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getPostsByUserID,
+		arg.UserID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -419,13 +939,21 @@ func (q *Queries) GetPostsByUserID(ctx context.Context, arg GetPostsByUserIDPara
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
 			&i.Username,
 			&i.DisplayName,
 			&i.AvatarUrl,
+			&i.ActivityAt,
+			&i.RepostedByID,
+			&i.RepostedByUsername,
+			&i.RepostedByDisplayName,
 		); err != nil {
 			return nil, err
 		}
@@ -439,7 +967,7 @@ func (q *Queries) GetPostsByUserID(ctx context.Context, arg GetPostsByUserIDPara
 
 const getPostsWithHashtag = `-- name: GetPostsWithHashtag :many
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
     u.avatar_url
@@ -448,36 +976,62 @@ JOIN users u ON p.user_id = u.id
 INNER JOIN post_hashtags ph ON p.id = ph.post_id
 WHERE ph.hashtag = $1 
 AND p.deleted_at IS NULL
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3
+AND can_view_post($2, p.user_id, p.is_private, u.is_private)
+AND NOT is_account_muted($2, p.user_id)
+AND (p.created_at, p.id) < ($3::timestamptz, $4::uuid)
+AND (p.created_at, p.id) > ($5::timestamptz, $6::uuid)
+ORDER BY
+    CASE WHEN $7::boolean THEN p.created_at END,
+    CASE WHEN $7::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT $8
 `
 
 type GetPostsWithHashtagParams struct {
-	Hashtag string `json:"hashtag"`
-	Limit   int32  `json:"limit"`
-	Offset  int32  `json:"offset"`
+	Hashtag         string             `json:"hashtag"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetPostsWithHashtagRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
 }
 
 func (q *Queries) GetPostsWithHashtag(ctx context.Context, arg GetPostsWithHashtagParams) ([]GetPostsWithHashtagRow, error) {
-	rows, err := q.db.Query(ctx, getPostsWithHashtag, arg.Hashtag, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getPostsWithHashtag,
+		arg.Hashtag,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -494,10 +1048,14 @@ func (q *Queries) GetPostsWithHashtag(ctx context.Context, arg GetPostsWithHasht
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
 			&i.Username,
 			&i.DisplayName,
 			&i.AvatarUrl,
@@ -512,51 +1070,428 @@ func (q *Queries) GetPostsWithHashtag(ctx context.Context, arg GetPostsWithHasht
 	return items, nil
 }
 
-const getUserFeed = `-- name: GetUserFeed :many
+const getQuotedPosts = `-- name: GetQuotedPosts :many
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
-    u.avatar_url
+    u.avatar_url,
+    (
+        p.deleted_at IS NULL
+        AND u.deleted_at IS NULL
+        AND can_view_post($1, p.user_id, p.is_private, u.is_private)
+    )::boolean AS is_visible
 FROM posts p
 JOIN users u ON p.user_id = u.id
-INNER JOIN follows f ON p.user_id = f.followed_id
-WHERE f.follower_id = $1 
-AND p.deleted_at IS NULL
-AND (NOT p.is_private OR p.user_id IN (
-    SELECT followed_id FROM follows 
-    WHERE follower_id = $1 AND is_accepted = true
-))
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3
+WHERE p.id = ANY($2::uuid[])
+`
+
+type GetQuotedPostsParams struct {
+	ViewerID pgtype.UUID   `json:"viewer_id"`
+	PostIds  []pgtype.UUID `json:"post_ids"`
+}
+
+type GetQuotedPostsRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	IsVisible      bool               `json:"is_visible"`
+}
+
+// Quoted posts embedded in a page of posts. is_visible is false for deleted
+// posts and for private posts the viewer can't see, which callers render as
+// a tombstone.
+func (q *Queries) GetQuotedPosts(ctx context.Context, arg GetQuotedPostsParams) ([]GetQuotedPostsRow, error) {
+	rows, err := q.db.Query(ctx, getQuotedPosts, arg.ViewerID, arg.PostIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetQuotedPostsRow
+	for rows.Next() {
+		var i GetQuotedPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.IsPrivate,
+			&i.ReplyToPostID,
+			&i.MediaUrls,
+			&i.LikeCount,
+			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.IsVisible,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThreadAncestors = `-- name: GetThreadAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.reply_to_post_id, 1 AS depth
+    FROM posts child
+    JOIN posts parent ON parent.id = child.reply_to_post_id
+    WHERE child.id = $1
+    UNION ALL
+    SELECT parent.id, parent.reply_to_post_id, a.depth + 1
+    FROM ancestors a
+    JOIN posts parent ON parent.id = a.reply_to_post_id
+    WHERE a.depth < $2::int
+)
+SELECT 
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    can_view_post($3, p.user_id, p.is_private, u.is_private)::boolean AS is_visible
+FROM ancestors a
+JOIN posts p ON p.id = a.id
+JOIN users u ON p.user_id = u.id
+ORDER BY a.depth DESC
+`
+
+type GetThreadAncestorsParams struct {
+	PostID   pgtype.UUID `json:"post_id"`
+	MaxDepth int32       `json:"max_depth"`
+	ViewerID pgtype.UUID `json:"viewer_id"`
+}
+
+type GetThreadAncestorsRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	IsVisible      bool               `json:"is_visible"`
+}
+
+// Ancestors of a post from the root down, including deleted ones and ones
+// the viewer can't see so the chain stays intact
+func (q *Queries) GetThreadAncestors(ctx context.Context, arg GetThreadAncestorsParams) ([]GetThreadAncestorsRow, error) {
+	rows, err := q.db.Query(ctx, getThreadAncestors, arg.PostID, arg.MaxDepth, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadAncestorsRow
+	for rows.Next() {
+		var i GetThreadAncestorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.IsPrivate,
+			&i.ReplyToPostID,
+			&i.MediaUrls,
+			&i.LikeCount,
+			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.IsVisible,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThreadDescendants = `-- name: GetThreadDescendants :many
+WITH RECURSIVE tree AS (
+    SELECT top.id, 1 AS depth
+    FROM (
+        SELECT c.id
+        FROM posts c
+        JOIN users cu ON cu.id = c.user_id
+        WHERE c.reply_to_post_id = $1
+        AND c.deleted_at IS NULL
+        AND can_view_post($2, c.user_id, c.is_private, cu.is_private)
+        AND (c.created_at, c.id) < ($3::timestamptz, $4::uuid)
+        AND (c.created_at, c.id) > ($5::timestamptz, $6::uuid)
+        ORDER BY
+            CASE WHEN $7::boolean THEN c.created_at END,
+            CASE WHEN $7::boolean THEN c.id END,
+            c.created_at DESC,
+            c.id DESC
+        LIMIT $8
+    ) top
+    UNION ALL
+    SELECT c.id, t.depth + 1
+    FROM tree t
+    CROSS JOIN LATERAL (
+        SELECT r.id
+        FROM posts r
+        JOIN users ru ON ru.id = r.user_id
+        WHERE r.reply_to_post_id = t.id
+        AND r.deleted_at IS NULL
+        AND can_view_post($2, r.user_id, r.is_private, ru.is_private)
+        ORDER BY r.created_at DESC, r.id DESC
+        LIMIT $9::int + 1
+    ) c
+    WHERE t.depth < $10::int
+)
+SELECT 
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    t.depth
+FROM tree t
+JOIN posts p ON p.id = t.id
+JOIN users u ON p.user_id = u.id
+ORDER BY
+    t.depth,
+    CASE WHEN $7::boolean AND t.depth = 1 THEN p.created_at END,
+    CASE WHEN $7::boolean AND t.depth = 1 THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+`
+
+type GetThreadDescendantsParams struct {
+	PostID          pgtype.UUID        `json:"post_id"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
+	SiblingLimit    int32              `json:"sibling_limit"`
+	MaxDepth        int32              `json:"max_depth"`
+}
+
+type GetThreadDescendantsRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	Depth          int32              `json:"depth"`
+}
+
+// Reply tree below a post. The direct replies are keyset paginated; deeper
+// levels keep the newest sibling_limit replies of each post down to max_depth,
+// plus a look-ahead row telling whether the post has more.
+func (q *Queries) GetThreadDescendants(ctx context.Context, arg GetThreadDescendantsParams) ([]GetThreadDescendantsRow, error) {
+	rows, err := q.db.Query(ctx, getThreadDescendants,
+		arg.PostID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+		arg.SiblingLimit,
+		arg.MaxDepth,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadDescendantsRow
+	for rows.Next() {
+		var i GetThreadDescendantsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.IsPrivate,
+			&i.ReplyToPostID,
+			&i.MediaUrls,
+			&i.LikeCount,
+			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarUrl,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserFeed = `-- name: GetUserFeed :many
+SELECT 
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    t.activity_at,
+    t.reposted_by_id,
+    ru.username AS reposted_by_username,
+    ru.display_name AS reposted_by_display_name
+FROM (
+    SELECT p.id AS post_id, p.created_at AS activity_at, NULL::uuid AS reposted_by_id
+    FROM posts p
+    WHERE p.user_id = $1
+    OR EXISTS (
+        SELECT 1 FROM follows f
+        WHERE f.follower_id = $1
+        AND f.followed_id = p.user_id
+        AND f.is_accepted = true
+    )
+    UNION ALL
+    SELECT r.post_id, r.created_at, r.reposter_id
+    FROM reposts r
+    WHERE r.reposter_id = $1
+    OR EXISTS (
+        SELECT 1 FROM follows f
+        WHERE f.follower_id = $1
+        AND f.followed_id = r.reposter_id
+        AND f.is_accepted = true
+    )
+) t
+JOIN posts p ON p.id = t.post_id
+JOIN users u ON p.user_id = u.id
+LEFT JOIN users ru ON ru.id = t.reposted_by_id
+WHERE p.deleted_at IS NULL
+AND u.deleted_at IS NULL
+AND can_view_post($1, p.user_id, p.is_private, u.is_private)
+AND NOT is_account_muted($1, p.user_id)
+AND (t.reposted_by_id IS NULL OR NOT is_account_muted($1, t.reposted_by_id))
+AND (t.activity_at, p.id) < ($2::timestamptz, $3::uuid)
+AND (t.activity_at, p.id) > ($4::timestamptz, $5::uuid)
+ORDER BY
+    CASE WHEN $6::boolean THEN t.activity_at END,
+    CASE WHEN $6::boolean THEN p.id END,
+    t.activity_at DESC,
+    p.id DESC
+LIMIT $7
 `
 
 type GetUserFeedParams struct {
-	FollowerID pgtype.UUID `json:"follower_id"`
-	Limit      int32       `json:"limit"`
-	Offset     int32       `json:"offset"`
+	UserID          pgtype.UUID        `json:"user_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetUserFeedRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID                    pgtype.UUID        `json:"id"`
+	UserID                pgtype.UUID        `json:"user_id"`
+	Content               string             `json:"content"`
+	CreatedAt             pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt             pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt             pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate             bool               `json:"is_private"`
+	ReplyToPostID         pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls             []string           `json:"media_urls"`
+	LikeCount             int32              `json:"like_count"`
+	RepostCount           int32              `json:"repost_count"`
+	QuotedPostID          pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID        pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy           ReplyPolicy        `json:"reply_policy"`
+	EditedAt              pgtype.Timestamptz `json:"edited_at"`
+	EditCount             int32              `json:"edit_count"`
+	Username              string             `json:"username"`
+	DisplayName           pgtype.Text        `json:"display_name"`
+	AvatarUrl             pgtype.Text        `json:"avatar_url"`
+	ActivityAt            pgtype.Timestamptz `json:"activity_at"`
+	RepostedByID          pgtype.UUID        `json:"reposted_by_id"`
+	RepostedByUsername    pgtype.Text        `json:"reposted_by_username"`
+	RepostedByDisplayName pgtype.Text        `json:"reposted_by_display_name"`
 }
 
+// Home timeline: posts and reposts by the user and by accounts they follow
 func (q *Queries) GetUserFeed(ctx context.Context, arg GetUserFeedParams) ([]GetUserFeedRow, error) {
-	rows, err := q.db.Query(ctx, getUserFeed, arg.FollowerID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getUserFeed,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -573,13 +1508,21 @@ func (q *Queries) GetUserFeed(ctx context.Context, arg GetUserFeedParams) ([]Get
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
 			&i.Username,
 			&i.DisplayName,
 			&i.AvatarUrl,
+			&i.ActivityAt,
+			&i.RepostedByID,
+			&i.RepostedByUsername,
+			&i.RepostedByDisplayName,
 		); err != nil {
 			return nil, err
 		}
@@ -593,45 +1536,72 @@ func (q *Queries) GetUserFeed(ctx context.Context, arg GetUserFeedParams) ([]Get
 
 const getUserLikedPosts = `-- name: GetUserLikedPosts :many
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
-    u.avatar_url
+    u.avatar_url,
+    pl.created_at as liked_at
 FROM posts p
 JOIN users u ON p.user_id = u.id
 JOIN post_likes pl ON p.id = pl.post_id
 WHERE pl.user_id = $1 
 AND p.deleted_at IS NULL
-ORDER BY pl.created_at DESC
-LIMIT $2 OFFSET $3
+AND can_view_post($2, p.user_id, p.is_private, u.is_private)
+AND (pl.created_at, p.id) < ($3::timestamptz, $4::uuid)
+AND (pl.created_at, p.id) > ($5::timestamptz, $6::uuid)
+ORDER BY
+    CASE WHEN $7::boolean THEN pl.created_at END,
+    CASE WHEN $7::boolean THEN p.id END,
+    pl.created_at DESC,
+    p.id DESC
+LIMIT $8
 `
 
 type GetUserLikedPostsParams struct {
-	UserID pgtype.UUID `json:"user_id"`
-	Limit  int32       `json:"limit"`
-	Offset int32       `json:"offset"`
+	UserID          pgtype.UUID        `json:"user_id"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetUserLikedPostsRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	LikedAt        pgtype.Timestamptz `json:"liked_at"`
 }
 
 func (q *Queries) GetUserLikedPosts(ctx context.Context, arg GetUserLikedPostsParams) ([]GetUserLikedPostsRow, error) {
-	rows, err := q.db.Query(ctx, getUserLikedPosts, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getUserLikedPosts,
+		arg.UserID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -648,13 +1618,18 @@ func (q *Queries) GetUserLikedPosts(ctx context.Context, arg GetUserLikedPostsPa
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
 			&i.Username,
 			&i.DisplayName,
 			&i.AvatarUrl,
+			&i.LikedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -668,7 +1643,7 @@ func (q *Queries) GetUserLikedPosts(ctx context.Context, arg GetUserLikedPostsPa
 
 const getUserReplies = `-- name: GetUserReplies :many
 SELECT 
-    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.allow_replies, p.media_urls, p.like_count, p.repost_count,
+    p.id, p.user_id, p.content, p.created_at, p.updated_at, p.deleted_at, p.is_private, p.reply_to_post_id, p.media_urls, p.like_count, p.repost_count, p.quoted_post_id, p.conversation_id, p.reply_policy, p.edited_at, p.edit_count,
     u.username,
     u.display_name,
     u.avatar_url
@@ -677,36 +1652,61 @@ JOIN users u ON p.user_id = u.id
 WHERE p.user_id = $1 
 AND p.reply_to_post_id IS NOT NULL
 AND p.deleted_at IS NULL
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3
+AND can_view_post($2, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < ($3::timestamptz, $4::uuid)
+AND (p.created_at, p.id) > ($5::timestamptz, $6::uuid)
+ORDER BY
+    CASE WHEN $7::boolean THEN p.created_at END,
+    CASE WHEN $7::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT $8
 `
 
 type GetUserRepliesParams struct {
-	UserID pgtype.UUID `json:"user_id"`
-	Limit  int32       `json:"limit"`
-	Offset int32       `json:"offset"`
+	UserID          pgtype.UUID        `json:"user_id"`
+	ViewerID        pgtype.UUID        `json:"viewer_id"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	AfterCreatedAt  pgtype.Timestamptz `json:"after_created_at"`
+	AfterID         pgtype.UUID        `json:"after_id"`
+	Ascending       bool               `json:"ascending"`
+	PageLimit       int32              `json:"page_limit"`
 }
 
 type GetUserRepliesRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	Username      string             `json:"username"`
-	DisplayName   pgtype.Text        `json:"display_name"`
-	AvatarUrl     pgtype.Text        `json:"avatar_url"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
+	Username       string             `json:"username"`
+	DisplayName    pgtype.Text        `json:"display_name"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
 }
 
 func (q *Queries) GetUserReplies(ctx context.Context, arg GetUserRepliesParams) ([]GetUserRepliesRow, error) {
-	rows, err := q.db.Query(ctx, getUserReplies, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getUserReplies,
+		arg.UserID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Ascending,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -723,10 +1723,14 @@ func (q *Queries) GetUserReplies(ctx context.Context, arg GetUserRepliesParams)
 			&i.DeletedAt,
 			&i.IsPrivate,
 			&i.ReplyToPostID,
-			&i.AllowReplies,
 			&i.MediaUrls,
 			&i.LikeCount,
 			&i.RepostCount,
+			&i.QuotedPostID,
+			&i.ConversationID,
+			&i.ReplyPolicy,
+			&i.EditedAt,
+			&i.EditCount,
 			&i.Username,
 			&i.DisplayName,
 			&i.AvatarUrl,
@@ -850,7 +1854,7 @@ func (q *Queries) RepostPost(ctx context.Context, arg RepostPostParams) (Repost,
 	return i, err
 }
 
-const unlikePost = `-- name: UnlikePost :exec
+const unlikePost = `-- name: UnlikePost :execrows
 DELETE FROM post_likes pl
 WHERE pl.post_id = $1 AND pl.user_id = $2
 `
@@ -860,18 +1864,41 @@ type UnlikePostParams struct {
 	UserID pgtype.UUID `json:"user_id"`
 }
 
-func (q *Queries) UnlikePost(ctx context.Context, arg UnlikePostParams) error {
-	_, err := q.db.Exec(ctx, unlikePost, arg.PostID, arg.UserID)
-	return err
+func (q *Queries) UnlikePost(ctx context.Context, arg UnlikePostParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unlikePost, arg.PostID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const unrepostPost = `-- name: UnrepostPost :execrows
+DELETE FROM reposts
+WHERE post_id = $1 AND reposter_id = $2
+`
+
+type UnrepostPostParams struct {
+	PostID     pgtype.UUID `json:"post_id"`
+	ReposterID pgtype.UUID `json:"reposter_id"`
+}
+
+func (q *Queries) UnrepostPost(ctx context.Context, arg UnrepostPostParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unrepostPost, arg.PostID, arg.ReposterID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const updatePostContent = `-- name: UpdatePostContent :one
 UPDATE posts 
 SET 
   content = $2,
-  updated_at = NOW()
+  updated_at = NOW(),
+  edited_at = NOW(),
+  edit_count = edit_count + 1
 WHERE id = $1 AND user_id = $3 AND deleted_at IS NULL
-RETURNING id, user_id, content, created_at, updated_at, deleted_at, is_private, reply_to_post_id, allow_replies, media_urls, like_count, repost_count
+RETURNING id, user_id, content, created_at, updated_at, deleted_at, is_private, reply_to_post_id, media_urls, like_count, repost_count, quoted_post_id, conversation_id, reply_policy, edited_at, edit_count
 `
 
 type UpdatePostContentParams struct {
@@ -880,7 +1907,8 @@ type UpdatePostContentParams struct {
 	UserID  pgtype.UUID `json:"user_id"`
 }
 
-// For content edits (limited in Twitter)
+// For content edits, limited to the edit window. The replaced content is
+// saved with CreatePostRevision first.
 func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentParams) (Post, error) {
 	row := q.db.QueryRow(ctx, updatePostContent, arg.ID, arg.Content, arg.UserID)
 	var i Post
@@ -893,10 +1921,14 @@ func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentPa
 		&i.DeletedAt,
 		&i.IsPrivate,
 		&i.ReplyToPostID,
-		&i.AllowReplies,
 		&i.MediaUrls,
 		&i.LikeCount,
 		&i.RepostCount,
+		&i.QuotedPostID,
+		&i.ConversationID,
+		&i.ReplyPolicy,
+		&i.EditedAt,
+		&i.EditCount,
 	)
 	return i, err
 }
@@ -907,7 +1939,7 @@ SET
   is_private = $2,
   updated_at = NOW()
 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
-RETURNING id, user_id, content, created_at, updated_at, deleted_at, is_private, reply_to_post_id, allow_replies, media_urls, like_count, repost_count
+RETURNING id, user_id, content, created_at, updated_at, deleted_at, is_private, reply_to_post_id, media_urls, like_count, repost_count, quoted_post_id, conversation_id, reply_policy, edited_at, edit_count
 `
 
 type UpdatePostPrivacyParams struct {
@@ -928,10 +1960,53 @@ func (q *Queries) UpdatePostPrivacy(ctx context.Context, arg UpdatePostPrivacyPa
 		&i.DeletedAt,
 		&i.IsPrivate,
 		&i.ReplyToPostID,
-		&i.AllowReplies,
 		&i.MediaUrls,
 		&i.LikeCount,
 		&i.RepostCount,
+		&i.QuotedPostID,
+		&i.ConversationID,
+		&i.ReplyPolicy,
+		&i.EditedAt,
+		&i.EditCount,
+	)
+	return i, err
+}
+
+const updatePostReplyPolicy = `-- name: UpdatePostReplyPolicy :one
+UPDATE posts
+SET 
+  reply_policy = $2,
+  updated_at = NOW()
+WHERE id = $1 AND user_id = $3 AND deleted_at IS NULL
+RETURNING id, user_id, content, created_at, updated_at, deleted_at, is_private, reply_to_post_id, media_urls, like_count, repost_count, quoted_post_id, conversation_id, reply_policy, edited_at, edit_count
+`
+
+type UpdatePostReplyPolicyParams struct {
+	ID          pgtype.UUID `json:"id"`
+	ReplyPolicy ReplyPolicy `json:"reply_policy"`
+	UserID      pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) UpdatePostReplyPolicy(ctx context.Context, arg UpdatePostReplyPolicyParams) (Post, error) {
+	row := q.db.QueryRow(ctx, updatePostReplyPolicy, arg.ID, arg.ReplyPolicy, arg.UserID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.IsPrivate,
+		&i.ReplyToPostID,
+		&i.MediaUrls,
+		&i.LikeCount,
+		&i.RepostCount,
+		&i.QuotedPostID,
+		&i.ConversationID,
+		&i.ReplyPolicy,
+		&i.EditedAt,
+		&i.EditCount,
 	)
 	return i, err
 }
@@ -0,0 +1,30 @@
+-- name: CreateBlock :execrows
+INSERT INTO blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- Accounts a user has blocked, most recently blocked first
+-- name: GetBlockedUsers :many
+SELECT
+    u.id,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    u.is_private,
+    b.created_at AS blocked_at
+FROM blocks b
+JOIN users u ON u.id = b.blocked_id
+WHERE b.blocker_id = @blocker_id
+AND u.deleted_at IS NULL
+AND (b.created_at, u.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (b.created_at, u.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN b.created_at END,
+    CASE WHEN @ascending::boolean THEN u.id END,
+    b.created_at DESC,
+    u.id DESC
+LIMIT @page_limit;
@@ -0,0 +1,31 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, post_id)
+VALUES ($1, $2);
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetUserBookmarkedPosts :many
+SELECT p.*, b.created_at AS bookmarked_at, u.username, u.display_name, u.avatar_url
+FROM posts p
+JOIN users u ON p.user_id = u.id
+JOIN bookmarks b ON b.post_id = p.id
+WHERE b.user_id = @user_id
+AND p.deleted_at IS NULL
+AND can_view_post(@user_id, p.user_id, p.is_private, u.is_private)
+AND (b.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (b.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN b.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    b.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
+
+-- name: IsPostBookmarked :one
+SELECT EXISTS (
+    SELECT 1
+    FROM bookmarks
+    WHERE user_id = $1 AND post_id = $2
+) AS is_bookmarked; 
\ No newline at end of file
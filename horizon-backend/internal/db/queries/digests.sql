@@ -0,0 +1,44 @@
+-- name: GetEmailDigestSettings :one
+SELECT * FROM email_digest_settings
+WHERE user_id = $1;
+
+-- Creates or changes a user's digest schedule. The unsubscribe token is only
+-- set when the row is created.
+-- name: UpsertEmailDigestSettings :one
+INSERT INTO email_digest_settings (user_id, frequency, hour, weekday, timezone, next_send_at, unsubscribe_token)
+VALUES (@user_id, @frequency, @hour, @weekday, @timezone, @next_send_at, @unsubscribe_token)
+ON CONFLICT (user_id) DO UPDATE SET
+    frequency = EXCLUDED.frequency,
+    hour = EXCLUDED.hour,
+    weekday = EXCLUDED.weekday,
+    timezone = EXCLUDED.timezone,
+    next_send_at = EXCLUDED.next_send_at,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: UnsubscribeEmailDigest :execrows
+UPDATE email_digest_settings
+SET frequency = 'off', next_send_at = NULL, updated_at = NOW()
+WHERE unsubscribe_token = @unsubscribe_token;
+
+-- Digests that are due, locked so only one server sends each. Must be run in
+-- a transaction that moves next_send_at on.
+-- name: GetDueEmailDigests :many
+SELECT s.*, u.email, u.username, u.display_name
+FROM email_digest_settings s
+JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL
+WHERE s.frequency <> 'off' AND s.next_send_at <= @due_at
+ORDER BY s.next_send_at
+LIMIT @batch_limit
+FOR UPDATE OF s SKIP LOCKED;
+
+-- name: GetEmailDigestRecipient :one
+SELECT s.*, u.email, u.username, u.display_name
+FROM email_digest_settings s
+JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL
+WHERE s.user_id = @user_id;
+
+-- name: MarkEmailDigestSent :exec
+UPDATE email_digest_settings
+SET last_sent_at = @sent_at, next_send_at = @next_send_at
+WHERE user_id = @user_id;
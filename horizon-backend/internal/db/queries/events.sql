@@ -0,0 +1,3 @@
+-- Publishes a realtime event to every server listening on the channel
+-- name: NotifyEvent :exec
+SELECT pg_notify(@channel::text, @payload::text);
@@ -0,0 +1,51 @@
+-- name: CreateFilter :one
+INSERT INTO filters (user_id, phrase, is_regex, whole_word, scopes, expires_at)
+VALUES (@user_id, @phrase, @is_regex, @whole_word, @scopes::text[], @expires_at)
+RETURNING *;
+
+-- name: CountFilters :one
+SELECT COUNT(*) FROM filters
+WHERE user_id = $1;
+
+-- name: GetFilters :many
+SELECT * FROM filters
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetFilter :one
+SELECT * FROM filters
+WHERE id = @id AND user_id = @user_id;
+
+-- name: UpdateFilter :one
+UPDATE filters
+SET phrase = @phrase,
+    is_regex = @is_regex,
+    whole_word = @whole_word,
+    scopes = @scopes::text[],
+    expires_at = @expires_at,
+    updated_at = NOW()
+WHERE id = @id AND user_id = @user_id
+RETURNING *;
+
+-- name: DeleteFilter :execrows
+DELETE FROM filters
+WHERE id = @id AND user_id = @user_id;
+
+-- The filters that apply in a scope right now
+-- name: GetActiveFilters :many
+SELECT * FROM filters
+WHERE user_id = @user_id
+AND @scope::text = ANY(scopes)
+AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: GetFilterSettings :one
+SELECT * FROM filter_settings
+WHERE user_id = $1;
+
+-- name: UpsertFilterSettings :one
+INSERT INTO filter_settings (user_id, action)
+VALUES (@user_id, @action)
+ON CONFLICT (user_id) DO UPDATE SET
+    action = EXCLUDED.action,
+    updated_at = NOW()
+RETURNING *;
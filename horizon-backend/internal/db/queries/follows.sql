@@ -1,26 +1,47 @@
+-- Follows an account, or asks to when it's private. No rows when there's a
+-- block between the two.
 -- name: CreateFollow :one
 INSERT INTO follows (follower_id, followed_id, is_accepted)
-VALUES ($1, $2, CASE WHEN (
-    SELECT is_private FROM users WHERE id = $2
-) THEN false ELSE true END)
+SELECT @follower_id::uuid, @followed_id::uuid, CASE WHEN (
+    SELECT is_private FROM users WHERE id = @followed_id::uuid
+) THEN false ELSE true END
+WHERE NOT is_blocked_between(@follower_id::uuid, @followed_id::uuid)
 RETURNING *;
 
 -- name: AcceptFollow :one
 UPDATE follows
 SET is_accepted = true
-WHERE follower_id = $1 AND followed_id = $2
+WHERE follower_id = $1 AND followed_id = $2 AND is_accepted = false
 RETURNING *;
 
 -- name: DeleteFollow :exec
 DELETE FROM follows
 WHERE follower_id = $1 AND followed_id = $2;
 
+-- Removes the follows and follow requests between two users, in both
+-- directions
+-- name: DeleteFollowsBetween :many
+DELETE FROM follows
+WHERE (follower_id = @user_id AND followed_id = @other_user_id)
+    OR (follower_id = @other_user_id AND followed_id = @user_id)
+RETURNING *;
+
+-- Removes a follow that is still waiting to be accepted
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followed_id = $2 AND is_accepted = false;
+
+-- Accounts whose posts appear in a user's home timeline: accepted follows
+-- that aren't muted
+-- name: GetFollowedUserIDs :many
+SELECT followed_id FROM follows
+WHERE follower_id = $1 AND is_accepted = true
+AND NOT is_account_muted(follower_id, followed_id);
+
+-- Whether a follow was accepted; no rows when there's no follow or request
 -- name: GetFollowStatus :one
-SELECT EXISTS (
-    SELECT 1 FROM follows
-    WHERE follower_id = $1 AND followed_id = $2
-    AND is_accepted = true
-) as is_following;
+SELECT is_accepted FROM follows
+WHERE follower_id = $1 AND followed_id = $2;
 
 -- name: GetFollowers :many
 SELECT 
@@ -34,10 +55,16 @@ SELECT
     f.created_at as followed_at
 FROM users u
 JOIN follows f ON u.id = f.follower_id
-WHERE f.followed_id = $1
+WHERE f.followed_id = @followed_id
 AND f.is_accepted = true
-ORDER BY f.created_at DESC
-LIMIT $2 OFFSET $3;
+AND (f.created_at, u.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (f.created_at, u.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN f.created_at END,
+    CASE WHEN @ascending::boolean THEN u.id END,
+    f.created_at DESC,
+    u.id DESC
+LIMIT @page_limit;
 
 -- name: GetFollowing :many
 SELECT 
@@ -51,10 +78,16 @@ SELECT
     f.created_at as followed_at
 FROM users u
 JOIN follows f ON u.id = f.followed_id
-WHERE f.follower_id = $1
+WHERE f.follower_id = @follower_id
 AND f.is_accepted = true
-ORDER BY f.created_at DESC
-LIMIT $2 OFFSET $3;
+AND (f.created_at, u.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (f.created_at, u.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN f.created_at END,
+    CASE WHEN @ascending::boolean THEN u.id END,
+    f.created_at DESC,
+    u.id DESC
+LIMIT @page_limit;
 
 -- name: GetFollowersCount :one
 SELECT COUNT(*)
@@ -79,6 +112,12 @@ SELECT
     f.created_at
 FROM follows f
 JOIN users u ON f.follower_id = u.id
-WHERE f.followed_id = $1 AND f.is_accepted = false AND u.deleted_at IS NULL
-ORDER BY f.created_at DESC
-LIMIT $2 OFFSET $3; 
\ No newline at end of file
+WHERE f.followed_id = @followed_id AND f.is_accepted = false AND u.deleted_at IS NULL
+AND (f.created_at, u.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (f.created_at, u.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN f.created_at END,
+    CASE WHEN @ascending::boolean THEN u.id END,
+    f.created_at DESC,
+    u.id DESC
+LIMIT @page_limit;
\ No newline at end of file
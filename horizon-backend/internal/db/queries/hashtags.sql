@@ -0,0 +1,38 @@
+-- Tags a post already has keep their created_at, so edits don't count as new
+-- usage in trends
+-- name: AddPostHashtags :exec
+INSERT INTO post_hashtags (post_id, hashtag)
+SELECT @post_id::uuid, unnest(@hashtags::text[])
+ON CONFLICT (post_id, hashtag) DO NOTHING;
+
+-- name: DeleteStalePostHashtags :exec
+DELETE FROM post_hashtags
+WHERE post_id = @post_id
+AND NOT (hashtag = ANY(@hashtags::text[]));
+
+-- Tags ranked by how much their usage grew in the latest window compared to
+-- the window before it. Trends are global, so only posts visible to everyone
+-- count.
+-- name: GetTrendingHashtags :many
+WITH usage AS (
+    SELECT
+        ph.hashtag,
+        COUNT(*) FILTER (WHERE ph.created_at >= NOW() - @window_size::interval) AS current_uses,
+        COUNT(*) FILTER (WHERE ph.created_at < NOW() - @window_size::interval) AS previous_uses
+    FROM post_hashtags ph
+    JOIN posts p ON p.id = ph.post_id
+    JOIN users u ON p.user_id = u.id
+    WHERE ph.created_at >= NOW() - 2 * @window_size::interval
+    AND p.deleted_at IS NULL
+    AND can_view_post(NULL, p.user_id, p.is_private, u.is_private)
+    GROUP BY ph.hashtag
+)
+SELECT
+    hashtag,
+    current_uses,
+    previous_uses,
+    ((current_uses - previous_uses)::float8 / GREATEST(previous_uses, @min_uses::bigint))::float8 AS growth
+FROM usage
+WHERE current_uses >= @min_uses::bigint
+ORDER BY growth DESC, current_uses DESC, hashtag
+LIMIT @result_limit;
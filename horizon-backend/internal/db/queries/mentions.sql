@@ -0,0 +1,48 @@
+-- Records the users mentioned in a post, leaving out users on either side of
+-- a block with the author. Returns the newly mentioned users who can see the
+-- post, who are the ones to notify.
+-- name: AddPostMentions :many
+WITH inserted AS (
+    INSERT INTO mentions (post_id, mentioned_user_id)
+    SELECT @post_id::uuid, u.id
+    FROM users u
+    WHERE lower(u.username) = ANY(@usernames::text[])
+    AND u.deleted_at IS NULL
+    AND NOT is_blocked_between(@author_id::uuid, u.id)
+    ON CONFLICT (post_id, mentioned_user_id) DO NOTHING
+    RETURNING mentioned_user_id
+)
+SELECT i.mentioned_user_id
+FROM inserted i
+JOIN posts p ON p.id = @post_id::uuid
+JOIN users u ON p.user_id = u.id
+WHERE can_view_post(i.mentioned_user_id, p.user_id, p.is_private, u.is_private);
+
+-- name: DeleteStalePostMentions :exec
+DELETE FROM mentions m
+USING users u
+WHERE m.post_id = @post_id
+AND u.id = m.mentioned_user_id
+AND NOT (lower(u.username) = ANY(@usernames::text[]));
+
+-- Posts mentioning a user, newest first
+-- name: GetUserMentions :many
+SELECT 
+    p.*,
+    u.username,
+    u.display_name,
+    u.avatar_url
+FROM mentions m
+JOIN posts p ON p.id = m.post_id
+JOIN users u ON p.user_id = u.id
+WHERE m.mentioned_user_id = @user_id
+AND p.deleted_at IS NULL
+AND can_view_post(@user_id, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (p.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN p.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
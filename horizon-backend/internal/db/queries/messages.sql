@@ -0,0 +1,231 @@
+-- Whether the sender may message a user under the direct message policy
+-- name: CanMessageUser :one
+SELECT can_message_account(@sender_id, u.id, u.is_private)::boolean AS can_message
+FROM users u
+WHERE u.id = @recipient_id AND u.deleted_at IS NULL;
+
+-- Users with the given lowercased usernames and whether the user may
+-- message them
+-- name: GetMessageableUsers :many
+SELECT u.id, u.username, can_message_account(@user_id, u.id, u.is_private)::boolean AS can_message
+FROM users u
+WHERE lower(u.username) = ANY(@usernames::text[])
+AND u.deleted_at IS NULL;
+
+-- The one-to-one conversation between two users, created on first use
+-- name: GetOrCreateDirectConversation :one
+INSERT INTO conversations (direct_user1_id, direct_user2_id)
+VALUES (LEAST(@user_id::uuid, @other_user_id::uuid), GREATEST(@user_id::uuid, @other_user_id::uuid))
+ON CONFLICT (direct_user1_id, direct_user2_id) DO UPDATE
+SET direct_user1_id = EXCLUDED.direct_user1_id
+RETURNING *;
+
+-- name: GetDirectConversation :one
+SELECT c.*
+FROM conversations c
+WHERE c.direct_user1_id = LEAST(@user_id::uuid, @other_user_id::uuid)
+AND c.direct_user2_id = GREATEST(@user_id::uuid, @other_user_id::uuid);
+
+-- name: CreateGroupConversation :one
+INSERT INTO conversations (is_group, title)
+VALUES (true, @title)
+RETURNING *;
+
+-- name: UpdateConversationTitle :one
+UPDATE conversations
+SET title = @title,
+    updated_at = NOW()
+WHERE id = @id AND is_group
+RETURNING *;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET last_activity_at = NOW()
+WHERE id = $1;
+
+-- Removes a conversation once its last member has left
+-- name: DeleteEmptyConversation :exec
+DELETE FROM conversations c
+WHERE c.id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM conversation_members cm
+    WHERE cm.conversation_id = c.id
+);
+
+-- Adds members to a conversation and returns the ones who weren't members
+-- already
+-- name: AddConversationMembers :many
+INSERT INTO conversation_members (conversation_id, user_id, role)
+SELECT @conversation_id::uuid, member_id, @role::conversation_role
+FROM unnest(@user_ids::uuid[]) AS member_id
+ON CONFLICT (conversation_id, user_id) DO NOTHING
+RETURNING user_id;
+
+-- name: RemoveConversationMember :execrows
+DELETE FROM conversation_members
+WHERE conversation_id = @conversation_id AND user_id = @user_id;
+
+-- Hands ownership of a group to its longest-standing member when it has no
+-- owner left
+-- name: TransferConversationOwnership :exec
+UPDATE conversation_members
+SET role = 'owner'
+WHERE conversation_id = @conversation_id::uuid
+AND user_id = (
+    SELECT cm.user_id
+    FROM conversation_members cm
+    WHERE cm.conversation_id = @conversation_id::uuid
+    ORDER BY cm.joined_at, cm.user_id
+    LIMIT 1
+)
+AND NOT EXISTS (
+    SELECT 1 FROM conversation_members cm
+    WHERE cm.conversation_id = @conversation_id::uuid
+    AND cm.role = 'owner'
+);
+
+-- name: CountConversationMembers :one
+SELECT COUNT(*)
+FROM conversation_members
+WHERE conversation_id = $1;
+
+-- Members of a page of conversations, in the order they joined
+-- name: GetConversationMembers :many
+SELECT
+    cm.conversation_id,
+    cm.user_id,
+    cm.role,
+    cm.joined_at,
+    cm.last_read_message_id,
+    cm.last_read_at,
+    u.username,
+    u.display_name,
+    u.avatar_url
+FROM conversation_members cm
+JOIN users u ON cm.user_id = u.id
+WHERE cm.conversation_id = ANY(@conversation_ids::uuid[])
+AND u.deleted_at IS NULL
+ORDER BY cm.conversation_id, cm.joined_at, cm.user_id;
+
+-- A user's conversations, most recently active first, with the latest
+-- message of each and how many messages the user hasn't read
+-- name: GetConversations :many
+SELECT
+    c.*,
+    cm.role,
+    lm.id AS last_message_id,
+    lm.sender_id AS last_message_sender_id,
+    lm.content AS last_message_content,
+    lm.created_at AS last_message_created_at,
+    (
+        SELECT COUNT(*)
+        FROM messages um
+        WHERE um.conversation_id = c.id
+        AND um.sender_id <> cm.user_id
+        AND um.created_at > cm.last_read_at
+    ) AS unread_count
+FROM conversation_members cm
+JOIN conversations c ON cm.conversation_id = c.id
+LEFT JOIN LATERAL (
+    SELECT m.id, m.sender_id, m.content, m.created_at
+    FROM messages m
+    WHERE m.conversation_id = c.id
+    ORDER BY m.created_at DESC, m.id DESC
+    LIMIT 1
+) lm ON true
+WHERE cm.user_id = @user_id
+AND (c.last_activity_at, c.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (c.last_activity_at, c.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN c.last_activity_at END,
+    CASE WHEN @ascending::boolean THEN c.id END,
+    c.last_activity_at DESC,
+    c.id DESC
+LIMIT @page_limit;
+
+-- A conversation as seen by one of its members; not found for anyone else
+-- name: GetConversationForMember :one
+SELECT
+    c.*,
+    cm.role,
+    lm.id AS last_message_id,
+    lm.sender_id AS last_message_sender_id,
+    lm.content AS last_message_content,
+    lm.created_at AS last_message_created_at,
+    (
+        SELECT COUNT(*)
+        FROM messages um
+        WHERE um.conversation_id = c.id
+        AND um.sender_id <> cm.user_id
+        AND um.created_at > cm.last_read_at
+    ) AS unread_count
+FROM conversation_members cm
+JOIN conversations c ON cm.conversation_id = c.id
+LEFT JOIN LATERAL (
+    SELECT m.id, m.sender_id, m.content, m.created_at
+    FROM messages m
+    WHERE m.conversation_id = c.id
+    ORDER BY m.created_at DESC, m.id DESC
+    LIMIT 1
+) lm ON true
+WHERE cm.conversation_id = @conversation_id AND cm.user_id = @user_id;
+
+-- name: CreateMessage :one
+INSERT INTO messages (conversation_id, sender_id, content)
+VALUES (@conversation_id, @sender_id, @content)
+RETURNING *;
+
+-- name: GetConversationMessage :one
+SELECT * FROM messages
+WHERE id = @id AND conversation_id = @conversation_id;
+
+-- Messages in a conversation, newest first
+-- name: GetConversationMessages :many
+SELECT m.*
+FROM messages m
+WHERE m.conversation_id = @conversation_id
+AND (m.created_at, m.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (m.created_at, m.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN m.created_at END,
+    CASE WHEN @ascending::boolean THEN m.id END,
+    m.created_at DESC,
+    m.id DESC
+LIMIT @page_limit;
+
+-- Moves a member's read cursor forward to a message. The cursor never moves
+-- backwards.
+-- name: UpdateReadCursor :execrows
+UPDATE conversation_members cm
+SET last_read_message_id = m.id,
+    last_read_at = m.created_at
+FROM messages m
+WHERE cm.conversation_id = @conversation_id
+AND cm.user_id = @user_id
+AND m.id = @message_id
+AND m.conversation_id = cm.conversation_id
+AND m.created_at > cm.last_read_at;
+
+-- Moves a member's read cursor to the latest message of the conversation
+-- name: MarkConversationAsRead :execrows
+UPDATE conversation_members cm
+SET last_read_message_id = lm.id,
+    last_read_at = lm.created_at
+FROM (
+    SELECT m.id, m.created_at
+    FROM messages m
+    WHERE m.conversation_id = @conversation_id
+    ORDER BY m.created_at DESC, m.id DESC
+    LIMIT 1
+) lm
+WHERE cm.conversation_id = @conversation_id
+AND cm.user_id = @user_id
+AND lm.created_at > cm.last_read_at;
+
+-- name: GetUnreadMessageCount :one
+SELECT COUNT(*)
+FROM conversation_members cm
+JOIN messages m ON m.conversation_id = cm.conversation_id
+WHERE cm.user_id = $1
+AND m.sender_id <> cm.user_id
+AND m.created_at > cm.last_read_at;
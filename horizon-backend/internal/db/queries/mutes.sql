@@ -0,0 +1,52 @@
+-- Mutes an account, or changes when an existing mute expires
+-- name: MuteUser :one
+INSERT INTO user_mutes (muter_id, muted_id, expires_at)
+VALUES (@muter_id, @muted_id, @expires_at)
+ON CONFLICT (muter_id, muted_id) DO UPDATE
+SET expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: UnmuteUser :execrows
+DELETE FROM user_mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- Accounts a user has muted, most recently muted first. Expired mutes are
+-- left out.
+-- name: GetMutedUsers :many
+SELECT
+    u.id,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    u.is_private,
+    m.expires_at,
+    m.created_at AS muted_at
+FROM user_mutes m
+JOIN users u ON u.id = m.muted_id
+WHERE m.muter_id = @muter_id
+AND (m.expires_at IS NULL OR m.expires_at > NOW())
+AND u.deleted_at IS NULL
+AND (m.created_at, u.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (m.created_at, u.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN m.created_at END,
+    CASE WHEN @ascending::boolean THEN u.id END,
+    m.created_at DESC,
+    u.id DESC
+LIMIT @page_limit;
+
+-- Whether a user has an active mute on any of the accounts
+-- name: IsAnyAccountMuted :one
+SELECT EXISTS (
+    SELECT 1 FROM unnest(@account_ids::uuid[]) AS a(id)
+    WHERE is_account_muted(@user_id, a.id)
+);
+
+-- name: MuteConversation :exec
+INSERT INTO conversation_mutes (user_id, conversation_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteConversation :execrows
+DELETE FROM conversation_mutes
+WHERE user_id = $1 AND conversation_id = $2;
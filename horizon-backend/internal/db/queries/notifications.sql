@@ -4,18 +4,117 @@ INSERT INTO notifications (
     actor_id,
     post_id,
     parent_post_id,
-    type
+    type,
+    group_id,
+    read
 )
 VALUES (
     @user_id,
     @actor_id,
     @post_id,
     @parent_post_id,
-    @notification_type::notification_type
+    @notification_type::notification_type,
+    @group_id,
+    @read
 )
 RETURNING *;
 
--- name: GetNotifications :many
+-- The user's most recent group with the key that opened after a time, locked
+-- so a new notification can join it
+-- name: GetOpenNotificationGroup :one
+SELECT * FROM notification_groups
+WHERE user_id = @user_id
+    AND group_key = @group_key
+    AND created_at > @opened_after
+ORDER BY created_at DESC
+LIMIT 1
+FOR UPDATE;
+
+-- name: CreateNotificationGroup :one
+INSERT INTO notification_groups (
+    user_id,
+    type,
+    post_id,
+    group_key,
+    read
+)
+VALUES (
+    @user_id,
+    @notification_type::notification_type,
+    @post_id,
+    @group_key,
+    @read
+)
+RETURNING *;
+
+-- A notification joined the group: it moves to the top, and unless the
+-- notification arrived read the group is unread again
+-- name: TouchNotificationGroup :exec
+UPDATE notification_groups
+SET read = read AND @read,
+    updated_at = NOW()
+WHERE id = @id;
+
+-- Notification groups of a user, most recently active first, with their
+-- latest notification and how many different actors they have
+-- name: GetNotificationGroups :many
+SELECT
+    g.*,
+    latest.id AS latest_notification_id,
+    latest.parent_post_id,
+    (
+        SELECT COUNT(DISTINCT n.actor_id)
+        FROM notifications n
+        WHERE n.group_id = g.id AND n.deleted_at IS NULL
+        AND NOT is_blocked_between(n.user_id, n.actor_id)
+    ) AS actor_count,
+    p.content AS post_content,
+    pp.content AS parent_post_content
+FROM notification_groups g
+JOIN LATERAL (
+    SELECT n.id, n.parent_post_id
+    FROM notifications n
+    WHERE n.group_id = g.id AND n.deleted_at IS NULL
+    AND NOT is_blocked_between(n.user_id, n.actor_id)
+    ORDER BY n.created_at DESC, n.id DESC
+    LIMIT 1
+) latest ON true
+LEFT JOIN posts p ON g.post_id = p.id AND p.deleted_at IS NULL
+LEFT JOIN posts pp ON latest.parent_post_id = pp.id AND pp.deleted_at IS NULL
+WHERE g.user_id = @user_id
+    AND (g.updated_at, g.id) < (@before_created_at::timestamptz, @before_id::uuid)
+    AND (g.updated_at, g.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN g.updated_at END,
+    CASE WHEN @ascending::boolean THEN g.id END,
+    g.updated_at DESC,
+    g.id DESC
+LIMIT @page_limit;
+
+-- The most recent distinct actors of each group, up to preview_limit per group
+-- name: GetNotificationGroupActors :many
+SELECT
+    a.group_id,
+    u.id,
+    u.username,
+    u.display_name,
+    u.avatar_url
+FROM (
+    SELECT
+        n.group_id,
+        n.actor_id,
+        ROW_NUMBER() OVER (PARTITION BY n.group_id ORDER BY MAX(n.created_at) DESC, n.actor_id) AS position
+    FROM notifications n
+    WHERE n.group_id = ANY(@group_ids::uuid[]) AND n.deleted_at IS NULL
+    AND NOT is_blocked_between(n.user_id, n.actor_id)
+    GROUP BY n.group_id, n.actor_id
+) a
+JOIN users u ON u.id = a.actor_id AND u.deleted_at IS NULL
+WHERE a.position <= @preview_limit::int
+ORDER BY a.group_id, a.position;
+
+-- A single notification of a user, with the same fields as GetNotificationsSince
+-- name: GetNotificationForUser :one
 SELECT 
     n.*,
     COALESCE(actor.username, '') as actor_username,
@@ -27,35 +126,139 @@ FROM notifications n
 LEFT JOIN users actor ON n.actor_id = actor.id AND actor.deleted_at IS NULL
 LEFT JOIN posts p ON n.post_id = p.id AND p.deleted_at IS NULL
 LEFT JOIN posts pp ON n.parent_post_id = pp.id AND pp.deleted_at IS NULL
-WHERE n.user_id = $1 
+WHERE n.id = @id
+    AND n.user_id = @user_id
     AND n.deleted_at IS NULL
-ORDER BY n.created_at DESC
-LIMIT $2
-OFFSET $3;
+    AND NOT is_blocked_between(n.user_id, n.actor_id);
 
+-- Notifications created after a position, oldest first. Used to replay what
+-- a reconnecting stream missed.
+-- name: GetNotificationsSince :many
+SELECT 
+    n.*,
+    COALESCE(actor.username, '') as actor_username,
+    actor.display_name as actor_display_name,
+    actor.avatar_url as actor_avatar_url,
+    p.content as post_content,
+    pp.content as parent_post_content
+FROM notifications n
+LEFT JOIN users actor ON n.actor_id = actor.id AND actor.deleted_at IS NULL
+LEFT JOIN posts p ON n.post_id = p.id AND p.deleted_at IS NULL
+LEFT JOIN posts pp ON n.parent_post_id = pp.id AND pp.deleted_at IS NULL
+WHERE n.user_id = @user_id 
+    AND n.deleted_at IS NULL
+    AND NOT is_blocked_between(n.user_id, n.actor_id)
+    AND (n.created_at, n.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY n.created_at, n.id
+LIMIT @page_limit;
+
+-- Unread notification groups of a user
 -- name: GetUnreadNotificationCount :one
 SELECT COUNT(*)
-FROM notifications
-WHERE user_id = $1 
-    AND read = false 
-    AND deleted_at IS NULL;
+FROM notification_groups g
+WHERE g.user_id = $1
+    AND g.read = false
+    AND EXISTS (
+        SELECT 1 FROM notifications n
+        WHERE n.group_id = g.id AND n.deleted_at IS NULL
+        AND NOT is_blocked_between(n.user_id, n.actor_id)
+    );
 
--- name: MarkNotificationAsRead :one
-UPDATE notifications
-SET read = true,
-    updated_at = NOW()
-WHERE id = $1
-RETURNING *;
+-- Marks a user's notification group and its notifications as read
+-- name: MarkNotificationGroupAsRead :one
+WITH marked AS (
+    UPDATE notification_groups
+    SET read = true
+    WHERE id = @id AND user_id = @user_id
+    RETURNING id
+), marked_notifications AS (
+    UPDATE notifications
+    SET read = true,
+        updated_at = NOW()
+    WHERE group_id IN (SELECT id FROM marked)
+        AND read = false
+)
+SELECT id FROM marked;
 
 -- name: MarkAllNotificationsAsRead :exec
+WITH marked AS (
+    UPDATE notification_groups
+    SET read = true
+    WHERE user_id = @user_id
+        AND read = false
+)
 UPDATE notifications
 SET read = true,
     updated_at = NOW()
-WHERE user_id = $1
+WHERE user_id = @user_id
     AND read = false
     AND deleted_at IS NULL;
 
 -- name: DeleteNotification :exec
 UPDATE notifications
 SET deleted_at = NOW()
-WHERE id = $1; 
\ No newline at end of file
+WHERE id = $1;
+
+-- Withdraws the follow request notifications an actor sent a user, once the
+-- request is gone
+-- name: DeleteFollowRequestNotifications :execrows
+UPDATE notifications
+SET deleted_at = NOW(),
+    updated_at = NOW()
+WHERE user_id = @user_id
+    AND actor_id = @actor_id
+    AND type = 'follow_request'
+    AND deleted_at IS NULL;
+
+-- name: GetNotificationSettings :many
+SELECT * FROM notification_settings
+WHERE user_id = $1;
+
+-- Saves a user's settings for several types at once; the arrays are matched
+-- up by position
+-- name: UpsertNotificationSettings :exec
+INSERT INTO notification_settings (user_id, type, enabled, only_from_following, muted)
+SELECT @user_id, s.type::notification_type, s.enabled, s.only_from_following, s.muted
+FROM unnest(
+    @types::text[],
+    @enabled::boolean[],
+    @only_from_following::boolean[],
+    @muted::boolean[]
+) AS s(type, enabled, only_from_following, muted)
+ON CONFLICT (user_id, type) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    only_from_following = EXCLUDED.only_from_following,
+    muted = EXCLUDED.muted,
+    updated_at = NOW();
+
+-- How a user's settings treat a notification of a type from an actor about
+-- a post: whether it's created at all, and whether it's muted. Nothing is
+-- created across a block, and replies in threads the user muted don't
+-- notify them.
+-- name: GetNotificationPolicy :one
+SELECT
+    (
+        NOT is_blocked_between(@user_id, @actor_id)
+        AND NOT (
+            @notification_type::notification_type IN ('reply', 'thread_reply')
+            AND EXISTS (
+                SELECT 1 FROM posts p
+                JOIN conversation_mutes cm ON cm.conversation_id = COALESCE(p.conversation_id, p.id)
+                WHERE p.id = @post_id
+                AND cm.user_id = @user_id
+            )
+        )
+        AND COALESCE(s.enabled, true)
+        AND (
+            NOT COALESCE(s.only_from_following, false)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = @user_id
+                AND f.followed_id = @actor_id
+                AND f.is_accepted = true
+            )
+        )
+    )::boolean AS allowed,
+    COALESCE(s.muted, false)::boolean AS muted
+FROM (SELECT 1) AS one
+LEFT JOIN notification_settings s ON s.user_id = @user_id AND s.type = @notification_type::notification_type;
@@ -1,14 +1,27 @@
+-- The id is generated up front so a root post can point conversation_id at
+-- itself; replies inherit the conversation of their parent.
 -- name: CreatePost :one
-WITH new_post AS (
+WITH new_id AS (
+    SELECT gen_random_uuid() AS id
+), new_post AS (
     INSERT INTO posts (
+        id,
         user_id,
         content,
         is_private,
         reply_to_post_id,
-        media_urls
-    ) VALUES (
-        $1, $2, $3, $4, $5
+        media_urls,
+        quoted_post_id,
+        reply_policy,
+        conversation_id
     )
+    SELECT
+        new_id.id, $1, $2, $3, $4, $5, $6, $7,
+        COALESCE(
+            (SELECT COALESCE(parent.conversation_id, parent.id) FROM posts parent WHERE parent.id = $4),
+            new_id.id
+        )
+    FROM new_id
     RETURNING *
 )
 SELECT 
@@ -28,8 +41,15 @@ SELECT
 FROM posts p
 JOIN users u ON p.user_id = u.id
 WHERE p.deleted_at IS NULL 
-ORDER BY p.created_at DESC
-LIMIT $1 OFFSET $2;
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (p.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN p.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
 
 -- name: GetPostByID :one
 SELECT 
@@ -39,22 +59,96 @@ SELECT
     u.avatar_url
 FROM posts p
 JOIN users u ON p.user_id = u.id
-WHERE p.id = $1 AND p.deleted_at IS NULL;
+WHERE p.id = @id AND p.deleted_at IS NULL
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private);
 
+-- Profile timeline: the user's posts plus posts they reposted, ordered by
+-- when each entry appeared on the profile
 -- name: GetPostsByUserID :many
 SELECT 
     p.*,
     u.username,
     u.display_name,
-    u.avatar_url
-FROM posts p
+    u.avatar_url,
+    t.activity_at,
+    t.reposted_by_id,
+    ru.username AS reposted_by_username,
+    ru.display_name AS reposted_by_display_name
+FROM (
+    SELECT p.id AS post_id, p.created_at AS activity_at, NULL::uuid AS reposted_by_id
+    FROM posts p
+    WHERE p.user_id = @user_id
+    UNION ALL
+    SELECT r.post_id, r.created_at, r.reposter_id
+    FROM reposts r
+    JOIN users reposter ON reposter.id = r.reposter_id
+    WHERE r.reposter_id = @user_id
+    AND can_view_account(@viewer_id, reposter.id, reposter.is_private)
+) t
+JOIN posts p ON p.id = t.post_id
 JOIN users u ON p.user_id = u.id
-WHERE p.user_id = $1 
-AND p.deleted_at IS NULL 
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3;
+LEFT JOIN users ru ON ru.id = t.reposted_by_id
+WHERE p.deleted_at IS NULL 
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+AND (t.activity_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (t.activity_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN t.activity_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    t.activity_at DESC,
+    p.id DESC
+LIMIT @page_limit;
 
+-- Home timeline: posts and reposts by the user and by accounts they follow
 -- name: GetUserFeed :many
+SELECT 
+    p.*,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    t.activity_at,
+    t.reposted_by_id,
+    ru.username AS reposted_by_username,
+    ru.display_name AS reposted_by_display_name
+FROM (
+    SELECT p.id AS post_id, p.created_at AS activity_at, NULL::uuid AS reposted_by_id
+    FROM posts p
+    WHERE p.user_id = @user_id
+    OR EXISTS (
+        SELECT 1 FROM follows f
+        WHERE f.follower_id = @user_id
+        AND f.followed_id = p.user_id
+        AND f.is_accepted = true
+    )
+    UNION ALL
+    SELECT r.post_id, r.created_at, r.reposter_id
+    FROM reposts r
+    WHERE r.reposter_id = @user_id
+    OR EXISTS (
+        SELECT 1 FROM follows f
+        WHERE f.follower_id = @user_id
+        AND f.followed_id = r.reposter_id
+        AND f.is_accepted = true
+    )
+) t
+JOIN posts p ON p.id = t.post_id
+JOIN users u ON p.user_id = u.id
+LEFT JOIN users ru ON ru.id = t.reposted_by_id
+WHERE p.deleted_at IS NULL
+AND u.deleted_at IS NULL
+AND can_view_post(@user_id, p.user_id, p.is_private, u.is_private)
+AND NOT is_account_muted(@user_id, p.user_id)
+AND (t.reposted_by_id IS NULL OR NOT is_account_muted(@user_id, t.reposted_by_id))
+AND (t.activity_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (t.activity_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN t.activity_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    t.activity_at DESC,
+    p.id DESC
+LIMIT @page_limit;
+
+-- name: GetPostReplies :many
 SELECT 
     p.*,
     u.username,
@@ -62,17 +156,97 @@ SELECT
     u.avatar_url
 FROM posts p
 JOIN users u ON p.user_id = u.id
-INNER JOIN follows f ON p.user_id = f.followed_id
-WHERE f.follower_id = $1 
+WHERE p.reply_to_post_id = @reply_to_post_id 
 AND p.deleted_at IS NULL
-AND (NOT p.is_private OR p.user_id IN (
-    SELECT followed_id FROM follows 
-    WHERE follower_id = $1 AND is_accepted = true
-))
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3;
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (p.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN p.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
 
--- name: GetPostReplies :many
+-- Ancestors of a post from the root down, including deleted ones and ones
+-- the viewer can't see so the chain stays intact
+-- name: GetThreadAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.reply_to_post_id, 1 AS depth
+    FROM posts child
+    JOIN posts parent ON parent.id = child.reply_to_post_id
+    WHERE child.id = @post_id
+    UNION ALL
+    SELECT parent.id, parent.reply_to_post_id, a.depth + 1
+    FROM ancestors a
+    JOIN posts parent ON parent.id = a.reply_to_post_id
+    WHERE a.depth < @max_depth::int
+)
+SELECT 
+    p.*,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)::boolean AS is_visible
+FROM ancestors a
+JOIN posts p ON p.id = a.id
+JOIN users u ON p.user_id = u.id
+ORDER BY a.depth DESC;
+
+-- Reply tree below a post. The direct replies are keyset paginated; deeper
+-- levels keep the newest sibling_limit replies of each post down to max_depth,
+-- plus a look-ahead row telling whether the post has more.
+-- name: GetThreadDescendants :many
+WITH RECURSIVE tree AS (
+    SELECT top.id, 1 AS depth
+    FROM (
+        SELECT c.id
+        FROM posts c
+        JOIN users cu ON cu.id = c.user_id
+        WHERE c.reply_to_post_id = @post_id
+        AND c.deleted_at IS NULL
+        AND can_view_post(@viewer_id, c.user_id, c.is_private, cu.is_private)
+        AND (c.created_at, c.id) < (@before_created_at::timestamptz, @before_id::uuid)
+        AND (c.created_at, c.id) > (@after_created_at::timestamptz, @after_id::uuid)
+        ORDER BY
+            CASE WHEN @ascending::boolean THEN c.created_at END,
+            CASE WHEN @ascending::boolean THEN c.id END,
+            c.created_at DESC,
+            c.id DESC
+        LIMIT @page_limit
+    ) top
+    UNION ALL
+    SELECT c.id, t.depth + 1
+    FROM tree t
+    CROSS JOIN LATERAL (
+        SELECT r.id
+        FROM posts r
+        JOIN users ru ON ru.id = r.user_id
+        WHERE r.reply_to_post_id = t.id
+        AND r.deleted_at IS NULL
+        AND can_view_post(@viewer_id, r.user_id, r.is_private, ru.is_private)
+        ORDER BY r.created_at DESC, r.id DESC
+        LIMIT @sibling_limit::int + 1
+    ) c
+    WHERE t.depth < @max_depth::int
+)
+SELECT 
+    p.*,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    t.depth
+FROM tree t
+JOIN posts p ON p.id = t.id
+JOIN users u ON p.user_id = u.id
+ORDER BY
+    t.depth,
+    CASE WHEN @ascending::boolean AND t.depth = 1 THEN p.created_at END,
+    CASE WHEN @ascending::boolean AND t.depth = 1 THEN p.id END,
+    p.created_at DESC,
+    p.id DESC;
+
+-- name: GetPostQuotes :many
 SELECT 
     p.*,
     u.username,
@@ -80,10 +254,35 @@ SELECT
     u.avatar_url
 FROM posts p
 JOIN users u ON p.user_id = u.id
-WHERE p.reply_to_post_id = $1 
+WHERE p.quoted_post_id = @quoted_post_id
 AND p.deleted_at IS NULL
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3;
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (p.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN p.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
+
+-- Quoted posts embedded in a page of posts. is_visible is false for deleted
+-- posts and for private posts the viewer can't see, which callers render as
+-- a tombstone.
+-- name: GetQuotedPosts :many
+SELECT 
+    p.*,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    (
+        p.deleted_at IS NULL
+        AND u.deleted_at IS NULL
+        AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+    )::boolean AS is_visible
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.id = ANY(@post_ids::uuid[]);
 
 -- name: DeletePost :exec
 UPDATE posts 
@@ -100,17 +299,25 @@ SELECT
 FROM posts p
 JOIN users u ON p.user_id = u.id
 INNER JOIN post_hashtags ph ON p.id = ph.post_id
-WHERE ph.hashtag = $1 
+WHERE ph.hashtag = @hashtag 
 AND p.deleted_at IS NULL
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3;
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+AND NOT is_account_muted(@viewer_id, p.user_id)
+AND (p.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (p.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN p.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
 
 -- name: LikePost :one
 INSERT INTO post_likes (user_id, post_id)
 VALUES ($1, $2)
 RETURNING *;
 
--- name: UnlikePost :exec
+-- name: UnlikePost :execrows
 DELETE FROM post_likes pl
 WHERE pl.post_id = $1 AND pl.user_id = $2;
 
@@ -125,27 +332,93 @@ FROM posts
 WHERE posts.id = $1
 RETURNING *;
 
+-- name: UnrepostPost :execrows
+DELETE FROM reposts
+WHERE post_id = $1 AND reposter_id = $2;
+
+-- name: GetPostReposters :many
+SELECT 
+    u.id,
+    u.username,
+    u.display_name,
+    u.avatar_url,
+    u.is_private,
+    r.created_at AS reposted_at
+FROM reposts r
+JOIN users u ON r.reposter_id = u.id
+WHERE r.post_id = @post_id
+AND u.deleted_at IS NULL
+AND NOT is_blocked_between(@viewer_id, u.id)
+AND (r.created_at, u.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (r.created_at, u.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN r.created_at END,
+    CASE WHEN @ascending::boolean THEN u.id END,
+    r.created_at DESC,
+    u.id DESC
+LIMIT @page_limit;
+
 -- name: GetPostRepostCount :one
 SELECT COUNT(*) FROM reposts
 WHERE post_id = $1;
 
--- name: GetPostStats :one
-SELECT 
-    (SELECT COUNT(*) FROM post_likes pl WHERE pl.post_id = p.id) as like_count,
-    (SELECT COUNT(*) FROM reposts r WHERE r.post_id = p.id) as repost_count,
-    (SELECT COUNT(*) FROM posts replies WHERE replies.reply_to_post_id = p.id AND replies.deleted_at IS NULL) as reply_count
+-- Engagement counts of a post, sent to live subscribers when they change
+-- name: GetPostCounts :one
+SELECT
+    p.id,
+    p.like_count,
+    p.repost_count,
+    (SELECT COUNT(*) FROM posts replies WHERE replies.reply_to_post_id = p.id AND replies.deleted_at IS NULL) AS reply_count
 FROM posts p
 WHERE p.id = $1 AND p.deleted_at IS NULL;
 
--- For content edits (limited in Twitter)
+-- Viewer state for a page of posts, fetched in one round trip
+-- name: GetPostViewerState :many
+SELECT
+    p.id AS post_id,
+    EXISTS (SELECT 1 FROM post_likes pl WHERE pl.post_id = p.id AND pl.user_id = @viewer_id) AS has_liked,
+    EXISTS (SELECT 1 FROM bookmarks b WHERE b.post_id = p.id AND b.user_id = @viewer_id) AS has_bookmarked,
+    EXISTS (SELECT 1 FROM reposts r WHERE r.post_id = p.id AND r.reposter_id = @viewer_id) AS has_reposted,
+    (SELECT COUNT(*) FROM posts replies WHERE replies.reply_to_post_id = p.id AND replies.deleted_at IS NULL) AS reply_count
+FROM posts p
+WHERE p.id = ANY(@post_ids::uuid[]);
+
+-- For content edits, limited to the edit window. The replaced content is
+-- saved with CreatePostRevision first.
 -- name: UpdatePostContent :one
 UPDATE posts 
 SET 
   content = $2,
-  updated_at = NOW()
+  updated_at = NOW(),
+  edited_at = NOW(),
+  edit_count = edit_count + 1
 WHERE id = $1 AND user_id = $3 AND deleted_at IS NULL
 RETURNING *;
 
+-- Saves the current content of a post as a revision. The row is locked so
+-- concurrent edits can't lose a version.
+-- name: CreatePostRevision :one
+INSERT INTO post_revisions (post_id, content, created_at)
+SELECT p.id, p.content, COALESCE(p.edited_at, p.created_at)
+FROM posts p
+WHERE p.id = $1 AND p.deleted_at IS NULL
+FOR UPDATE
+RETURNING *;
+
+-- Earlier versions of a post, newest first
+-- name: GetPostRevisions :many
+SELECT *
+FROM post_revisions r
+WHERE r.post_id = @post_id
+AND (r.created_at, r.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (r.created_at, r.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN r.created_at END,
+    CASE WHEN @ascending::boolean THEN r.id END,
+    r.created_at DESC,
+    r.id DESC
+LIMIT @page_limit;
+
 -- For toggling privacy
 -- name: UpdatePostPrivacy :one
 UPDATE posts
@@ -155,6 +428,43 @@ SET
 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 RETURNING *;
 
+-- name: UpdatePostReplyPolicy :one
+UPDATE posts
+SET 
+  reply_policy = $2,
+  updated_at = NOW()
+WHERE id = $1 AND user_id = $3 AND deleted_at IS NULL
+RETURNING *;
+
+-- Whether a user may reply to a post under its reply policy. Authors can
+-- always reply to their own posts; posts the user can't see aren't found.
+-- name: CanUserReplyToPost :one
+SELECT (
+    p.user_id = @user_id
+    OR p.reply_policy = 'everyone'
+    OR (
+        p.reply_policy = 'following'
+        AND EXISTS (
+            SELECT 1 FROM follows f
+            WHERE f.follower_id = p.user_id
+            AND f.followed_id = @user_id
+            AND f.is_accepted = true
+        )
+    )
+    OR (
+        p.reply_policy = 'mentioned'
+        AND EXISTS (
+            SELECT 1 FROM mentions m
+            WHERE m.post_id = p.id
+            AND m.mentioned_user_id = @user_id
+        )
+    )
+)::boolean AS can_reply
+FROM posts p
+JOIN users u ON p.user_id = u.id
+WHERE p.id = @post_id AND p.deleted_at IS NULL
+AND can_view_post(@user_id, p.user_id, p.is_private, u.is_private);
+
 -- name: IncrementLikeCount :one
 UPDATE posts
 SET like_count = like_count + 1
@@ -173,6 +483,12 @@ SET repost_count = repost_count + 1
 WHERE id = $1 AND deleted_at IS NULL
 RETURNING repost_count;
 
+-- name: DecrementRepostCount :one
+UPDATE posts
+SET repost_count = GREATEST(0, repost_count - 1)
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING repost_count;
+
 -- name: HasUserLikedPost :one
 SELECT EXISTS (
     SELECT 1 FROM post_likes
@@ -187,25 +503,40 @@ SELECT
     u.avatar_url
 FROM posts p
 JOIN users u ON p.user_id = u.id
-WHERE p.user_id = $1 
+WHERE p.user_id = @user_id 
 AND p.reply_to_post_id IS NOT NULL
 AND p.deleted_at IS NULL
-ORDER BY p.created_at DESC
-LIMIT $2 OFFSET $3;
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+AND (p.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (p.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN p.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    p.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
 
 -- name: GetUserLikedPosts :many
 SELECT 
     p.*,
     u.username,
     u.display_name,
-    u.avatar_url
+    u.avatar_url,
+    pl.created_at as liked_at
 FROM posts p
 JOIN users u ON p.user_id = u.id
 JOIN post_likes pl ON p.id = pl.post_id
-WHERE pl.user_id = $1 
+WHERE pl.user_id = @user_id 
 AND p.deleted_at IS NULL
-ORDER BY pl.created_at DESC
-LIMIT $2 OFFSET $3;
+AND can_view_post(@viewer_id, p.user_id, p.is_private, u.is_private)
+AND (pl.created_at, p.id) < (@before_created_at::timestamptz, @before_id::uuid)
+AND (pl.created_at, p.id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN pl.created_at END,
+    CASE WHEN @ascending::boolean THEN p.id END,
+    pl.created_at DESC,
+    p.id DESC
+LIMIT @page_limit;
 
 -- name: HasUserBookmarkedPost :one
 SELECT EXISTS (
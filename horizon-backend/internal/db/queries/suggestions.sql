@@ -0,0 +1,135 @@
+-- Users whose suggestions have never been computed or were computed before
+-- stale_before, those never computed first. Locked so only one server
+-- refreshes each; must be run in a transaction that marks them refreshed.
+-- name: GetStaleFollowSuggestionUsers :many
+SELECT u.id
+FROM users u
+LEFT JOIN follow_suggestion_refreshes r ON r.user_id = u.id
+WHERE u.deleted_at IS NULL
+AND (r.refreshed_at IS NULL OR r.refreshed_at < @stale_before)
+ORDER BY r.refreshed_at NULLS FIRST, u.id
+LIMIT @batch_limit
+FOR UPDATE OF u SKIP LOCKED;
+
+-- name: MarkFollowSuggestionsRefreshed :exec
+INSERT INTO follow_suggestion_refreshes (user_id, refreshed_at)
+SELECT unnest(@user_ids::uuid[]), @refreshed_at::timestamptz
+ON CONFLICT (user_id) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at;
+
+-- name: HasFollowSuggestionsRefreshed :one
+SELECT EXISTS (
+    SELECT 1 FROM follow_suggestion_refreshes
+    WHERE user_id = $1
+);
+
+-- name: DeleteFollowSuggestions :exec
+DELETE FROM follow_suggestions
+WHERE user_id = $1;
+
+-- Computes a user's suggestions from the follows graph. Candidates are
+-- accounts followed by the accounts the user follows (friends of friends),
+-- accounts followed by the user's followers (shared followers) and, when
+-- those turn up too few, the most followed accounts. A friend of friends
+-- weighs three times a shared follower and popularity only breaks ties;
+-- recent posts raise the score up to 3x an inactive account's. Accounts the
+-- user follows or asked to follow, blocked, muted and deleted accounts are
+-- left out.
+-- name: InsertFollowSuggestions :exec
+WITH following AS (
+    SELECT followed_id AS id, created_at FROM follows
+    WHERE follower_id = @user_id AND is_accepted
+),
+followers AS (
+    SELECT follower_id AS id FROM follows
+    WHERE followed_id = @user_id AND is_accepted
+),
+mutuals AS (
+    SELECT f.followed_id AS candidate_id,
+        COUNT(*) AS mutual_count,
+        (ARRAY_AGG(f.follower_id ORDER BY following.created_at DESC))[1:@reason_user_limit::int] AS mutual_ids
+    FROM follows f
+    JOIN following ON following.id = f.follower_id
+    WHERE f.is_accepted
+    GROUP BY f.followed_id
+),
+shared AS (
+    SELECT f.followed_id AS candidate_id, COUNT(*) AS shared_follower_count
+    FROM follows f
+    JOIN followers ON followers.id = f.follower_id
+    WHERE f.is_accepted
+    GROUP BY f.followed_id
+),
+popular AS (
+    SELECT followed_id AS candidate_id, COUNT(*) AS follower_count
+    FROM follows
+    WHERE is_accepted
+    AND (SELECT COUNT(*) FROM mutuals) + (SELECT COUNT(*) FROM shared) < @suggestion_limit::int
+    GROUP BY followed_id
+    ORDER BY COUNT(*) DESC
+    LIMIT @suggestion_limit::int
+),
+candidates AS (
+    SELECT candidate_id FROM mutuals
+    UNION SELECT candidate_id FROM shared
+    UNION SELECT candidate_id FROM popular
+),
+scored AS (
+    SELECT c.candidate_id,
+        COALESCE(m.mutual_count, 0) AS mutual_count,
+        COALESCE(m.mutual_ids, '{}') AS mutual_ids,
+        COALESCE(s.shared_follower_count, 0) AS shared_follower_count,
+        (3 * COALESCE(m.mutual_count, 0) + COALESCE(s.shared_follower_count, 0) + LN(1 + COALESCE(p.follower_count, 0)) / 10)
+            * (1 + activity.recent_posts / 10.0) AS score
+    FROM candidates c
+    JOIN users u ON u.id = c.candidate_id AND u.deleted_at IS NULL
+    LEFT JOIN mutuals m ON m.candidate_id = c.candidate_id
+    LEFT JOIN shared s ON s.candidate_id = c.candidate_id
+    LEFT JOIN popular p ON p.candidate_id = c.candidate_id
+    CROSS JOIN LATERAL (
+        SELECT COUNT(*) AS recent_posts FROM (
+            SELECT 1 FROM posts
+            WHERE posts.user_id = c.candidate_id
+            AND posts.deleted_at IS NULL
+            AND posts.created_at > @active_since
+            LIMIT 20
+        ) recent
+    ) activity
+    WHERE c.candidate_id <> @user_id
+    AND NOT EXISTS (
+        SELECT 1 FROM follows f
+        WHERE f.follower_id = @user_id AND f.followed_id = c.candidate_id
+    )
+    AND NOT is_blocked_between(@user_id, c.candidate_id)
+    AND NOT is_account_muted(@user_id, c.candidate_id)
+)
+INSERT INTO follow_suggestions (user_id, suggested_id, score, mutual_count, mutual_ids, shared_follower_count)
+SELECT @user_id, candidate_id, score, mutual_count, mutual_ids, shared_follower_count
+FROM scored
+ORDER BY score DESC
+LIMIT @suggestion_limit::int
+ON CONFLICT (user_id, suggested_id) DO NOTHING;
+
+-- A user's suggestions, best first. Accounts followed, blocked or muted since
+-- they were computed are left out.
+-- name: GetFollowSuggestions :many
+SELECT u.id, u.username, u.display_name, u.avatar_url, u.bio, u.is_private,
+    s.mutual_count, s.mutual_ids, s.shared_follower_count
+FROM follow_suggestions s
+JOIN users u ON u.id = s.suggested_id AND u.deleted_at IS NULL
+WHERE s.user_id = @user_id
+AND NOT EXISTS (
+    SELECT 1 FROM follows f
+    WHERE f.follower_id = @user_id AND f.followed_id = s.suggested_id
+)
+AND NOT is_blocked_between(@user_id, s.suggested_id)
+AND NOT is_account_muted(@user_id, s.suggested_id)
+ORDER BY s.score DESC, s.suggested_id
+LIMIT @page_limit;
+
+-- The accounts named in suggestion reasons that the viewer can still see
+-- name: GetSuggestionReasonUsers :many
+SELECT u.id, u.username, u.display_name, u.avatar_url
+FROM users u
+WHERE u.id = ANY(@user_ids::uuid[])
+AND u.deleted_at IS NULL
+AND can_view_account(@viewer_id, u.id, u.is_private);
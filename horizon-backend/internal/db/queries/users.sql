@@ -51,4 +51,10 @@ SET
   avatar_url = sqlc.arg(avatar_url),
   updated_at = NOW()
 WHERE id = sqlc.arg(id)
-RETURNING *; 
\ No newline at end of file
+RETURNING *; 
+-- Whether the viewer may see a user's private content: their posts and
+-- their followers and following lists
+-- name: CanViewUser :one
+SELECT can_view_account(@viewer_id, u.id, u.is_private)::boolean AS can_view
+FROM users u
+WHERE u.id = @user_id AND u.deleted_at IS NULL;
@@ -0,0 +1,74 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, events)
+VALUES (@user_id, @url, @secret, @events::text[])
+RETURNING *;
+
+-- name: CountWebhooks :one
+SELECT COUNT(*) FROM webhooks
+WHERE user_id = $1;
+
+-- name: GetWebhooks :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks
+WHERE id = @id AND user_id = @user_id;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET url = @url, events = @events::text[], active = @active, updated_at = NOW()
+WHERE id = @id AND user_id = @user_id
+RETURNING *;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = @id AND user_id = @user_id;
+
+-- Queues a delivery of an event to each of a user's active webhooks that
+-- subscribe to it
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT id, @event::text, @payload::jsonb
+FROM webhooks
+WHERE user_id = @user_id AND active AND @event::text = ANY(events);
+
+-- Takes a batch of due deliveries, counting the attempt and pushing
+-- next_attempt_at to lease_until so no other server picks them up meanwhile.
+-- A delivery whose server dies mid-attempt is retried once the lease ends.
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET attempts = d.attempts + 1, next_attempt_at = @lease_until
+FROM webhooks w
+WHERE d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= @due_at
+    ORDER BY next_attempt_at
+    LIMIT @batch_limit
+    FOR UPDATE SKIP LOCKED
+) AND w.id = d.webhook_id
+RETURNING d.*, w.url, w.secret;
+
+-- Records the outcome of an attempt. Retries stay pending until
+-- next_attempt_at.
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = @status,
+    response_status = @response_status,
+    error = @error,
+    next_attempt_at = @next_attempt_at,
+    last_attempt_at = NOW()
+WHERE id = @id;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = @webhook_id
+    AND (created_at, id) < (@before_created_at::timestamptz, @before_id::uuid)
+    AND (created_at, id) > (@after_created_at::timestamptz, @after_id::uuid)
+ORDER BY
+    CASE WHEN @ascending::boolean THEN created_at END,
+    CASE WHEN @ascending::boolean THEN id END,
+    created_at DESC,
+    id DESC
+LIMIT @page_limit;
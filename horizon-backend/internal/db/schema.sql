@@ -24,6 +24,9 @@ CREATE INDEX idx_users_username ON users (lower(username));
 CREATE INDEX idx_users_email ON users (lower(email));
 CREATE INDEX idx_users_created ON users (created_at);
 
+-- Who may reply to a post
+CREATE TYPE reply_policy AS ENUM ('everyone', 'following', 'mentioned');
+
 -- Posts table
 CREATE TABLE posts (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -34,16 +37,36 @@ CREATE TABLE posts (
     deleted_at TIMESTAMPTZ,
     is_private BOOLEAN DEFAULT false NOT NULL,
     reply_to_post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
-    allow_replies BOOLEAN DEFAULT true NOT NULL,
     media_urls TEXT[],
     like_count INTEGER DEFAULT 0 NOT NULL,
     repost_count INTEGER DEFAULT 0 NOT NULL,
+    quoted_post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    conversation_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    reply_policy reply_policy DEFAULT 'everyone' NOT NULL,
+    edited_at TIMESTAMPTZ,
+    edit_count INTEGER DEFAULT 0 NOT NULL,
     CONSTRAINT posts_check CHECK (id <> reply_to_post_id),
+    CONSTRAINT posts_quote_check CHECK (id <> quoted_post_id),
     CONSTRAINT posts_content_check CHECK (length(content) <= 500)
 );
 
 CREATE INDEX idx_posts_user_id ON posts (user_id);
 CREATE INDEX idx_posts_parent_id ON posts (reply_to_post_id);
+CREATE INDEX idx_posts_created_id ON posts (created_at DESC, id DESC);
+CREATE INDEX idx_posts_user_created_id ON posts (user_id, created_at DESC, id DESC);
+CREATE INDEX idx_posts_parent_created_id ON posts (reply_to_post_id, created_at DESC, id DESC);
+CREATE INDEX idx_posts_conversation_created ON posts (conversation_id, created_at);
+CREATE INDEX idx_posts_quoted_created_id ON posts (quoted_post_id, created_at DESC, id DESC) WHERE quoted_post_id IS NOT NULL;
+
+-- Previous versions of a post's content
+CREATE TABLE post_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_post_revisions_post_created_id ON post_revisions (post_id, created_at DESC, id DESC);
 
 -- Post likes table
 CREATE TABLE post_likes (
@@ -54,6 +77,7 @@ CREATE TABLE post_likes (
 );
 
 CREATE INDEX idx_post_likes_post ON post_likes (post_id);
+CREATE INDEX idx_post_likes_user_created ON post_likes (user_id, created_at DESC, post_id DESC);
 
 -- Follows table
 CREATE TABLE follows (
@@ -67,6 +91,104 @@ CREATE TABLE follows (
 
 CREATE INDEX idx_follows_follower ON follows (follower_id);
 CREATE INDEX idx_follows_followed ON follows (followed_id);
+CREATE INDEX idx_follows_followed_created ON follows (followed_id, created_at DESC, follower_id DESC);
+CREATE INDEX idx_follows_follower_created ON follows (follower_id, created_at DESC, followed_id DESC);
+
+-- Blocks between accounts
+CREATE TABLE blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT blocks_check CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked ON blocks (blocked_id, blocker_id);
+CREATE INDEX idx_blocks_blocker_created ON blocks (blocker_id, created_at DESC, blocked_id DESC);
+
+-- Whether either of two accounts has blocked the other. Accounts on either
+-- side of a block don't see or interact with each other.
+CREATE OR REPLACE FUNCTION is_blocked_between(user_a UUID, user_b UUID)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM blocks b
+        WHERE (b.blocker_id = user_a AND b.blocked_id = user_b)
+        OR (b.blocker_id = user_b AND b.blocked_id = user_a)
+    )
+$$;
+
+-- Muted accounts. Their posts are left out of the muter's home and hashtag
+-- timelines until the mute expires; mutes without an expiry last until
+-- they're lifted.
+CREATE TABLE user_mutes (
+    muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (muter_id, muted_id),
+    CONSTRAINT user_mutes_check CHECK (muter_id <> muted_id)
+);
+
+CREATE INDEX idx_user_mutes_muter_created ON user_mutes (muter_id, created_at DESC, muted_id DESC);
+
+-- Whether a user has muted an account, counting only mutes that haven't
+-- expired
+CREATE OR REPLACE FUNCTION is_account_muted(user_id UUID, account_id UUID)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM user_mutes m
+        WHERE m.muter_id = user_id
+        AND m.muted_id = account_id
+        AND (m.expires_at IS NULL OR m.expires_at > NOW())
+    )
+$$;
+
+-- Muted threads, keyed by their root post. Replies in them don't notify the
+-- user.
+CREATE TABLE conversation_mutes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, conversation_id)
+);
+
+-- Single source of truth for who may see an account's content. Public
+-- content is visible to everyone; private content only to its owner and
+-- to accepted followers. Anonymous viewers (NULL) only see public content.
+-- Nothing is visible across a block.
+CREATE OR REPLACE FUNCTION can_view_account(viewer_id UUID, account_id UUID, is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT (
+            NOT is_private
+            OR COALESCE(account_id = viewer_id, false)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = viewer_id
+                AND f.followed_id = account_id
+                AND f.is_accepted = true
+            )
+        )
+        AND NOT is_blocked_between(viewer_id, account_id)
+$$;
+
+-- A post is private when either the post itself or its author's account is
+-- private
+CREATE OR REPLACE FUNCTION can_view_post(viewer_id UUID, author_id UUID, post_is_private BOOLEAN, author_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT can_view_account(viewer_id, author_id, post_is_private OR author_is_private)
+$$;
 
 -- Bookmarks table
 CREATE TABLE bookmarks (
@@ -77,6 +199,7 @@ CREATE TABLE bookmarks (
 );
 
 CREATE INDEX idx_bookmarks_user ON bookmarks (user_id);
+CREATE INDEX idx_bookmarks_user_created ON bookmarks (user_id, created_at DESC, post_id DESC);
 
 -- Media table
 CREATE TABLE media (
@@ -100,19 +223,85 @@ CREATE TABLE mentions (
 
 CREATE INDEX idx_mentions_user ON mentions (mentioned_user_id);
 
+-- Conversations table. A conversation is either one-to-one or a group.
+-- One-to-one conversations are unique per pair of users, stored with the
+-- smaller id first; only groups have a title.
+CREATE TYPE conversation_role AS ENUM ('owner', 'member');
+
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    is_group BOOLEAN NOT NULL DEFAULT false,
+    title VARCHAR(100),
+    direct_user1_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    direct_user2_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_activity_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT conversations_kind_check CHECK (
+        (is_group AND direct_user1_id IS NULL AND direct_user2_id IS NULL)
+        OR (NOT is_group AND title IS NULL AND direct_user1_id < direct_user2_id)
+    ),
+    CONSTRAINT conversations_direct_users_key UNIQUE (direct_user1_id, direct_user2_id)
+);
+
+CREATE INDEX idx_conversations_activity_id ON conversations (last_activity_at DESC, id DESC);
+
 -- Messages table
 CREATE TABLE messages (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    receiver_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     content TEXT NOT NULL,
-    read BOOLEAN DEFAULT false NOT NULL,
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    CONSTRAINT messages_check CHECK (sender_id <> receiver_id),
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
     CONSTRAINT messages_content_check CHECK (length(content) <= 1000)
 );
 
-CREATE INDEX idx_messages_conversation ON messages (LEAST(sender_id, receiver_id), GREATEST(sender_id, receiver_id), created_at);
+CREATE INDEX idx_messages_sender_created_id ON messages (sender_id, created_at DESC, id DESC);
+CREATE INDEX idx_messages_conversation_id_created ON messages (conversation_id, created_at DESC, id DESC);
+
+-- Members of a conversation. last_read_at is the member's read cursor:
+-- messages created after it are unread.
+CREATE TABLE conversation_members (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role conversation_role NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_read_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    last_read_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX idx_conversation_members_user ON conversation_members (user_id);
+
+-- Single source of truth for who may send a direct message to an account.
+-- Public accounts accept messages from anyone. Private accounts accept them
+-- from accepted followers, from accounts they follow and from people they
+-- have already messaged one-to-one, so they can always get replies. No one
+-- can message across a block.
+CREATE OR REPLACE FUNCTION can_message_account(from_id UUID, to_id UUID, to_is_private BOOLEAN)
+RETURNS BOOLEAN
+LANGUAGE sql
+STABLE
+AS $$
+    SELECT COALESCE(from_id <> to_id, false)
+        AND NOT is_blocked_between(from_id, to_id)
+        AND (
+            can_view_account(from_id, to_id, to_is_private)
+            OR EXISTS (
+                SELECT 1 FROM follows f
+                WHERE f.follower_id = to_id
+                AND f.followed_id = from_id
+                AND f.is_accepted = true
+            )
+            OR EXISTS (
+                SELECT 1 FROM conversations c
+                JOIN messages m ON m.conversation_id = c.id
+                WHERE c.direct_user1_id = LEAST(from_id, to_id)
+                AND c.direct_user2_id = GREATEST(from_id, to_id)
+                AND m.sender_id = to_id
+            )
+        )
+$$;
 
 -- Post hashtags table
 CREATE TABLE post_hashtags (
@@ -123,6 +312,8 @@ CREATE TABLE post_hashtags (
 );
 
 CREATE INDEX idx_post_hashtags_tag ON post_hashtags (hashtag);
+CREATE INDEX idx_post_hashtags_tag_created ON post_hashtags (hashtag, created_at DESC);
+CREATE INDEX idx_post_hashtags_created ON post_hashtags (created_at);
 
 -- Reposts table
 CREATE TABLE reposts (
@@ -136,6 +327,8 @@ CREATE TABLE reposts (
 
 CREATE INDEX idx_reposts_post ON reposts (post_id);
 CREATE INDEX idx_reposts_original_poster ON reposts (original_poster_id);
+CREATE INDEX idx_reposts_reposter_created ON reposts (reposter_id, created_at DESC, post_id DESC);
+CREATE INDEX idx_reposts_post_created ON reposts (post_id, created_at DESC, reposter_id DESC);
 
 -- User relationships table
 CREATE TABLE user_relationships (
@@ -150,7 +343,24 @@ CREATE INDEX idx_relationships_follower ON user_relationships (follower_id);
 CREATE INDEX idx_relationships_following ON user_relationships (following_id);
 
 -- Add notifications table
-CREATE TYPE notification_type AS ENUM ('like', 'repost', 'reply', 'follow');
+CREATE TYPE notification_type AS ENUM ('like', 'repost', 'reply', 'follow', 'quote', 'mention', 'follow_request', 'follow_accepted', 'thread_reply');
+
+-- Notifications of the same type about the same post that arrive within a
+-- window are collapsed into a group. Groups carry the read state.
+CREATE TABLE notification_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    post_id UUID REFERENCES posts(id),
+    group_key TEXT,
+    read BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_groups_user_updated ON notification_groups(user_id, updated_at DESC, id DESC);
+CREATE INDEX idx_notification_groups_open ON notification_groups(user_id, group_key, created_at DESC) WHERE group_key IS NOT NULL;
+CREATE INDEX idx_notification_groups_unread ON notification_groups(user_id) WHERE read = false;
 
 CREATE TABLE notifications (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -163,6 +373,7 @@ CREATE TABLE notifications (
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     deleted_at TIMESTAMPTZ,
+    group_id UUID NOT NULL REFERENCES notification_groups(id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES users(id),
     FOREIGN KEY (actor_id) REFERENCES users(id),
     FOREIGN KEY (post_id) REFERENCES posts(id),
@@ -173,6 +384,127 @@ CREATE TABLE notifications (
 CREATE INDEX idx_notifications_user_id ON notifications(user_id);
 CREATE INDEX idx_notifications_created_at ON notifications(created_at);
 CREATE INDEX idx_notifications_read ON notifications(read);
+CREATE INDEX idx_notifications_user_created_id ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_notifications_group_created ON notifications(group_id, created_at DESC, id DESC);
+
+-- How a user receives each type of notification. Types without a row use
+-- the defaults: enabled, from anyone, not muted.
+CREATE TABLE notification_settings (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type notification_type NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    only_from_following BOOLEAN NOT NULL DEFAULT false,
+    muted BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, type)
+);
+
+CREATE TYPE digest_frequency AS ENUM ('off', 'daily', 'weekly');
+
+-- When each user is emailed a digest of their unread notifications. hour is
+-- the local hour in timezone; weekday (0 = Sunday) only applies to weekly
+-- digests.
+CREATE TABLE email_digest_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    frequency digest_frequency NOT NULL DEFAULT 'off',
+    hour INTEGER NOT NULL DEFAULT 9 CHECK (hour BETWEEN 0 AND 23),
+    weekday INTEGER NOT NULL DEFAULT 1 CHECK (weekday BETWEEN 0 AND 6),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    unsubscribe_token TEXT NOT NULL UNIQUE,
+    last_sent_at TIMESTAMPTZ,
+    next_send_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_digest_settings_due ON email_digest_settings (next_send_at) WHERE frequency <> 'off';
+
+-- Outbound webhooks. Each receives the chosen events about its owner.
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhooks_user ON webhooks (user_id);
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'failed');
+
+-- The delivery queue and log. Pending deliveries are attempted from
+-- next_attempt_at on; the rest are kept as a record of what was sent.
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ,
+    response_status INTEGER,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at DESC, id DESC);
+
+-- Muted words and phrases. A filter matches its phrase, or the regular
+-- expression in it when is_regex is set, in each of its scopes until it
+-- expires.
+CREATE TABLE filters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    phrase TEXT NOT NULL,
+    is_regex BOOLEAN NOT NULL DEFAULT false,
+    whole_word BOOLEAN NOT NULL DEFAULT true,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_filters_user ON filters (user_id, created_at DESC, id DESC);
+
+CREATE TYPE filter_action AS ENUM ('hide', 'warn');
+
+-- What happens to content a user's filters match: hidden outright, or kept
+-- and marked as filtered
+CREATE TABLE filter_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    action filter_action NOT NULL DEFAULT 'hide',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Precomputed who-to-follow suggestions, best first by score. mutual_ids
+-- holds a few of the mutual_count accounts the user follows that follow the
+-- suggestion, to explain it.
+CREATE TABLE follow_suggestions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    suggested_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    mutual_count INTEGER NOT NULL DEFAULT 0,
+    mutual_ids UUID[] NOT NULL DEFAULT '{}',
+    shared_follower_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, suggested_id),
+    CONSTRAINT follow_suggestions_check CHECK (user_id <> suggested_id)
+);
+
+CREATE INDEX idx_follow_suggestions_user_score ON follow_suggestions (user_id, score DESC, suggested_id);
+
+-- When each user's suggestions were last computed
+CREATE TABLE follow_suggestion_refreshes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    refreshed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_follow_suggestion_refreshes_refreshed ON follow_suggestion_refreshes (refreshed_at);
 
 -- Add trigger to update updated_at
 CREATE TRIGGER set_timestamp
@@ -11,6 +11,26 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const canViewUser = `-- name: CanViewUser :one
+SELECT can_view_account($1, u.id, u.is_private)::boolean AS can_view
+FROM users u
+WHERE u.id = $2 AND u.deleted_at IS NULL
+`
+
+type CanViewUserParams struct {
+	ViewerID pgtype.UUID `json:"viewer_id"`
+	UserID   pgtype.UUID `json:"user_id"`
+}
+
+// Whether the viewer may see a user's private content: their posts and
+// their followers and following lists
+func (q *Queries) CanViewUser(ctx context.Context, arg CanViewUserParams) (bool, error) {
+	row := q.db.QueryRow(ctx, canViewUser, arg.ViewerID, arg.UserID)
+	var can_view bool
+	err := row.Scan(&can_view)
+	return can_view, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     username, 
@@ -0,0 +1,79 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	digestText = texttemplate.Must(texttemplate.ParseFS(templates, "templates/digest.txt"))
+	digestHTML = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/digest.html"))
+)
+
+// Digest is a summary of a user's unread notifications
+type Digest struct {
+	// Name is how the user is greeted
+	Name string
+
+	// Period is "daily" or "weekly"
+	Period string
+
+	Items []DigestItem
+
+	// More counts unread notifications that aren't listed
+	More int64
+
+	NotificationsURL string
+	UnsubscribeURL   string
+}
+
+// DigestItem is one notification group in a digest
+type DigestItem struct {
+	// Summary says what happened, e.g. "alice and 2 others liked your post"
+	Summary string
+
+	// Excerpt is the start of the post it's about, if any
+	Excerpt string
+}
+
+// Subject is the digest's email subject
+func (d Digest) Subject() string {
+	count := int64(len(d.Items)) + d.More
+	switch count {
+	case 0:
+		return "You're all caught up on Horizon"
+	case 1:
+		return "You have 1 unread notification on Horizon"
+	default:
+		return fmt.Sprintf("You have %d unread notifications on Horizon", count)
+	}
+}
+
+// DigestMessage renders a digest as an email to the given address. It
+// carries one-click unsubscribe headers (RFC 8058).
+func DigestMessage(to string, d Digest) (Message, error) {
+	var text, html bytes.Buffer
+	if err := digestText.Execute(&text, d); err != nil {
+		return Message{}, fmt.Errorf("failed to render digest: %w", err)
+	}
+	if err := digestHTML.Execute(&html, d); err != nil {
+		return Message{}, fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: d.Subject(),
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + d.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
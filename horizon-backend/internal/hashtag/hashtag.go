@@ -0,0 +1,88 @@
+package hashtag
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the longest hashtag stored, in characters, matching the
+// post_hashtags.hashtag column
+const MaxLength = 140
+
+// Extract returns the normalized hashtags in a post's content, in order of
+// first appearance and without duplicates. A tag starts with '#' (or its
+// full-width form) that isn't preceded by a tag character, so "a#b" and
+// "&#39;" don't produce tags.
+func Extract(content string) []string {
+	// NFKC folds full-width '＃' and letters into their canonical forms
+	runes := []rune(norm.NFKC.String(content))
+
+	var tags []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' {
+			continue
+		}
+		if i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '&' || runes[i-1] == '#') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+
+		if tag, ok := Normalize(string(runes[i+1 : end])); ok && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		i = end - 1
+	}
+
+	return tags
+}
+
+// Normalize returns the canonical form of a hashtag, with or without its
+// leading '#': NFKC normalized and case folded so that "#Café", "#CAFÉ" and
+// "#café" are the same tag. ok is false when the text isn't a valid tag.
+func Normalize(tag string) (string, bool) {
+	tag = norm.NFKC.String(tag)
+	tag = strings.TrimPrefix(tag, "#")
+	// Case folding can produce denormalized text, so normalize again
+	tag = norm.NFKC.String(cases.Fold().String(tag))
+
+	if tag == "" || utf8.RuneCountInString(tag) > MaxLength {
+		return "", false
+	}
+
+	hasNonDigit := false
+	for _, r := range tag {
+		if !isTagRune(r) {
+			return "", false
+		}
+		if !unicode.IsDigit(r) {
+			hasNonDigit = true
+		}
+	}
+	// Numbers alone like "#1" aren't tags
+	if !hasNonDigit {
+		return "", false
+	}
+
+	return tag, true
+}
+
+// isTagRune reports whether r can be part of a hashtag: letters and their
+// combining marks in any script, digits, underscores and the zero-width
+// non-joiner used by scripts such as Persian
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) ||
+		unicode.Is(unicode.M, r) ||
+		unicode.IsDigit(r) ||
+		r == '_' ||
+		r == '\u200c'
+}
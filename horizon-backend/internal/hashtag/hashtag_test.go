@@ -0,0 +1,75 @@
+package hashtag
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "no tags here", nil},
+		{"single", "hello #world", []string{"world"}},
+		{"order of first appearance", "#b #a #c", []string{"b", "a", "c"}},
+		{"duplicates differing in case", "#Go #go #GO", []string{"go"}},
+		{"trailing punctuation", "love #golang!", []string{"golang"}},
+		{"underscores and digits", "#go_1_22 #2024goals", []string{"go_1_22", "2024goals"}},
+		{"numbers alone", "#1 #2024", nil},
+		{"inside a word", "a#b", nil},
+		{"html entity", "it&#39;s", nil},
+		{"double hash", "##tag", nil},
+		{"empty tag", "# tag", nil},
+		{"full-width hash", "＃ｔａｇ", []string{"tag"}},
+		{"accented", "#Café #CAFÉ", []string{"café"}},
+		{"non-latin", "#東京 #Москва", []string{"東京", "москва"}},
+		{"zero-width non-joiner", "#می\u200cخواهم", []string{"می\u200cخواهم"}},
+		{"too long", "#" + strings.Repeat("a", MaxLength+1), nil},
+		{"longest", "#" + strings.Repeat("a", MaxLength), []string{strings.Repeat("a", MaxLength)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("Extract(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		tag    string
+		want   string
+		wantOK bool
+	}{
+		{"plain", "golang", "golang", true},
+		{"leading hash", "#golang", "golang", true},
+		{"full-width hash", "＃golang", "golang", true},
+		{"upper case", "GoLang", "golang", true},
+		{"composed accent", "Café", "café", true},
+		{"decomposed accent", "Cafe\u0301", "café", true},
+		{"full-width letters", "ＧＯ", "go", true},
+		{"sharp s folds", "Straße", "strasse", true},
+		{"final sigma folds", "ΟΔΟΣ", "οδοσ", true},
+		{"empty", "", "", false},
+		{"hash only", "#", "", false},
+		{"digits only", "123", "", false},
+		{"space", "two words", "", false},
+		{"punctuation", "c++", "", false},
+		{"too long", strings.Repeat("a", MaxLength+1), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Normalize(tt.tag)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
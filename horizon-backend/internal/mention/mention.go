@@ -0,0 +1,57 @@
+package mention
+
+import (
+	"strings"
+)
+
+const (
+	// MaxUsernameLength matches the users.username column
+	MaxUsernameLength = 30
+
+	// MaxPerPost caps how many users a single post can mention
+	MaxPerPost = 50
+)
+
+// Extract returns the lowercased usernames mentioned in a post's content, in
+// order of first appearance and without duplicates. A mention is '@'
+// followed by letters, digits or underscores and not preceded by one of
+// them, so email addresses like "me@example.com" aren't mentions.
+func Extract(content string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(content); i++ {
+		if content[i] != '@' {
+			continue
+		}
+		if i > 0 && (isUsernameByte(content[i-1]) || content[i-1] == '@') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(content) && isUsernameByte(content[end]) {
+			end++
+		}
+
+		// Longer runs aren't usernames; skip the whole run
+		if n := end - (i + 1); n > 0 && n <= MaxUsernameLength {
+			username := strings.ToLower(content[i+1 : end])
+			if !seen[username] {
+				seen[username] = true
+				usernames = append(usernames, username)
+				if len(usernames) == MaxPerPost {
+					break
+				}
+			}
+		}
+		i = end - 1
+	}
+
+	return usernames
+}
+
+func isUsernameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' ||
+		b >= 'A' && b <= 'Z' ||
+		b >= '0' && b <= '9' ||
+		b == '_'
+}
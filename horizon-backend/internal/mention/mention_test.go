@@ -0,0 +1,70 @@
+package mention
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "no mentions here", nil},
+		{"single", "hi @alice", []string{"alice"}},
+		{"order of first appearance", "@bob @alice @carol", []string{"bob", "alice", "carol"}},
+		{"lowercased and deduplicated", "@Alice @ALICE @alice", []string{"alice"}},
+		{"trailing punctuation", "thanks @alice!", []string{"alice"}},
+		{"underscores and digits", "@al_ice99", []string{"al_ice99"}},
+		{"email address", "me@example.com", nil},
+		{"double at", "@@alice", nil},
+		{"bare at", "@ alice", nil},
+		{"after punctuation", "(@alice)", []string{"alice"}},
+		{"longest username", "@" + strings.Repeat("a", MaxUsernameLength), []string{strings.Repeat("a", MaxUsernameLength)}},
+		{"too long", "@" + strings.Repeat("a", MaxUsernameLength+1), nil},
+		{"too long run skipped whole", "@" + strings.Repeat("a", MaxUsernameLength+5) + " @bob", []string{"bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Extract(tt.content); !slices.Equal(got, tt.want) {
+				t.Errorf("Extract(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractMaxPerPost(t *testing.T) {
+	tests := []struct {
+		name     string
+		mentions int
+		want     int
+	}{
+		{"under the cap", MaxPerPost - 1, MaxPerPost - 1},
+		{"at the cap", MaxPerPost, MaxPerPost},
+		{"over the cap", MaxPerPost + 10, MaxPerPost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			for i := 0; i < tt.mentions; i++ {
+				fmt.Fprintf(&b, "@user%d ", i)
+			}
+
+			got := Extract(b.String())
+			if len(got) != tt.want {
+				t.Fatalf("Extract() returned %d usernames, want %d", len(got), tt.want)
+			}
+			// The first mentions are the ones kept
+			for i, username := range got {
+				if want := fmt.Sprintf("user%d", i); username != want {
+					t.Fatalf("Extract()[%d] = %q, want %q", i, username, want)
+				}
+			}
+		})
+	}
+}
@@ -30,29 +30,64 @@ func AuthMiddleware(authProvider auth.AuthProvider) echo.MiddlewareFunc {
 			if len(authParts) != 2 || authParts[0] != "Bearer" {
 				return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization header format")
 			}
-			tokenString := authParts[1]
 
-			// Verify token
-			userID, err := authProvider.VerifyToken(c.Request().Context(), tokenString)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			if err := authenticate(c, authProvider, authParts[1]); err != nil {
+				return err
 			}
 
-			// Get user from token
-			user, err := authProvider.GetUserFromToken(c.Request().Context(), tokenString)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			return next(c)
+		}
+	}
+}
+
+// StreamAuthMiddleware validates the JWT token of streaming endpoints. Browsers
+// can't set headers on EventSource and WebSocket requests, so the token may
+// also be passed in the access_token query parameter.
+func StreamAuthMiddleware(authProvider auth.AuthProvider) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tokenString := c.QueryParam("access_token")
+			if authHeader := c.Request().Header.Get("Authorization"); authHeader != "" {
+				authParts := strings.Split(authHeader, " ")
+				if len(authParts) != 2 || authParts[0] != "Bearer" {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid authorization header format")
+				}
+				tokenString = authParts[1]
+			}
+			if tokenString == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing access token")
 			}
 
-			// Set user info in context
-			c.Set("user", user)
-			c.Set("user_id", userID)
+			if err := authenticate(c, authProvider, tokenString); err != nil {
+				return err
+			}
 
 			return next(c)
 		}
 	}
 }
 
+// authenticate verifies a token and sets the user info in the context
+func authenticate(c echo.Context, authProvider auth.AuthProvider, tokenString string) error {
+	// Verify token
+	userID, err := authProvider.VerifyToken(c.Request().Context(), tokenString)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+
+	// Get user from token
+	user, err := authProvider.GetUserFromToken(c.Request().Context(), tokenString)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+	}
+
+	// Set user info in context
+	c.Set("user", user)
+	c.Set("user_id", userID)
+
+	return nil
+}
+
 // isPublicEndpoint checks if an endpoint is public (doesn't require authentication)
 func isPublicEndpoint(path string) bool {
 	// Define public endpoints
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DigestFrequency is how often a user is emailed a digest of their unread
+// notifications
+type DigestFrequency string
+
+const (
+	DigestFrequencyOff    DigestFrequency = "off"
+	DigestFrequencyDaily  DigestFrequency = "daily"
+	DigestFrequencyWeekly DigestFrequency = "weekly"
+)
+
+// IsValid reports whether f is a known digest frequency
+func (f DigestFrequency) IsValid() bool {
+	switch f {
+	case DigestFrequencyOff, DigestFrequencyDaily, DigestFrequencyWeekly:
+		return true
+	}
+	return false
+}
+
+// Period is the stretch of time one digest covers
+func (f DigestFrequency) Period() time.Duration {
+	if f == DigestFrequencyWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// EmailDigestSettings is when a user is emailed digests
+type EmailDigestSettings struct {
+	Frequency DigestFrequency `json:"frequency"`
+
+	// Hour is the local hour digests are sent at, 0 to 23
+	Hour int32 `json:"hour"`
+
+	// Weekday is the day weekly digests are sent on, 0 (Sunday) to 6
+	Weekday int32 `json:"weekday"`
+
+	// Timezone is an IANA time zone name such as "Europe/Paris"
+	Timezone string `json:"timezone"`
+
+	LastSentAt pgtype.Timestamptz `json:"last_sent_at"`
+	NextSendAt pgtype.Timestamptz `json:"next_send_at"`
+}
+
+// DefaultEmailDigestSettings apply to users who haven't set a schedule
+var DefaultEmailDigestSettings = EmailDigestSettings{
+	Frequency: DigestFrequencyOff,
+	Hour:      9,
+	Weekday:   int32(time.Monday),
+	Timezone:  "UTC",
+}
+
+// NextDigestAt returns when the first digest after t is due. ok is false when
+// digests are off.
+func (s EmailDigestSettings) NextDigestAt(t time.Time) (next time.Time, ok bool) {
+	if s.Frequency != DigestFrequencyDaily && s.Frequency != DigestFrequencyWeekly {
+		return time.Time{}, false
+	}
+
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := t.In(loc)
+	next = time.Date(local.Year(), local.Month(), local.Day(), int(s.Hour), 0, 0, 0, loc)
+
+	if s.Frequency == DigestFrequencyWeekly {
+		days := (int(s.Weekday) - int(next.Weekday()) + 7) % 7
+		next = next.AddDate(0, 0, days)
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next, true
+	}
+
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, true
+}
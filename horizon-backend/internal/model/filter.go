@@ -0,0 +1,90 @@
+package model
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// MaxFilters caps how many filters a user can have
+	MaxFilters = 100
+
+	// MaxFilterPhraseLength caps the length of a filter's phrase or regular
+	// expression, in characters
+	MaxFilterPhraseLength = 200
+)
+
+// FilterScope is a place filters can apply to
+type FilterScope string
+
+const (
+	FilterScopeHome          FilterScope = "home"
+	FilterScopeNotifications FilterScope = "notifications"
+	FilterScopeReplies       FilterScope = "replies"
+)
+
+// FilterScopes lists every filter scope
+var FilterScopes = []FilterScope{
+	FilterScopeHome,
+	FilterScopeNotifications,
+	FilterScopeReplies,
+}
+
+// IsValid reports whether s is a known filter scope
+func (s FilterScope) IsValid() bool {
+	for _, known := range FilterScopes {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterAction is what happens to content a user's filters match
+type FilterAction string
+
+const (
+	// FilterActionHide leaves filtered content out of lists
+	FilterActionHide FilterAction = "hide"
+
+	// FilterActionWarn keeps filtered content in lists, marked as filtered so
+	// clients can show it collapsed
+	FilterActionWarn FilterAction = "warn"
+)
+
+// IsValid reports whether a is a known filter action
+func (a FilterAction) IsValid() bool {
+	switch a {
+	case FilterActionHide, FilterActionWarn:
+		return true
+	}
+	return false
+}
+
+// Filter is a muted word or phrase. Phrase is matched case-insensitively as
+// plain text, or as a regular expression when IsRegex is set. WholeWord only
+// matches plain phrases that aren't part of a longer word.
+type Filter struct {
+	ID        pgtype.UUID        `json:"id"`
+	Phrase    string             `json:"phrase"`
+	IsRegex   bool               `json:"is_regex"`
+	WholeWord bool               `json:"whole_word"`
+	Scopes    []FilterScope      `json:"scopes"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+// FilterSettings are how a user's filters treat the content they match
+type FilterSettings struct {
+	Action FilterAction `json:"action"`
+}
+
+// DefaultFilterSettings apply to users who haven't changed them
+var DefaultFilterSettings = FilterSettings{Action: FilterActionHide}
+
+// FilterResult marks content that matched some of the viewer's filters. It's
+// only set when the viewer's filter action is warn.
+type FilterResult struct {
+	FilterIDs []pgtype.UUID `json:"filter_ids"`
+	Phrases   []string      `json:"phrases"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// MaxMessageLength matches the messages.content check constraint
+	MaxMessageLength = 1000
+
+	// MaxConversationTitleLength matches the conversations.title column
+	MaxConversationTitleLength = 100
+
+	// MaxGroupMembers caps the size of a group conversation, owner included
+	MaxGroupMembers = 50
+)
+
+type ConversationRole string
+
+const (
+	ConversationRoleOwner  ConversationRole = "owner"
+	ConversationRoleMember ConversationRole = "member"
+)
+
+type Message struct {
+	ID             pgtype.UUID        `json:"id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	SenderID       pgtype.UUID        `json:"sender_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+// ConversationMember is a user taking part in a conversation. LastReadAt is
+// the member's read cursor: messages created after it are unread.
+type ConversationMember struct {
+	ID                pgtype.UUID        `json:"id"`
+	Username          string             `json:"username"`
+	DisplayName       pgtype.Text        `json:"display_name"`
+	AvatarURL         pgtype.Text        `json:"avatar_url"`
+	Role              ConversationRole   `json:"role"`
+	JoinedAt          pgtype.Timestamptz `json:"joined_at"`
+	LastReadMessageID pgtype.UUID        `json:"last_read_message_id"`
+	LastReadAt        pgtype.Timestamptz `json:"last_read_at"`
+}
+
+// Conversation is a one-to-one or group message thread as seen by one of its
+// members
+type Conversation struct {
+	ID             pgtype.UUID          `json:"id"`
+	IsGroup        bool                 `json:"is_group"`
+	Title          pgtype.Text          `json:"title"`
+	Role           ConversationRole     `json:"role"`
+	Members        []ConversationMember `json:"members"`
+	LastMessage    *Message             `json:"last_message"`
+	UnreadCount    int64                `json:"unread_count"`
+	CreatedAt      pgtype.Timestamptz   `json:"created_at"`
+	LastActivityAt pgtype.Timestamptz   `json:"last_activity_at"`
+}
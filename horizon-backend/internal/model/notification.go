@@ -7,12 +7,72 @@ import (
 type NotificationType string
 
 const (
-	NotificationTypeLike   NotificationType = "like"
-	NotificationTypeRepost NotificationType = "repost"
-	NotificationTypeReply  NotificationType = "reply"
-	NotificationTypeFollow NotificationType = "follow"
+	NotificationTypeLike           NotificationType = "like"
+	NotificationTypeRepost         NotificationType = "repost"
+	NotificationTypeReply          NotificationType = "reply"
+	NotificationTypeFollow         NotificationType = "follow"
+	NotificationTypeQuote          NotificationType = "quote"
+	NotificationTypeMention        NotificationType = "mention"
+	NotificationTypeFollowRequest  NotificationType = "follow_request"
+	NotificationTypeFollowAccepted NotificationType = "follow_accepted"
+
+	// NotificationTypeThreadReply is a reply deeper in a thread the user
+	// started, rather than to one of their posts
+	NotificationTypeThreadReply NotificationType = "thread_reply"
 )
 
+// NotificationTypes lists every notification type
+var NotificationTypes = []NotificationType{
+	NotificationTypeLike,
+	NotificationTypeRepost,
+	NotificationTypeReply,
+	NotificationTypeFollow,
+	NotificationTypeQuote,
+	NotificationTypeMention,
+	NotificationTypeFollowRequest,
+	NotificationTypeFollowAccepted,
+	NotificationTypeThreadReply,
+}
+
+// IsValid reports whether t is a known notification type
+func (t NotificationType) IsValid() bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPost reports whether notifications of the type are about a post
+func (t NotificationType) HasPost() bool {
+	switch t {
+	case NotificationTypeFollow, NotificationTypeFollowRequest, NotificationTypeFollowAccepted:
+		return false
+	}
+	return true
+}
+
+// IsFromActor reports whether the post of notifications of the type was
+// written by the actor, rather than being one of the user's own posts
+func (t NotificationType) IsFromActor() bool {
+	switch t {
+	case NotificationTypeReply, NotificationTypeQuote, NotificationTypeMention, NotificationTypeThreadReply:
+		return true
+	}
+	return false
+}
+
+// HasParentPost reports whether notifications of the type refer to a second
+// post: the post replied to, the quoted post, or the root of the thread
+func (t NotificationType) HasParentPost() bool {
+	switch t {
+	case NotificationTypeReply, NotificationTypeQuote, NotificationTypeThreadReply:
+		return true
+	}
+	return false
+}
+
 type Notification struct {
 	ID           pgtype.UUID        `json:"id"`
 	UserID       pgtype.UUID        `json:"user_id"`
@@ -24,6 +84,7 @@ type Notification struct {
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
 	DeletedAt    pgtype.Timestamptz `json:"deleted_at,omitempty"`
+	GroupID      pgtype.UUID        `json:"group_id"`
 
 	// Additional fields from joins
 	ActorUsername     string      `json:"actor_username,omitempty"`
@@ -31,4 +92,67 @@ type Notification struct {
 	ActorAvatarURL    pgtype.Text `json:"actor_avatar_url,omitempty"`
 	PostContent       pgtype.Text `json:"post_content,omitempty"`
 	ParentPostContent pgtype.Text `json:"parent_post_content,omitempty"`
+
+	// Set when the post matched the user's filters and they chose to see
+	// filtered notifications collapsed
+	Filtered *FilterResult `json:"filtered,omitempty"`
 }
+
+// NotificationGroupActorPreviews is how many of a group's actors are listed
+const NotificationGroupActorPreviews = 3
+
+// NotificationGroup is one or more notifications of the same type about the
+// same post, e.g. everyone who liked a post within a window. Replies, quotes,
+// mentions and accepted follow requests are never grouped with others.
+type NotificationGroup struct {
+	ID     pgtype.UUID      `json:"id"`
+	Type   NotificationType `json:"type"`
+	PostID pgtype.UUID      `json:"post_id,omitempty"`
+	Read   bool             `json:"read"`
+
+	// Actors are the most recent actors, newest first; ActorCount counts
+	// all of them
+	Actors     []NotificationActor `json:"actors"`
+	ActorCount int64               `json:"actor_count"`
+
+	// Taken from the group's latest notification
+	LatestNotificationID pgtype.UUID `json:"latest_notification_id"`
+	ParentPostID         pgtype.UUID `json:"parent_post_id,omitempty"`
+
+	PostContent       pgtype.Text        `json:"post_content,omitempty"`
+	ParentPostContent pgtype.Text        `json:"parent_post_content,omitempty"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+
+	// Set when the post matched the user's filters and they chose to see
+	// filtered notifications collapsed
+	Filtered *FilterResult `json:"filtered,omitempty"`
+}
+
+// NotificationActor is a user who caused a notification
+type NotificationActor struct {
+	ID          pgtype.UUID `json:"id"`
+	Username    string      `json:"username"`
+	DisplayName pgtype.Text `json:"display_name,omitempty"`
+	AvatarURL   pgtype.Text `json:"avatar_url,omitempty"`
+}
+
+// NotificationSetting is how a user receives one type of notification
+type NotificationSetting struct {
+	// Enabled notifications are created; disabled ones aren't
+	Enabled bool `json:"enabled"`
+
+	// OnlyFromFollowing limits notifications to ones caused by accounts the
+	// user follows
+	OnlyFromFollowing bool `json:"only_from_following"`
+
+	// Muted notifications are still listed, but arrive read and aren't
+	// pushed to live streams
+	Muted bool `json:"muted"`
+}
+
+// DefaultNotificationSetting applies to types a user hasn't changed
+var DefaultNotificationSetting = NotificationSetting{Enabled: true}
+
+// NotificationSettings are a user's settings for each notification type
+type NotificationSettings map[NotificationType]NotificationSetting
@@ -4,24 +4,83 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// ReplyPolicy controls who may reply to a post
+type ReplyPolicy string
+
+const (
+	ReplyPolicyEveryone  ReplyPolicy = "everyone"
+	ReplyPolicyFollowing ReplyPolicy = "following"
+	ReplyPolicyMentioned ReplyPolicy = "mentioned"
+)
+
+// IsValid reports whether p is a known reply policy
+func (p ReplyPolicy) IsValid() bool {
+	switch p {
+	case ReplyPolicyEveryone, ReplyPolicyFollowing, ReplyPolicyMentioned:
+		return true
+	}
+	return false
+}
+
 type Post struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	Content       string             `json:"content"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt     pgtype.Timestamptz `json:"deleted_at"`
-	IsPrivate     bool               `json:"is_private"`
-	ReplyToPostID pgtype.UUID        `json:"reply_to_post_id"`
-	AllowReplies  bool               `json:"allow_replies"`
-	MediaUrls     []string           `json:"media_urls"`
-	LikeCount     int32              `json:"like_count"`
-	RepostCount   int32              `json:"repost_count"`
-	ReplyCount    int32              `json:"reply_count"`
-	HasLiked      bool               `json:"has_liked"`
-	HasBookmarked bool               `json:"has_bookmarked"`
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Content        string             `json:"content"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	IsPrivate      bool               `json:"is_private"`
+	ReplyToPostID  pgtype.UUID        `json:"reply_to_post_id"`
+	ConversationID pgtype.UUID        `json:"conversation_id"`
+	ReplyPolicy    ReplyPolicy        `json:"reply_policy"`
+	MediaUrls      []string           `json:"media_urls"`
+	LikeCount      int32              `json:"like_count"`
+	RepostCount    int32              `json:"repost_count"`
+	ReplyCount     int32              `json:"reply_count"`
+	HasLiked       bool               `json:"has_liked"`
+	HasBookmarked  bool               `json:"has_bookmarked"`
+	HasReposted    bool               `json:"has_reposted"`
+	QuotedPostID   pgtype.UUID        `json:"quoted_post_id"`
+	QuotedPost     *QuotedPost        `json:"quoted_post,omitempty"`
+	EditedAt       pgtype.Timestamptz `json:"edited_at"`
+	EditCount      int32              `json:"edit_count"`
 	// User information
 	Username    string      `json:"username"`
 	DisplayName pgtype.Text `json:"display_name"`
 	AvatarUrl   pgtype.Text `json:"avatar_url"`
+	// Set when the post appears in a timeline because someone reposted it
+	RepostedBy *RepostedBy `json:"reposted_by,omitempty"`
+	// Set when the post matched the viewer's filters and they chose to see
+	// filtered posts collapsed
+	Filtered *FilterResult `json:"filtered,omitempty"`
+}
+
+// RepostedBy identifies the user whose repost put a post in a timeline
+type RepostedBy struct {
+	ID          pgtype.UUID `json:"id"`
+	Username    string      `json:"username"`
+	DisplayName pgtype.Text `json:"display_name"`
+}
+
+// QuotedPost is the post embedded in a quote post. If the quoted post was
+// deleted or the viewer can't see it, only ID and Unavailable are set.
+type QuotedPost struct {
+	ID          pgtype.UUID        `json:"id"`
+	Unavailable bool               `json:"unavailable"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	Content     string             `json:"content"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	MediaUrls   []string           `json:"media_urls"`
+	Username    string             `json:"username"`
+	DisplayName pgtype.Text        `json:"display_name"`
+	AvatarUrl   pgtype.Text        `json:"avatar_url"`
+}
+
+// PostRevision is an earlier version of a post's content. CreatedAt is when
+// that version was written.
+type PostRevision struct {
+	ID        pgtype.UUID        `json:"id"`
+	PostID    pgtype.UUID        `json:"post_id"`
+	Content   string             `json:"content"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MaxWebhooks caps how many webhooks a user can register
+const MaxWebhooks = 10
+
+// WebhookEvent is an event webhooks can subscribe to
+type WebhookEvent string
+
+const (
+	WebhookEventPostCreated         WebhookEvent = "post.created"
+	WebhookEventPostDeleted         WebhookEvent = "post.deleted"
+	WebhookEventPostLiked           WebhookEvent = "post.liked"
+	WebhookEventUserFollowed        WebhookEvent = "user.followed"
+	WebhookEventNotificationCreated WebhookEvent = "notification.created"
+)
+
+// WebhookEvents lists every webhook event
+var WebhookEvents = []WebhookEvent{
+	WebhookEventPostCreated,
+	WebhookEventPostDeleted,
+	WebhookEventPostLiked,
+	WebhookEventUserFollowed,
+	WebhookEventNotificationCreated,
+}
+
+// IsValid reports whether e is a known webhook event
+func (e WebhookEvent) IsValid() bool {
+	for _, known := range WebhookEvents {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a URL that is sent a user's events. Secret signs the deliveries
+// and is only returned when the webhook is created.
+type Webhook struct {
+	ID        pgtype.UUID        `json:"id"`
+	URL       string             `json:"url"`
+	Events    []WebhookEvent     `json:"events"`
+	Active    bool               `json:"active"`
+	Secret    string             `json:"secret,omitempty"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook.
+// ResponseStatus and Error describe the latest attempt.
+type WebhookDelivery struct {
+	ID             pgtype.UUID           `json:"id"`
+	WebhookID      pgtype.UUID           `json:"webhook_id"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                 `json:"attempts"`
+	NextAttemptAt  pgtype.Timestamptz    `json:"next_attempt_at"`
+	LastAttemptAt  pgtype.Timestamptz    `json:"last_attempt_at"`
+	ResponseStatus pgtype.Int4           `json:"response_status"`
+	Error          pgtype.Text           `json:"error"`
+	CreatedAt      pgtype.Timestamptz    `json:"created_at"`
+}
@@ -0,0 +1,239 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// DefaultLimit is the page size used when the request doesn't specify one
+	DefaultLimit int32 = 20
+
+	// MaxLimit caps the page size to prevent abuse
+	MaxLimit int32 = 50
+)
+
+var (
+	// ErrInvalidCursor is returned when a cursor can't be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrInvalidLimit is returned when the limit isn't a positive integer
+	ErrInvalidLimit = errors.New("invalid limit")
+)
+
+// Direction tells which side of a cursor a page lies on
+type Direction byte
+
+const (
+	// Older pages continue past the cursor towards older items
+	Older Direction = 'o'
+
+	// Newer pages return items newer than the cursor
+	Newer Direction = 'n'
+)
+
+// Cursor is a position in a list ordered by (created_at, id) descending
+type Cursor struct {
+	CreatedAt time.Time
+	ID        [16]byte
+}
+
+// NewCursor builds a cursor from the sort key columns of a row
+func NewCursor(createdAt pgtype.Timestamptz, id pgtype.UUID) Cursor {
+	return Cursor{CreatedAt: createdAt.Time, ID: id.Bytes}
+}
+
+// Encode returns the opaque string form of the cursor for the given direction
+func (c Cursor) Encode(dir Direction) string {
+	raw := fmt.Sprintf("%c:%d:%s", dir, c.CreatedAt.UnixNano(), hex.EncodeToString(c.ID[:]))
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses an opaque cursor string
+func Decode(s string) (Cursor, Direction, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, 0, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || len(parts[0]) != 1 {
+		return Cursor{}, 0, ErrInvalidCursor
+	}
+
+	dir := Direction(parts[0][0])
+	if dir != Older && dir != Newer {
+		return Cursor{}, 0, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, 0, ErrInvalidCursor
+	}
+
+	id, err := hex.DecodeString(parts[2])
+	if err != nil || len(id) != 16 {
+		return Cursor{}, 0, ErrInvalidCursor
+	}
+
+	cursor := Cursor{CreatedAt: time.Unix(0, nanos).UTC()}
+	copy(cursor.ID[:], id)
+	return cursor, dir, nil
+}
+
+// Params holds the page size and keyset bounds for a list query.
+// A nil bound means the list is unbounded on that side.
+type Params struct {
+	Limit  int32
+	Before *Cursor
+	After  *Cursor
+}
+
+// FromRequest reads the limit and cursor query parameters.
+// Older cursors continue a list downwards; newer cursors return the items right
+// above the cursor, which is what clients use to catch up on new activity.
+func FromRequest(ctx echo.Context) (Params, error) {
+	params := Params{Limit: DefaultLimit}
+
+	if limitStr := ctx.QueryParam("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return Params{}, ErrInvalidLimit
+		}
+		if limit > int(MaxLimit) {
+			limit = int(MaxLimit)
+		}
+		params.Limit = int32(limit)
+	}
+
+	if cursorStr := ctx.QueryParam("cursor"); cursorStr != "" {
+		cursor, dir, err := Decode(cursorStr)
+		if err != nil {
+			return Params{}, err
+		}
+		if dir == Older {
+			params.Before = &cursor
+		} else {
+			params.After = &cursor
+		}
+	}
+
+	return params, nil
+}
+
+// QueryLimit is the number of rows to fetch. One extra row is requested so
+// NewPage can tell whether another page follows.
+func (p Params) QueryLimit() int32 {
+	return p.Limit + 1
+}
+
+// Ascending reports whether rows are fetched oldest first. Newer pages are, so
+// they hold the items right above the cursor rather than the newest ones;
+// NewPage puts them back in newest-first order.
+func (p Params) Ascending() bool {
+	return p.After != nil
+}
+
+// BeforeCreatedAt returns the upper created_at bound, or +infinity if unbounded
+func (p Params) BeforeCreatedAt() pgtype.Timestamptz {
+	if p.Before == nil {
+		return pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true}
+	}
+	return pgtype.Timestamptz{Time: p.Before.CreatedAt, Valid: true}
+}
+
+// BeforeID returns the upper id bound, or the largest UUID if unbounded
+func (p Params) BeforeID() pgtype.UUID {
+	if p.Before == nil {
+		var max [16]byte
+		for i := range max {
+			max[i] = 0xff
+		}
+		return pgtype.UUID{Bytes: max, Valid: true}
+	}
+	return pgtype.UUID{Bytes: p.Before.ID, Valid: true}
+}
+
+// AfterCreatedAt returns the lower created_at bound, or -infinity if unbounded
+func (p Params) AfterCreatedAt() pgtype.Timestamptz {
+	if p.After == nil {
+		return pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}
+	}
+	return pgtype.Timestamptz{Time: p.After.CreatedAt, Valid: true}
+}
+
+// AfterID returns the lower id bound, or the zero UUID if unbounded
+func (p Params) AfterID() pgtype.UUID {
+	if p.After == nil {
+		return pgtype.UUID{Valid: true}
+	}
+	return pgtype.UUID{Bytes: p.After.ID, Valid: true}
+}
+
+// Page is the response envelope for every list endpoint
+type Page[T any] struct {
+	Data       []T     `json:"data"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+// NewPage trims the look-ahead row from rows (fetched with QueryLimit, in the
+// order Ascending asks for) and builds the cursors for the neighbouring pages
+// using key to read each row's sort position. Page data is always newest first.
+func NewPage[T any](rows []T, params Params, key func(T) Cursor) *Page[T] {
+	hasMore := len(rows) > int(params.Limit)
+	if hasMore {
+		rows = rows[:params.Limit]
+	}
+	if params.Ascending() {
+		slices.Reverse(rows)
+	}
+
+	page := &Page[T]{Data: rows}
+	if page.Data == nil {
+		page.Data = []T{}
+	}
+
+	if len(rows) == 0 {
+		// Nothing on this page; let the client retry from the same position
+		if params.After != nil {
+			prev := params.After.Encode(Newer)
+			page.PrevCursor = &prev
+		}
+		return page
+	}
+
+	prev := key(rows[0]).Encode(Newer)
+	page.PrevCursor = &prev
+
+	// Below a newer page is what the client already has, so only older pages
+	// continue downwards
+	if hasMore && !params.Ascending() {
+		next := key(rows[len(rows)-1]).Encode(Older)
+		page.NextCursor = &next
+	}
+
+	return page
+}
+
+// Map converts the items of a page while keeping its cursors
+func Map[T, U any](page *Page[T], fn func(T) U) *Page[U] {
+	data := make([]U, len(page.Data))
+	for i, item := range page.Data {
+		data[i] = fn(item)
+	}
+	return &Page[U]{
+		Data:       data,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+}
@@ -0,0 +1,205 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestCursorEncodeDecode(t *testing.T) {
+	id := [16]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0xfe, 0xdc, 0xba, 0x98, 0x76, 0x54, 0x32, 0x10}
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+		dir    Direction
+	}{
+		{"older", Cursor{CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC), ID: id}, Older},
+		{"newer", Cursor{CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), ID: id}, Newer},
+		{"zero id", Cursor{CreatedAt: time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC)}, Older},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, dir, err := Decode(tt.cursor.Encode(tt.dir))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if dir != tt.dir {
+				t.Errorf("direction = %c, want %c", dir, tt.dir)
+			}
+			if !cursor.CreatedAt.Equal(tt.cursor.CreatedAt) {
+				t.Errorf("CreatedAt = %v, want %v", cursor.CreatedAt, tt.cursor.CreatedAt)
+			}
+			if cursor.ID != tt.cursor.ID {
+				t.Errorf("ID = %x, want %x", cursor.ID, tt.cursor.ID)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"empty payload", encode("")},
+		{"missing parts", encode("o:1700000000000000000")},
+		{"extra parts", encode("o:1:0123456789abcdef0123456789abcdef:x")},
+		{"unknown direction", encode("x:1700000000000000000:0123456789abcdef0123456789abcdef")},
+		{"long direction", encode("on:1700000000000000000:0123456789abcdef0123456789abcdef")},
+		{"bad timestamp", encode("o:yesterday:0123456789abcdef0123456789abcdef")},
+		{"bad id hex", encode("o:1700000000000000000:not-hex")},
+		{"short id", encode("o:1700000000000000000:0123456789abcdef")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Decode(tt.cursor); err != ErrInvalidCursor {
+				t.Errorf("Decode() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+type item struct {
+	n int
+}
+
+func itemCursor(i item) Cursor {
+	return Cursor{CreatedAt: time.Unix(int64(i.n), 0).UTC(), ID: [16]byte{byte(i.n)}}
+}
+
+func items(ns ...int) []item {
+	out := make([]item, len(ns))
+	for i, n := range ns {
+		out[i] = item{n}
+	}
+	return out
+}
+
+func TestNewPage(t *testing.T) {
+	cursor := itemCursor(item{5})
+	encode := func(n int, dir Direction) *string {
+		s := itemCursor(item{n}).Encode(dir)
+		return &s
+	}
+
+	tests := []struct {
+		name     string
+		rows     []item
+		params   Params
+		wantData []item
+		wantNext *string
+		wantPrev *string
+	}{
+		{
+			name:     "first page with more",
+			rows:     items(9, 8, 7, 6),
+			params:   Params{Limit: 3},
+			wantData: items(9, 8, 7),
+			wantNext: encode(7, Older),
+			wantPrev: encode(9, Newer),
+		},
+		{
+			name:     "last page",
+			rows:     items(9, 8),
+			params:   Params{Limit: 3},
+			wantData: items(9, 8),
+			wantPrev: encode(9, Newer),
+		},
+		{
+			name:     "exactly limit rows",
+			rows:     items(9, 8, 7),
+			params:   Params{Limit: 3},
+			wantData: items(9, 8, 7),
+			wantPrev: encode(9, Newer),
+		},
+		{
+			name:     "older page with more",
+			rows:     items(4, 3, 2, 1),
+			params:   Params{Limit: 3, Before: &cursor},
+			wantData: items(4, 3, 2),
+			wantNext: encode(2, Older),
+			wantPrev: encode(4, Newer),
+		},
+		{
+			name:     "newer page is reversed and trimmed from the far end",
+			rows:     items(6, 7, 8, 9),
+			params:   Params{Limit: 3, After: &cursor},
+			wantData: items(8, 7, 6),
+			wantPrev: encode(8, Newer),
+		},
+		{
+			name:     "newer page without more",
+			rows:     items(6, 7),
+			params:   Params{Limit: 3, After: &cursor},
+			wantData: items(7, 6),
+			wantPrev: encode(7, Newer),
+		},
+		{
+			name:     "empty first page",
+			params:   Params{Limit: 3},
+			wantData: []item{},
+		},
+		{
+			name:     "empty newer page keeps its position",
+			params:   Params{Limit: 3, After: &cursor},
+			wantData: []item{},
+			wantPrev: encode(5, Newer),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(tt.rows, tt.params, itemCursor)
+
+			if page.Data == nil {
+				t.Fatal("Data is nil, want an empty slice")
+			}
+			if len(page.Data) != len(tt.wantData) {
+				t.Fatalf("Data = %v, want %v", page.Data, tt.wantData)
+			}
+			for i := range page.Data {
+				if page.Data[i] != tt.wantData[i] {
+					t.Fatalf("Data = %v, want %v", page.Data, tt.wantData)
+				}
+			}
+			checkCursor(t, "NextCursor", page.NextCursor, tt.wantNext)
+			checkCursor(t, "PrevCursor", page.PrevCursor, tt.wantPrev)
+		})
+	}
+}
+
+func checkCursor(t *testing.T, name string, got, want *string) {
+	t.Helper()
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s = %v, want %v", name, got, want)
+	case *got != *want:
+		t.Errorf("%s = %q, want %q", name, *got, *want)
+	}
+}
+
+func TestMap(t *testing.T) {
+	next, prev := "next", "prev"
+	page := &Page[int]{Data: []int{1, 2, 3}, NextCursor: &next, PrevCursor: &prev}
+
+	mapped := Map(page, func(n int) int { return n * 10 })
+
+	want := []int{10, 20, 30}
+	for i := range want {
+		if mapped.Data[i] != want[i] {
+			t.Fatalf("Data = %v, want %v", mapped.Data, want)
+		}
+	}
+	if mapped.NextCursor != &next || mapped.PrevCursor != &prev {
+		t.Errorf("cursors weren't kept")
+	}
+}
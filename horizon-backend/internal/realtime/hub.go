@@ -0,0 +1,312 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"horizon-backend/internal/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// channel is the Postgres channel events travel on between servers
+	channel = "horizon_events"
+
+	// maxPayload keeps events under Postgres's 8000 byte NOTIFY limit
+	maxPayload = 7900
+
+	// bufferSize is how many events a subscriber can fall behind before it's
+	// dropped
+	bufferSize = 64
+
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Event types
+const (
+	EventNotification = "notification"
+	EventUnreadCount  = "unread_count"
+	EventFollow       = "follow"
+	EventPost         = "post"
+	EventPostCounts   = "post_counts"
+	EventPostDeleted  = "post_deleted"
+	EventMessage      = "message"
+)
+
+// Event is a message published to a topic. Data is kept small, usually just
+// ids, and subscribers load what they need.
+type Event struct {
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// UserTopic is the topic for events addressed to a single user
+func UserTopic(userID [16]byte) string {
+	return "user:" + uuid.UUID(userID).String()
+}
+
+// AuthorTopic is the topic for a user's new posts and reposts
+func AuthorTopic(userID [16]byte) string {
+	return "author:" + uuid.UUID(userID).String()
+}
+
+// PostTopic is the topic for changes to a post
+func PostTopic(postID [16]byte) string {
+	return "post:" + uuid.UUID(postID).String()
+}
+
+// ConversationTopic is the topic for new messages in a conversation
+func ConversationTopic(conversationID [16]byte) string {
+	return "dm:" + uuid.UUID(conversationID).String()
+}
+
+// Hub fans events out to subscribers. Events are published through Postgres
+// NOTIFY and received back through LISTEN, so subscribers on every server see
+// events published on any of them.
+type Hub struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+
+	mu     sync.Mutex
+	topics map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub creates a hub. Run must be called for subscribers to receive events.
+func NewHub(pool *pgxpool.Pool, queries *db.Queries) *Hub {
+	return &Hub{
+		pool:    pool,
+		queries: queries,
+		topics:  make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to the subscribers of a topic on every server
+func (h *Hub) Publish(ctx context.Context, topic, eventType string, data any) error {
+	event := Event{Topic: topic, Type: eventType}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode event data: %w", err)
+		}
+		event.Data = raw
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if len(payload) > maxPayload {
+		return fmt.Errorf("event payload too large: %d bytes", len(payload))
+	}
+
+	if err := h.queries.NotifyEvent(ctx, db.NotifyEventParams{
+		Channel: channel,
+		Payload: string(payload),
+	}); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	return nil
+}
+
+// Run listens for events until ctx is done, reconnecting when the connection
+// drops. Subscriptions are closed when it returns.
+func (h *Hub) Run(ctx context.Context) {
+	defer h.close()
+
+	delay := time.Second
+	for {
+		listening, err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if listening {
+			delay = time.Second
+		}
+
+		log.Printf("Realtime listener stopped: %v; reconnecting in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// listen dispatches notifications from a dedicated connection. listening
+// reports whether LISTEN succeeded before it stopped.
+func (h *Hub) listen(ctx context.Context) (listening bool, err error) {
+	conn, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// The connection is taken out of the pool for good; it's closed when
+	// listening stops
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+
+	if _, err := pgConn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return false, err
+	}
+
+	for {
+		notification, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Printf("Error decoding realtime event: %v", err)
+			continue
+		}
+		h.dispatch(event)
+	}
+}
+
+func (h *Hub) dispatch(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.topics[event.Topic] {
+		select {
+		case sub.events <- event:
+		default:
+			// Drop subscribers that can't keep up; clients reconnect and
+			// catch up from their last event
+			h.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe starts receiving the events of the given topics
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	sub := &Subscription{
+		hub:    h,
+		events: make(chan Event, bufferSize),
+		topics: make(map[string]struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		sub.closed = true
+		close(sub.events)
+		return sub
+	}
+
+	for _, topic := range topics {
+		h.addTopic(sub, topic)
+	}
+
+	return sub
+}
+
+func (h *Hub) addTopic(sub *Subscription, topic string) {
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = make(map[*Subscription]struct{})
+		h.topics[topic] = subs
+	}
+	subs[sub] = struct{}{}
+	sub.topics[topic] = struct{}{}
+}
+
+// unsubscribe removes a subscription from every topic and closes its
+// channel. h.mu must be held.
+func (h *Hub) unsubscribe(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+
+	for topic := range sub.topics {
+		delete(h.topics[topic], sub)
+		if len(h.topics[topic]) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+
+	sub.closed = true
+	close(sub.events)
+}
+
+func (h *Hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.topics {
+		for sub := range subs {
+			h.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscription receives the events of the topics it's subscribed to
+type Subscription struct {
+	hub    *Hub
+	events chan Event
+
+	// Guarded by hub.mu
+	topics map[string]struct{}
+	closed bool
+}
+
+// Events returns the channel events are delivered on. It's closed when the
+// subscription ends, including when the subscriber falls too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Add subscribes to more topics
+func (s *Subscription) Add(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	for _, topic := range topics {
+		s.hub.addTopic(s, topic)
+	}
+}
+
+// Remove unsubscribes from topics, leaving the subscription open
+func (s *Subscription) Remove(topics ...string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	for _, topic := range topics {
+		delete(s.hub.topics[topic], s)
+		if len(s.hub.topics[topic]) == 0 {
+			delete(s.hub.topics, topic)
+		}
+		delete(s.topics, topic)
+	}
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.unsubscribe(s)
+}
@@ -104,7 +104,8 @@ func (r *PostRepository) LikePost(ctx context.Context, postID, userID pgtype.UUI
 }
 
 func (r *PostRepository) GetPostById(ctx context.Context, postId pgtype.UUID) (*model.Post, error) {
-	dbPost, err := r.queries.GetPostByID(ctx, postId) 
+	viewerID, _ := ctx.Value("user_id").(pgtype.UUID)
+	dbPost, err := r.queries.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: viewerID})
 	
 	if err != nil {
 		return nil, fmt.Errorf("failed to get post with id: %w", err)
@@ -119,7 +120,7 @@ func (r *PostRepository) GetPostById(ctx context.Context, postId pgtype.UUID) (*
         DeletedAt:     dbPost.DeletedAt,
         IsPrivate:     dbPost.IsPrivate,
         ReplyToPostID: dbPost.ReplyToPostID,
-        AllowReplies:  dbPost.AllowReplies,
+        ReplyPolicy:   model.ReplyPolicy(dbPost.ReplyPolicy),
         MediaUrls:     dbPost.MediaUrls,
         LikeCount:     dbPost.LikeCount,
         RepostCount:   dbPost.RepostCount,
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"horizon-backend/internal/db"
+	"horizon-backend/internal/pagination"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BlockService handles blocks between accounts. The block itself is enforced
+// in the database: accounts on either side of a block can't see each other's
+// posts, follow, reply to, mention, like or message each other, and their
+// notifications about each other are hidden.
+type BlockService struct {
+	queries       *db.Queries
+	db            *pgxpool.Pool
+	followService *FollowService
+}
+
+func NewBlockService(queries *db.Queries, pool *pgxpool.Pool, followService *FollowService) *BlockService {
+	return &BlockService{
+		queries:       queries,
+		db:            pool,
+		followService: followService,
+	}
+}
+
+type BlockedUser struct {
+	ID          pgtype.UUID        `json:"id"`
+	Username    string             `json:"username"`
+	DisplayName pgtype.Text        `json:"display_name"`
+	AvatarURL   pgtype.Text        `json:"avatar_url"`
+	IsPrivate   bool               `json:"is_private"`
+	BlockedAt   pgtype.Timestamptz `json:"blocked_at"`
+}
+
+// BlockUser blocks an account and removes the follows and follow requests
+// between the two, in both directions. Blocking an account again does
+// nothing.
+func (s *BlockService) BlockUser(ctx context.Context, blockerID, blockedID pgtype.UUID) error {
+	if blockerID == blockedID {
+		return fmt.Errorf("cannot block yourself")
+	}
+
+	// Start a transaction so no follow slips in between the block and the
+	// cleanup
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	_, err = qtx.CreateBlock(ctx, db.CreateBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create block: %w", err)
+	}
+
+	removed, err := qtx.DeleteFollowsBetween(ctx, db.DeleteFollowsBetweenParams{
+		UserID:      blockerID,
+		OtherUserID: blockedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove follows: %w", err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, follow := range removed {
+		if follow.IsAccepted {
+			s.followService.publishFollow(ctx, follow.FollowerID, follow.FollowedID, false)
+		}
+	}
+
+	return nil
+}
+
+// UnblockUser lifts a block. Follows removed by the block aren't restored.
+func (s *BlockService) UnblockUser(ctx context.Context, blockerID, blockedID pgtype.UUID) error {
+	deleted, err := s.queries.DeleteBlock(ctx, db.DeleteBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete block: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("block not found")
+	}
+
+	return nil
+}
+
+// GetBlockedUsers gets the accounts a user has blocked, most recently blocked
+// first
+func (s *BlockService) GetBlockedUsers(ctx context.Context, userID pgtype.UUID, page pagination.Params) (*pagination.Page[BlockedUser], error) {
+	blocked, err := s.queries.GetBlockedUsers(ctx, db.GetBlockedUsersParams{
+		BlockerID:       userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked users: %w", err)
+	}
+
+	rows := pagination.NewPage(blocked, page, func(b db.GetBlockedUsersRow) pagination.Cursor {
+		return pagination.NewCursor(b.BlockedAt, b.ID)
+	})
+
+	return pagination.Map(rows, func(b db.GetBlockedUsersRow) BlockedUser {
+		return BlockedUser{
+			ID:          b.ID,
+			Username:    b.Username,
+			DisplayName: b.DisplayName,
+			AvatarURL:   b.AvatarUrl,
+			IsPrivate:   b.IsPrivate,
+			BlockedAt:   b.BlockedAt,
+		}
+	}), nil
+}
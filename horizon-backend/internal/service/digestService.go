@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	// Time zones are embedded so schedules work in images without zoneinfo
+	_ "time/tzdata"
+
+	"horizon-backend/internal/db"
+	"horizon-backend/internal/email"
+	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// digestBatchSize is how many due digests are claimed at a time
+	digestBatchSize = 50
+
+	// digestMaxItems is how many notification groups a digest lists
+	digestMaxItems = 10
+
+	// digestExcerptLength is how many characters of a post a digest quotes
+	digestExcerptLength = 140
+)
+
+// DigestService emails users digests of their unread notifications on the
+// schedule they choose
+type DigestService struct {
+	queries             *db.Queries
+	db                  *pgxpool.Pool
+	notificationService *NotificationService
+	mailer              *email.Mailer
+	publicURL           string
+	appURL              string
+}
+
+// NewDigestService creates a digest service. publicURL is where the API is
+// reached and appURL where the web app is, for links in the emails.
+func NewDigestService(queries *db.Queries, pool *pgxpool.Pool, notificationService *NotificationService, mailer *email.Mailer, publicURL, appURL string) *DigestService {
+	return &DigestService{
+		queries:             queries,
+		db:                  pool,
+		notificationService: notificationService,
+		mailer:              mailer,
+		publicURL:           strings.TrimRight(publicURL, "/"),
+		appURL:              strings.TrimRight(appURL, "/"),
+	}
+}
+
+// digestRecipient is a user a digest is sent to
+type digestRecipient struct {
+	settings         model.EmailDigestSettings
+	userID           pgtype.UUID
+	email            string
+	name             string
+	unsubscribeToken string
+}
+
+// GetSettings retrieves a user's digest schedule
+func (s *DigestService) GetSettings(ctx context.Context, userID [16]byte) (*model.EmailDigestSettings, error) {
+	dbSettings, err := s.queries.GetEmailDigestSettings(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			settings := model.DefaultEmailDigestSettings
+			return &settings, nil
+		}
+		return nil, fmt.Errorf("failed to get digest settings: %w", err)
+	}
+
+	return dbDigestSettingsToModel(dbSettings), nil
+}
+
+// UpdateSettings changes a user's digest schedule
+func (s *DigestService) UpdateSettings(ctx context.Context, userID [16]byte, settings model.EmailDigestSettings) (*model.EmailDigestSettings, error) {
+	if !settings.Frequency.IsValid() {
+		return nil, fmt.Errorf("invalid digest frequency")
+	}
+	if settings.Hour < 0 || settings.Hour > 23 {
+		return nil, fmt.Errorf("invalid digest hour")
+	}
+	if settings.Weekday < 0 || settings.Weekday > 6 {
+		return nil, fmt.Errorf("invalid digest weekday")
+	}
+	if _, err := time.LoadLocation(settings.Timezone); err != nil || settings.Timezone == "" {
+		return nil, fmt.Errorf("invalid timezone")
+	}
+
+	var nextSendAt pgtype.Timestamptz
+	if next, ok := settings.NextDigestAt(time.Now()); ok {
+		nextSendAt = pgtype.Timestamptz{Time: next, Valid: true}
+	}
+
+	// Only used if this is the user's first schedule
+	token, err := newUnsubscribeToken()
+	if err != nil {
+		return nil, err
+	}
+
+	dbSettings, err := s.queries.UpsertEmailDigestSettings(ctx, db.UpsertEmailDigestSettingsParams{
+		UserID:           pgtype.UUID{Bytes: userID, Valid: true},
+		Frequency:        db.DigestFrequency(settings.Frequency),
+		Hour:             settings.Hour,
+		Weekday:          settings.Weekday,
+		Timezone:         settings.Timezone,
+		NextSendAt:       nextSendAt,
+		UnsubscribeToken: token,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update digest settings: %w", err)
+	}
+
+	return dbDigestSettingsToModel(dbSettings), nil
+}
+
+// Unsubscribe turns off the digests of the user an unsubscribe token belongs
+// to
+func (s *DigestService) Unsubscribe(ctx context.Context, token string) error {
+	if token == "" {
+		return fmt.Errorf("invalid unsubscribe token")
+	}
+
+	rows, err := s.queries.UnsubscribeEmailDigest(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("invalid unsubscribe token")
+	}
+
+	return nil
+}
+
+// SendTestDigest emails a user their digest right away, whatever their
+// schedule, covering the last day or week
+func (s *DigestService) SendTestDigest(ctx context.Context, userID [16]byte) error {
+	if !s.mailer.Enabled() {
+		return fmt.Errorf("email is not configured")
+	}
+
+	id := pgtype.UUID{Bytes: userID, Valid: true}
+	row, err := s.queries.GetEmailDigestRecipient(ctx, id)
+	if err == pgx.ErrNoRows {
+		// Save the default schedule so the user has an unsubscribe token
+		if _, err := s.UpdateSettings(ctx, userID, model.DefaultEmailDigestSettings); err != nil {
+			return err
+		}
+		row, err = s.queries.GetEmailDigestRecipient(ctx, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get digest recipient: %w", err)
+	}
+
+	// The rows have the same columns
+	recipient := dbRowToDigestRecipient(db.GetDueEmailDigestsRow(row))
+	since := time.Now().Add(-recipient.settings.Frequency.Period())
+	_, err = s.send(ctx, recipient, since, true)
+	return err
+}
+
+// Run sends due digests every interval until ctx is done
+func (s *DigestService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SendDueDigests(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error sending email digests: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDueDigests sends every digest that is due and returns how many were
+// sent. Each digest is moved on to its next send time before it's sent, so
+// servers running this at the same time never send one twice; a digest that
+// fails to send is skipped rather than retried.
+func (s *DigestService) SendDueDigests(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		recipients, err := s.claimDueDigests(ctx)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, recipient := range recipients {
+			since := time.Now().Add(-recipient.settings.Frequency.Period())
+			if recipient.settings.LastSentAt.Valid {
+				since = recipient.settings.LastSentAt.Time
+			}
+
+			ok, err := s.send(ctx, recipient, since, false)
+			if err != nil {
+				log.Printf("Error sending email digest to user %x: %v", recipient.userID.Bytes, err)
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+
+		if len(recipients) < digestBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// claimDueDigests locks a batch of due digests and moves them on to their
+// next send time
+func (s *DigestService) claimDueDigests(ctx context.Context) ([]digestRecipient, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	now := time.Now()
+	rows, err := qtx.GetDueEmailDigests(ctx, db.GetDueEmailDigestsParams{
+		DueAt:      pgtype.Timestamptz{Time: now, Valid: true},
+		BatchLimit: digestBatchSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due digests: %w", err)
+	}
+
+	recipients := make([]digestRecipient, 0, len(rows))
+	for _, row := range rows {
+		recipient := dbRowToDigestRecipient(row)
+
+		var nextSendAt pgtype.Timestamptz
+		if next, ok := recipient.settings.NextDigestAt(now); ok {
+			nextSendAt = pgtype.Timestamptz{Time: next, Valid: true}
+		}
+
+		err := qtx.MarkEmailDigestSent(ctx, db.MarkEmailDigestSentParams{
+			UserID:     row.UserID,
+			SentAt:     pgtype.Timestamptz{Time: now, Valid: true},
+			NextSendAt: nextSendAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update digest schedule: %w", err)
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// send emails a recipient the unread notifications that were active since a
+// time. Nothing is sent when there are none unless always is set; sent
+// reports whether an email went out.
+func (s *DigestService) send(ctx context.Context, recipient digestRecipient, since time.Time, always bool) (sent bool, err error) {
+	groups, err := s.notificationService.GetNotifications(ctx, recipient.userID.Bytes, pagination.Params{
+		Limit: pagination.MaxLimit,
+		After: &pagination.Cursor{CreatedAt: since},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	var items []email.DigestItem
+	for _, group := range groups.Data {
+		if group.Read || len(items) == digestMaxItems {
+			continue
+		}
+		items = append(items, email.DigestItem{
+			Summary: digestSummary(group),
+			Excerpt: excerpt(group.PostContent.String, digestExcerptLength),
+		})
+	}
+	if len(items) == 0 && !always {
+		return false, nil
+	}
+
+	unread, err := s.notificationService.GetUnreadCount(ctx, recipient.userID.Bytes)
+	if err != nil {
+		return false, err
+	}
+	more := unread - int64(len(items))
+	if more < 0 {
+		more = 0
+	}
+
+	period := string(recipient.settings.Frequency)
+	if recipient.settings.Frequency == model.DigestFrequencyOff {
+		period = string(model.DigestFrequencyDaily)
+	}
+
+	msg, err := email.DigestMessage(recipient.email, email.Digest{
+		Name:             recipient.name,
+		Period:           period,
+		Items:            items,
+		More:             more,
+		NotificationsURL: s.appURL + "/notifications",
+		UnsubscribeURL:   s.publicURL + "/api/email/unsubscribe?token=" + url.QueryEscape(recipient.unsubscribeToken),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.mailer.Send(msg); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// digestSummary describes a notification group in a sentence, e.g. "alice
+// and 2 others liked your post"
+func digestSummary(group *model.NotificationGroup) string {
+	var action string
+	switch group.Type {
+	case model.NotificationTypeLike:
+		action = "liked your post"
+	case model.NotificationTypeRepost:
+		action = "reposted your post"
+	case model.NotificationTypeReply:
+		action = "replied to your post"
+	case model.NotificationTypeThreadReply:
+		action = "replied in your thread"
+	case model.NotificationTypeQuote:
+		action = "quoted your post"
+	case model.NotificationTypeMention:
+		action = "mentioned you"
+	case model.NotificationTypeFollow:
+		action = "followed you"
+	case model.NotificationTypeFollowRequest:
+		action = "asked to follow you"
+	case model.NotificationTypeFollowAccepted:
+		action = "accepted your follow request"
+	default:
+		action = "sent you a notification"
+	}
+
+	actor := "Someone"
+	if len(group.Actors) > 0 {
+		actor = nameOrUsername(group.Actors[0].DisplayName, group.Actors[0].Username)
+	}
+
+	switch {
+	case group.ActorCount == 2 && len(group.Actors) > 1:
+		actor += " and " + nameOrUsername(group.Actors[1].DisplayName, group.Actors[1].Username)
+	case group.ActorCount == 2:
+		actor += " and 1 other"
+	case group.ActorCount > 2:
+		actor += fmt.Sprintf(" and %d others", group.ActorCount-1)
+	}
+
+	return actor + " " + action
+}
+
+// nameOrUsername is how a user is named in text such as emails
+func nameOrUsername(name pgtype.Text, username string) string {
+	if name.Valid && name.String != "" {
+		return name.String
+	}
+	return "@" + username
+}
+
+// excerpt shortens text to at most n characters
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// newUnsubscribeToken generates a random token for unsubscribe links
+func newUnsubscribeToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate unsubscribe token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func dbRowToDigestRecipient(row db.GetDueEmailDigestsRow) digestRecipient {
+	return digestRecipient{
+		settings: model.EmailDigestSettings{
+			Frequency:  model.DigestFrequency(row.Frequency),
+			Hour:       row.Hour,
+			Weekday:    row.Weekday,
+			Timezone:   row.Timezone,
+			LastSentAt: row.LastSentAt,
+			NextSendAt: row.NextSendAt,
+		},
+		userID:           row.UserID,
+		email:            row.Email,
+		name:             nameOrUsername(row.DisplayName, row.Username),
+		unsubscribeToken: row.UnsubscribeToken,
+	}
+}
+
+func dbDigestSettingsToModel(s db.EmailDigestSetting) *model.EmailDigestSettings {
+	return &model.EmailDigestSettings{
+		Frequency:  model.DigestFrequency(s.Frequency),
+		Hour:       s.Hour,
+		Weekday:    s.Weekday,
+		Timezone:   s.Timezone,
+		LastSentAt: s.LastSentAt,
+		NextSendAt: s.NextSendAt,
+	}
+}
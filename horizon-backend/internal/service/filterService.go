@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"horizon-backend/internal/db"
+	"horizon-backend/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// FilterService manages users' muted words and phrases. Filters are matched
+// in Go rather than in the queries so regular expressions use RE2, which runs
+// in linear time whatever the pattern.
+type FilterService struct {
+	queries *db.Queries
+}
+
+func NewFilterService(queries *db.Queries) *FilterService {
+	return &FilterService{
+		queries: queries,
+	}
+}
+
+// CreateFilter adds a filter for a user
+func (s *FilterService) CreateFilter(ctx context.Context, userID [16]byte, filter model.Filter) (*model.Filter, error) {
+	if err := validateFilter(&filter, true); err != nil {
+		return nil, err
+	}
+
+	user := pgtype.UUID{Bytes: userID, Valid: true}
+	count, err := s.queries.CountFilters(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count filters: %w", err)
+	}
+	if count >= model.MaxFilters {
+		return nil, fmt.Errorf("too many filters")
+	}
+
+	dbFilter, err := s.queries.CreateFilter(ctx, db.CreateFilterParams{
+		UserID:    user,
+		Phrase:    filter.Phrase,
+		IsRegex:   filter.IsRegex,
+		WholeWord: filter.WholeWord,
+		Scopes:    filterScopeStrings(filter.Scopes),
+		ExpiresAt: filter.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create filter: %w", err)
+	}
+
+	return dbFilterToModel(dbFilter), nil
+}
+
+// GetFilters retrieves all of a user's filters, newest first, including
+// expired ones
+func (s *FilterService) GetFilters(ctx context.Context, userID [16]byte) ([]*model.Filter, error) {
+	dbFilters, err := s.queries.GetFilters(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filters: %w", err)
+	}
+
+	filters := make([]*model.Filter, len(dbFilters))
+	for i, f := range dbFilters {
+		filters[i] = dbFilterToModel(f)
+	}
+	return filters, nil
+}
+
+// GetFilter retrieves one of a user's filters
+func (s *FilterService) GetFilter(ctx context.Context, userID, filterID [16]byte) (*model.Filter, error) {
+	dbFilter, err := s.queries.GetFilter(ctx, db.GetFilterParams{
+		ID:     pgtype.UUID{Bytes: filterID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("filter not found")
+		}
+		return nil, fmt.Errorf("failed to get filter: %w", err)
+	}
+
+	return dbFilterToModel(dbFilter), nil
+}
+
+// UpdateFilter replaces a user's filter. An expiry that isn't changed may be
+// in the past, so expired filters can be edited without being revived.
+func (s *FilterService) UpdateFilter(ctx context.Context, userID, filterID [16]byte, filter model.Filter) (*model.Filter, error) {
+	current, err := s.GetFilter(ctx, userID, filterID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiryChanged := filter.ExpiresAt.Valid != current.ExpiresAt.Valid || !filter.ExpiresAt.Time.Equal(current.ExpiresAt.Time)
+	if err := validateFilter(&filter, expiryChanged); err != nil {
+		return nil, err
+	}
+
+	dbFilter, err := s.queries.UpdateFilter(ctx, db.UpdateFilterParams{
+		Phrase:    filter.Phrase,
+		IsRegex:   filter.IsRegex,
+		WholeWord: filter.WholeWord,
+		Scopes:    filterScopeStrings(filter.Scopes),
+		ExpiresAt: filter.ExpiresAt,
+		ID:        pgtype.UUID{Bytes: filterID, Valid: true},
+		UserID:    pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("filter not found")
+		}
+		return nil, fmt.Errorf("failed to update filter: %w", err)
+	}
+
+	return dbFilterToModel(dbFilter), nil
+}
+
+// DeleteFilter removes a user's filter
+func (s *FilterService) DeleteFilter(ctx context.Context, userID, filterID [16]byte) error {
+	rows, err := s.queries.DeleteFilter(ctx, db.DeleteFilterParams{
+		ID:     pgtype.UUID{Bytes: filterID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete filter: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("filter not found")
+	}
+	return nil
+}
+
+// GetSettings retrieves how a user's filters treat what they match, falling
+// back to the defaults
+func (s *FilterService) GetSettings(ctx context.Context, userID [16]byte) (model.FilterSettings, error) {
+	dbSettings, err := s.queries.GetFilterSettings(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.DefaultFilterSettings, nil
+		}
+		return model.FilterSettings{}, fmt.Errorf("failed to get filter settings: %w", err)
+	}
+
+	return model.FilterSettings{Action: model.FilterAction(dbSettings.Action)}, nil
+}
+
+// UpdateSettings changes how a user's filters treat what they match
+func (s *FilterService) UpdateSettings(ctx context.Context, userID [16]byte, settings model.FilterSettings) (model.FilterSettings, error) {
+	if !settings.Action.IsValid() {
+		return model.FilterSettings{}, fmt.Errorf("invalid filter action")
+	}
+
+	dbSettings, err := s.queries.UpsertFilterSettings(ctx, db.UpsertFilterSettingsParams{
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		Action: db.FilterAction(settings.Action),
+	})
+	if err != nil {
+		return model.FilterSettings{}, fmt.Errorf("failed to update filter settings: %w", err)
+	}
+
+	return model.FilterSettings{Action: model.FilterAction(dbSettings.Action)}, nil
+}
+
+// FilterMatcher checks content against the filters a user has in one scope.
+// A nil FilterMatcher matches nothing.
+type FilterMatcher struct {
+	action  model.FilterAction
+	filters []compiledFilter
+}
+
+type compiledFilter struct {
+	id      pgtype.UUID
+	phrase  string
+	pattern *regexp.Regexp
+}
+
+// Matcher loads a user's active filters for scope. It returns nil when the
+// user has none.
+func (s *FilterService) Matcher(ctx context.Context, userID [16]byte, scope model.FilterScope) (*FilterMatcher, error) {
+	dbFilters, err := s.queries.GetActiveFilters(ctx, db.GetActiveFiltersParams{
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		Scope:  string(scope),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filters: %w", err)
+	}
+	if len(dbFilters) == 0 {
+		return nil, nil
+	}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := &FilterMatcher{action: settings.Action}
+	for _, f := range dbFilters {
+		pattern, err := compileFilter(f.Phrase, f.IsRegex, f.WholeWord)
+		if err != nil {
+			// Filters are checked when saved, so this is never expected
+			log.Printf("Error compiling filter %x: %v", f.ID.Bytes, err)
+			continue
+		}
+		matcher.filters = append(matcher.filters, compiledFilter{id: f.ID, phrase: f.Phrase, pattern: pattern})
+	}
+	return matcher, nil
+}
+
+// Check matches content against the filters. keep is false when content
+// matched and the user hides filtered content; when they have it shown
+// collapsed, result lists the filters it matched.
+func (m *FilterMatcher) Check(content string) (result *model.FilterResult, keep bool) {
+	if m == nil {
+		return nil, true
+	}
+
+	for _, f := range m.filters {
+		if !f.pattern.MatchString(content) {
+			continue
+		}
+		if m.action == model.FilterActionHide {
+			return nil, false
+		}
+		if result == nil {
+			result = &model.FilterResult{}
+		}
+		result.FilterIDs = append(result.FilterIDs, f.id)
+		result.Phrases = append(result.Phrases, f.phrase)
+	}
+	return result, true
+}
+
+// compileFilter turns a filter into a case-insensitive pattern
+func compileFilter(phrase string, isRegex, wholeWord bool) (*regexp.Regexp, error) {
+	pattern := phrase
+	if !isRegex {
+		pattern = regexp.QuoteMeta(phrase)
+		if wholeWord {
+			// RE2 has no lookbehind and its \b only knows ASCII, so match the
+			// neighbouring character instead. Ends of the phrase that aren't
+			// word characters need no boundary.
+			first, _ := utf8.DecodeRuneInString(phrase)
+			last, _ := utf8.DecodeLastRuneInString(phrase)
+			if isWordRune(first) {
+				pattern = `(?:^|[^\pL\pN_])` + pattern
+			}
+			if isWordRune(last) {
+				pattern += `(?:$|[^\pL\pN_])`
+			}
+		}
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// validateFilter normalizes a filter and checks it. The expiry is only
+// checked when checkExpiry is set.
+func validateFilter(filter *model.Filter, checkExpiry bool) error {
+	filter.Phrase = strings.TrimSpace(filter.Phrase)
+	if filter.Phrase == "" {
+		return fmt.Errorf("phrase is required")
+	}
+	if utf8.RuneCountInString(filter.Phrase) > model.MaxFilterPhraseLength {
+		return fmt.Errorf("phrase is too long")
+	}
+
+	pattern, err := compileFilter(filter.Phrase, filter.IsRegex, filter.WholeWord)
+	if err != nil || pattern.MatchString("") {
+		// A pattern matching empty text would filter everything
+		return fmt.Errorf("invalid regular expression")
+	}
+
+	if len(filter.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range filter.Scopes {
+		if !scope.IsValid() {
+			return fmt.Errorf("invalid filter scope: %s", scope)
+		}
+	}
+
+	if checkExpiry && filter.ExpiresAt.Valid && !filter.ExpiresAt.Time.After(time.Now()) {
+		return fmt.Errorf("filter expiry must be in the future")
+	}
+
+	return nil
+}
+
+func filterScopeStrings(scopes []model.FilterScope) []string {
+	seen := make(map[model.FilterScope]bool, len(scopes))
+	out := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, string(scope))
+		}
+	}
+	return out
+}
+
+func dbFilterToModel(f db.Filter) *model.Filter {
+	scopes := make([]model.FilterScope, len(f.Scopes))
+	for i, scope := range f.Scopes {
+		scopes[i] = model.FilterScope(scope)
+	}
+
+	return &model.Filter{
+		ID:        f.ID,
+		Phrase:    f.Phrase,
+		IsRegex:   f.IsRegex,
+		WholeWord: f.WholeWord,
+		Scopes:    scopes,
+		ExpiresAt: f.ExpiresAt,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"horizon-backend/internal/model"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCompileFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		phrase    string
+		isRegex   bool
+		wholeWord bool
+		content   string
+		want      bool
+	}{
+		{"plain substring", "cat", false, false, "concatenate", true},
+		{"plain is case-insensitive", "cat", false, false, "CAT", true},
+		{"plain quotes metacharacters", "c++", false, false, "I write c++", true},
+		{"plain metacharacters are literal", "c++", false, false, "cc", false},
+		{"whole word alone", "cat", false, true, "cat", true},
+		{"whole word in a sentence", "cat", false, true, "the cat sat", true},
+		{"whole word before punctuation", "cat", false, true, "my cat!", true},
+		{"whole word is case-insensitive", "cat", false, true, "Cat videos", true},
+		{"whole word inside a word", "cat", false, true, "concatenate", false},
+		{"whole word prefix of a word", "cat", false, true, "cats", false},
+		{"whole word before underscore", "cat", false, true, "cat_pics", false},
+		{"whole word non-latin", "кот", false, true, "мой кот.", true},
+		{"whole word non-latin inside a word", "кот", false, true, "котик", false},
+		{"whole word phrase", "bad news", false, true, "some bad news today", true},
+		{"whole word symbol edge needs no boundary", "#tag", false, true, "x#tag", true},
+		{"whole word symbol edge still bounded by word end", "#tag", false, true, "#tags", false},
+		{"regex", "colou?r", true, false, "What a COLOR", true},
+		{"regex no match", "colou?r", true, false, "colr", false},
+		{"regex ignores whole word", "cat", true, true, "concatenate", true},
+		{"regex anchors", "^spoiler", true, false, "no spoiler here", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := compileFilter(tt.phrase, tt.isRegex, tt.wholeWord)
+			if err != nil {
+				t.Fatalf("compileFilter() error = %v", err)
+			}
+			if got := pattern.MatchString(tt.content); got != tt.want {
+				t.Errorf("%q matching %q = %v, want %v", pattern, tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileFilterInvalidRegex(t *testing.T) {
+	if _, err := compileFilter("(unclosed", true, false); err == nil {
+		t.Error("compileFilter() error = nil, want an error")
+	}
+}
+
+func TestFilterMatcherCheck(t *testing.T) {
+	id := func(b byte) pgtype.UUID {
+		return pgtype.UUID{Bytes: [16]byte{b}, Valid: true}
+	}
+	matcher := func(action model.FilterAction) *FilterMatcher {
+		m := &FilterMatcher{action: action}
+		for i, f := range []struct {
+			phrase    string
+			isRegex   bool
+			wholeWord bool
+		}{
+			{"spoiler", false, true},
+			{"fin(al|ale)", true, false},
+		} {
+			pattern, err := compileFilter(f.phrase, f.isRegex, f.wholeWord)
+			if err != nil {
+				t.Fatalf("compileFilter(%q) error = %v", f.phrase, err)
+			}
+			m.filters = append(m.filters, compiledFilter{id: id(byte(i + 1)), phrase: f.phrase, pattern: pattern})
+		}
+		return m
+	}
+
+	tests := []struct {
+		name        string
+		matcher     *FilterMatcher
+		content     string
+		wantKeep    bool
+		wantPhrases []string
+		wantIDs     []pgtype.UUID
+	}{
+		{"nil matcher", nil, "spoiler", true, nil, nil},
+		{"hide without match", matcher(model.FilterActionHide), "nothing to see", true, nil, nil},
+		{"hide with match", matcher(model.FilterActionHide), "Spoiler ahead", false, nil, nil},
+		{"warn without match", matcher(model.FilterActionWarn), "spoilers", true, nil, nil},
+		{"warn with one match", matcher(model.FilterActionWarn), "the finale", true, []string{"fin(al|ale)"}, []pgtype.UUID{id(2)}},
+		{
+			name:        "warn lists every match",
+			matcher:     matcher(model.FilterActionWarn),
+			content:     "spoiler: the final score",
+			wantKeep:    true,
+			wantPhrases: []string{"spoiler", "fin(al|ale)"},
+			wantIDs:     []pgtype.UUID{id(1), id(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, keep := tt.matcher.Check(tt.content)
+			if keep != tt.wantKeep {
+				t.Errorf("keep = %v, want %v", keep, tt.wantKeep)
+			}
+			if tt.wantPhrases == nil {
+				if result != nil {
+					t.Errorf("result = %+v, want nil", result)
+				}
+				return
+			}
+			if result == nil {
+				t.Fatalf("result = nil, want phrases %q", tt.wantPhrases)
+			}
+			if !slices.Equal(result.Phrases, tt.wantPhrases) {
+				t.Errorf("Phrases = %q, want %q", result.Phrases, tt.wantPhrases)
+			}
+			if !slices.Equal(result.FilterIDs, tt.wantIDs) {
+				t.Errorf("FilterIDs = %v, want %v", result.FilterIDs, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"horizon-backend/internal/db"
 	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/realtime"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -16,15 +18,26 @@ import (
 type FollowService struct {
 	queries             *db.Queries
 	notificationService *NotificationService
+	hub                 *realtime.Hub
+	webhooks            *WebhookService
 }
 
-func NewFollowService(queries *db.Queries, notificationService *NotificationService) *FollowService {
+func NewFollowService(queries *db.Queries, notificationService *NotificationService, hub *realtime.Hub, webhooks *WebhookService) *FollowService {
 	return &FollowService{
 		queries:             queries,
 		notificationService: notificationService,
+		hub:                 hub,
+		webhooks:            webhooks,
 	}
 }
 
+// FollowEvent is the data of the realtime event telling a follower that an
+// account's posts started or stopped appearing in their home timeline
+type FollowEvent struct {
+	UserID    pgtype.UUID `json:"user_id"`
+	Following bool        `json:"following"`
+}
+
 type FollowUserResponse struct {
 	IsAccepted bool `json:"is_accepted"`
 }
@@ -40,22 +53,39 @@ func (s *FollowService) FollowUser(ctx context.Context, followerID, followedID [
 	follower := pgtype.UUID{Bytes: followerID, Valid: true}
 	followed := pgtype.UUID{Bytes: followedID, Valid: true}
 
-	// Create follow relationship
+	// Create follow relationship; nothing is created across a block
 	follow, err := s.queries.CreateFollow(ctx, db.CreateFollowParams{
 		FollowerID: follower,
 		FollowedID: followed,
 	})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("cannot follow this user")
+		}
 		return nil, fmt.Errorf("error creating follow: %w", err)
 	}
 
-	// Create notification for followed user
-	_, err = s.notificationService.CreateNotification(ctx, followedID, followerID, nil, nil, model.NotificationTypeFollow)
+	// Create notification for followed user. Private accounts are asked to
+	// accept the follow first.
+	notificationType := model.NotificationTypeFollow
+	if !follow.IsAccepted {
+		notificationType = model.NotificationTypeFollowRequest
+	}
+	_, err = s.notificationService.CreateNotification(ctx, followedID, followerID, nil, nil, notificationType)
 	if err != nil {
 		// Log error but don't fail the follow operation
 		log.Printf("Error creating follow notification: %v", err)
 	}
 
+	if follow.IsAccepted {
+		s.publishFollow(ctx, follower, followed, true)
+	}
+	s.webhooks.Emit(ctx, followedID, model.WebhookEventUserFollowed, UserFollowedData{
+		FollowerID: follower,
+		FollowedID: followed,
+		IsAccepted: follow.IsAccepted,
+	})
+
 	return &FollowUserResponse{
 		IsAccepted: follow.IsAccepted,
 	}, nil
@@ -70,12 +100,27 @@ func (s *FollowService) UnfollowUser(ctx context.Context, followerID, followedID
 	if err != nil {
 		return fmt.Errorf("error deleting follow: %w", err)
 	}
+
+	s.publishFollow(ctx, followerID, followedID, false)
 	return nil
 }
 
+// FollowState is where a user stands with following another
+type FollowState string
+
+const (
+	FollowStateNone      FollowState = "none"
+	FollowStatePending   FollowState = "pending"
+	FollowStateFollowing FollowState = "following"
+)
+
+// FollowStatus describes a user's follow of another. IsFollowing and
+// IsAccepted are only set once the follow is accepted; a request waiting on
+// a private account has the pending state.
 type FollowStatus struct {
-	IsFollowing bool `json:"is_following"`
-	IsAccepted  bool `json:"is_accepted"`
+	State       FollowState `json:"state"`
+	IsFollowing bool        `json:"is_following"`
+	IsAccepted  bool        `json:"is_accepted"`
 }
 
 // GetFollowStatus checks if a user is following another user
@@ -87,30 +132,29 @@ func (s *FollowService) GetFollowStatus(ctx context.Context, followerID, followe
 
 	// Check if users are the same
 	if followerID == followedID {
-		return &FollowStatus{
-			IsFollowing: false,
-			IsAccepted:  false,
-		}, nil
+		return &FollowStatus{State: FollowStateNone}, nil
 	}
 
-	isFollowing, err := s.queries.GetFollowStatus(ctx, db.GetFollowStatusParams{
+	isAccepted, err := s.queries.GetFollowStatus(ctx, db.GetFollowStatusParams{
 		FollowerID: followerID,
 		FollowedID: followedID,
 	})
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			// Not following is a valid state
-			return &FollowStatus{
-				IsFollowing: false,
-				IsAccepted:  false,
-			}, nil
+			return &FollowStatus{State: FollowStateNone}, nil
 		}
 		return nil, fmt.Errorf("database error while getting follow status: %w", err)
 	}
 
+	if !isAccepted {
+		return &FollowStatus{State: FollowStatePending}, nil
+	}
+
 	return &FollowStatus{
-		IsFollowing: isFollowing,
-		IsAccepted:  isFollowing, // If following is true, it means the follow request was accepted
+		State:       FollowStateFollowing,
+		IsFollowing: true,
+		IsAccepted:  true,
 	}, nil
 }
 
@@ -124,20 +168,51 @@ type UserFollow struct {
 	FollowedAt  pgtype.Timestamptz `json:"followed_at,omitempty"`
 }
 
+// checkCanViewUser enforces the visibility policy for a user's follow lists:
+// a private account's lists are only visible to the account and its accepted
+// followers
+func (s *FollowService) checkCanViewUser(ctx context.Context, viewerID, userID pgtype.UUID) error {
+	canView, err := s.queries.CanViewUser(ctx, db.CanViewUserParams{
+		ViewerID: viewerID,
+		UserID:   userID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("user not found")
+		}
+		return fmt.Errorf("error checking account visibility: %w", err)
+	}
+	if !canView {
+		return fmt.Errorf("account is private")
+	}
+	return nil
+}
+
 // GetFollowers gets a list of users who follow the specified user
-func (s *FollowService) GetFollowers(ctx context.Context, userID pgtype.UUID, limit, offset int32) ([]UserFollow, error) {
+func (s *FollowService) GetFollowers(ctx context.Context, viewerID, userID pgtype.UUID, page pagination.Params) (*pagination.Page[UserFollow], error) {
+	if err := s.checkCanViewUser(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+
 	followers, err := s.queries.GetFollowers(ctx, db.GetFollowersParams{
-		FollowedID: userID,
-		Limit:      limit,
-		Offset:     offset,
+		FollowedID:      userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting followers: %w", err)
 	}
 
-	var userFollows []UserFollow
-	for _, f := range followers {
-		userFollows = append(userFollows, UserFollow{
+	rows := pagination.NewPage(followers, page, func(f db.GetFollowersRow) pagination.Cursor {
+		return pagination.NewCursor(f.FollowedAt, f.ID)
+	})
+
+	return pagination.Map(rows, func(f db.GetFollowersRow) UserFollow {
+		return UserFollow{
 			ID:          f.ID,
 			Username:    f.Username,
 			DisplayName: f.DisplayName,
@@ -145,73 +220,155 @@ func (s *FollowService) GetFollowers(ctx context.Context, userID pgtype.UUID, li
 			IsPrivate:   f.IsPrivate,
 			CreatedAt:   f.CreatedAt,
 			FollowedAt:  f.FollowedAt,
-		})
-	}
-
-	return userFollows, nil
+		}
+	}), nil
 }
 
 // GetFollowing gets a list of users that the specified user follows
-func (s *FollowService) GetFollowing(ctx context.Context, userID pgtype.UUID, limit, offset int32) ([]UserFollow, error) {
+func (s *FollowService) GetFollowing(ctx context.Context, viewerID, userID pgtype.UUID, page pagination.Params) (*pagination.Page[UserFollow], error) {
+	if err := s.checkCanViewUser(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+
 	following, err := s.queries.GetFollowing(ctx, db.GetFollowingParams{
-		FollowerID: userID,
-		Limit:      limit,
-		Offset:     offset,
+		FollowerID:      userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting following: %w", err)
 	}
 
-	var userFollows []UserFollow
-	for _, f := range following {
-		userFollows = append(userFollows, UserFollow{
+	rows := pagination.NewPage(following, page, func(f db.GetFollowingRow) pagination.Cursor {
+		return pagination.NewCursor(f.FollowedAt, f.ID)
+	})
+
+	return pagination.Map(rows, func(f db.GetFollowingRow) UserFollow {
+		return UserFollow{
 			ID:          f.ID,
 			Username:    f.Username,
 			DisplayName: f.DisplayName,
 			AvatarURL:   f.AvatarUrl,
 			IsPrivate:   f.IsPrivate,
 			CreatedAt:   f.CreatedAt,
-			FollowedAt:  f.CreatedAt,
-		})
-	}
-
-	return userFollows, nil
+			FollowedAt:  f.FollowedAt,
+		}
+	}), nil
 }
 
 // GetPendingFollowRequests gets a list of pending follow requests for a user
-func (s *FollowService) GetPendingFollowRequests(ctx context.Context, userID pgtype.UUID, limit, offset int32) ([]UserFollow, error) {
+func (s *FollowService) GetPendingFollowRequests(ctx context.Context, userID pgtype.UUID, page pagination.Params) (*pagination.Page[UserFollow], error) {
 	requests, err := s.queries.GetPendingFollowRequests(ctx, db.GetPendingFollowRequestsParams{
-		FollowedID: userID,
-		Limit:      limit,
-		Offset:     offset,
+		FollowedID:      userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting pending follow requests: %w", err)
 	}
 
-	var userFollows []UserFollow
-	for _, f := range requests {
-		userFollows = append(userFollows, UserFollow{
+	rows := pagination.NewPage(requests, page, func(f db.GetPendingFollowRequestsRow) pagination.Cursor {
+		return pagination.NewCursor(f.CreatedAt, f.ID)
+	})
+
+	return pagination.Map(rows, func(f db.GetPendingFollowRequestsRow) UserFollow {
+		return UserFollow{
 			ID:          f.ID,
 			Username:    f.Username,
 			DisplayName: f.DisplayName,
 			AvatarURL:   f.AvatarUrl,
 			IsPrivate:   f.IsPrivate,
 			CreatedAt:   f.CreatedAt,
-		})
-	}
-
-	return userFollows, nil
+		}
+	}), nil
 }
 
-// AcceptFollowRequest accepts a pending follow request
+// AcceptFollowRequest accepts a pending follow request and lets the follower
+// know
 func (s *FollowService) AcceptFollowRequest(ctx context.Context, followerID, followedID pgtype.UUID) error {
 	_, err := s.queries.AcceptFollow(ctx, db.AcceptFollowParams{
 		FollowerID: followerID,
 		FollowedID: followedID,
 	})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("follow request not found")
+		}
 		return fmt.Errorf("error accepting follow request: %w", err)
 	}
+
+	_, err = s.notificationService.CreateNotification(ctx, followerID.Bytes, followedID.Bytes, nil, nil, model.NotificationTypeFollowAccepted)
+	if err != nil {
+		// Log error but don't fail the accept operation
+		log.Printf("Error creating follow accepted notification: %v", err)
+	}
+
+	s.publishFollow(ctx, followerID, followedID, true)
+	s.webhooks.Emit(ctx, followedID.Bytes, model.WebhookEventUserFollowed, UserFollowedData{
+		FollowerID: followerID,
+		FollowedID: followedID,
+		IsAccepted: true,
+	})
 	return nil
 }
+
+// RejectFollowRequest turns down a pending follow request. The requester
+// isn't told.
+func (s *FollowService) RejectFollowRequest(ctx context.Context, followerID, followedID pgtype.UUID) error {
+	return s.deleteFollowRequest(ctx, followerID, followedID)
+}
+
+// CancelFollowRequest withdraws a follow request that hasn't been accepted
+func (s *FollowService) CancelFollowRequest(ctx context.Context, followerID, followedID pgtype.UUID) error {
+	return s.deleteFollowRequest(ctx, followerID, followedID)
+}
+
+// deleteFollowRequest removes a pending follow request along with the
+// notification that announced it
+func (s *FollowService) deleteFollowRequest(ctx context.Context, followerID, followedID pgtype.UUID) error {
+	deleted, err := s.queries.DeleteFollowRequest(ctx, db.DeleteFollowRequestParams{
+		FollowerID: followerID,
+		FollowedID: followedID,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting follow request: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("follow request not found")
+	}
+
+	err = s.notificationService.DeleteFollowRequestNotifications(ctx, followedID.Bytes, followerID.Bytes)
+	if err != nil {
+		// Log error but don't fail the operation
+		log.Printf("Error deleting follow request notification: %v", err)
+	}
+
+	return nil
+}
+
+// GetFollowedUserIDs retrieves the accounts whose posts appear in a user's
+// home timeline, not including the user
+func (s *FollowService) GetFollowedUserIDs(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error) {
+	ids, err := s.queries.GetFollowedUserIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting followed users: %w", err)
+	}
+	return ids, nil
+}
+
+// publishFollow tells the follower's live connections about a follow change.
+// Failures are logged; realtime delivery never fails the follow itself.
+func (s *FollowService) publishFollow(ctx context.Context, followerID, followedID pgtype.UUID, following bool) {
+	event := FollowEvent{UserID: followedID, Following: following}
+	if err := s.hub.Publish(ctx, realtime.UserTopic(followerID.Bytes), realtime.EventFollow, event); err != nil {
+		log.Printf("Error publishing %s event: %v", realtime.EventFollow, err)
+	}
+}
@@ -0,0 +1,668 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"horizon-backend/internal/db"
+	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/realtime"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MessageService handles one-to-one and group conversations
+type MessageService struct {
+	queries *db.Queries
+	db      *pgxpool.Pool
+	hub     *realtime.Hub
+}
+
+// NewMessageService creates a new message service
+func NewMessageService(queries *db.Queries, pool *pgxpool.Pool, hub *realtime.Hub) *MessageService {
+	return &MessageService{
+		queries: queries,
+		db:      pool,
+		hub:     hub,
+	}
+}
+
+// MessageEvent is the data of the realtime event announcing a new message
+type MessageEvent struct {
+	ID             pgtype.UUID `json:"id"`
+	ConversationID pgtype.UUID `json:"conversation_id"`
+}
+
+// CreateGroupConversation starts a group owned by ownerID with the given
+// members. Every member must accept messages from the owner.
+func (s *MessageService) CreateGroupConversation(ctx context.Context, ownerID pgtype.UUID, title string, usernames []string) (*model.Conversation, error) {
+	groupTitle, err := validateConversationTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	memberIDs, err := s.resolveMembers(ctx, ownerID, usernames)
+	if err != nil {
+		return nil, err
+	}
+	if len(memberIDs) == 0 {
+		return nil, fmt.Errorf("a group needs at least one other member")
+	}
+	if len(memberIDs)+1 > model.MaxGroupMembers {
+		return nil, fmt.Errorf("conversation is full")
+	}
+
+	// Start a transaction so the group never exists without its members
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	conversation, err := qtx.CreateGroupConversation(ctx, groupTitle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	if _, err := qtx.AddConversationMembers(ctx, db.AddConversationMembersParams{
+		ConversationID: conversation.ID,
+		Role:           db.ConversationRoleOwner,
+		UserIds:        []pgtype.UUID{ownerID},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add conversation owner: %w", err)
+	}
+
+	if _, err := qtx.AddConversationMembers(ctx, db.AddConversationMembersParams{
+		ConversationID: conversation.ID,
+		Role:           db.ConversationRoleMember,
+		UserIds:        memberIDs,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add conversation members: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetConversation(ctx, ownerID, conversation.ID)
+}
+
+// GetConversation retrieves a conversation the user is a member of
+func (s *MessageService) GetConversation(ctx context.Context, userID, conversationID pgtype.UUID) (*model.Conversation, error) {
+	row, err := s.getMemberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := []*model.Conversation{dbConversationToModelConversation(db.GetConversationsRow(row))}
+	if err := s.loadMembers(ctx, conversations); err != nil {
+		return nil, err
+	}
+
+	return conversations[0], nil
+}
+
+// GetConversations lists a user's conversations, most recently active first
+func (s *MessageService) GetConversations(ctx context.Context, userID pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Conversation], error) {
+	dbConversations, err := s.queries.GetConversations(ctx, db.GetConversationsParams{
+		UserID:          userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversations: %w", err)
+	}
+
+	// Conversations are paged by their last activity
+	conversations := pagination.Map(pagination.NewPage(dbConversations, page, func(c db.GetConversationsRow) pagination.Cursor {
+		return pagination.NewCursor(c.LastActivityAt, c.ID)
+	}), dbConversationToModelConversation)
+
+	// Fill in the members for the whole page
+	if err := s.loadMembers(ctx, conversations.Data); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+// UpdateConversationTitle renames a group. Only its owner can rename it.
+func (s *MessageService) UpdateConversationTitle(ctx context.Context, userID, conversationID pgtype.UUID, title string) (*model.Conversation, error) {
+	conversation, err := s.getMemberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.IsGroup {
+		return nil, fmt.Errorf("direct conversations don't have a title")
+	}
+	if conversation.Role != db.ConversationRoleOwner {
+		return nil, fmt.Errorf("only the owner can change the title")
+	}
+
+	groupTitle, err := validateConversationTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.queries.UpdateConversationTitle(ctx, db.UpdateConversationTitleParams{
+		ID:    conversationID,
+		Title: groupTitle,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update conversation title: %w", err)
+	}
+
+	return s.GetConversation(ctx, userID, conversationID)
+}
+
+// AddMembers adds users to a group. Only its owner can add members, and
+// every new member must accept messages from the owner.
+func (s *MessageService) AddMembers(ctx context.Context, userID, conversationID pgtype.UUID, usernames []string) (*model.Conversation, error) {
+	conversation, err := s.getMemberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.IsGroup {
+		return nil, fmt.Errorf("cannot add members to a direct conversation")
+	}
+	if conversation.Role != db.ConversationRoleOwner {
+		return nil, fmt.Errorf("only the owner can manage members")
+	}
+
+	memberIDs, err := s.resolveMembers(ctx, userID, usernames)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a transaction so additions that overfill the group are undone
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	added, err := qtx.AddConversationMembers(ctx, db.AddConversationMembersParams{
+		ConversationID: conversationID,
+		Role:           db.ConversationRoleMember,
+		UserIds:        memberIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add conversation members: %w", err)
+	}
+
+	count, err := qtx.CountConversationMembers(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count conversation members: %w", err)
+	}
+	if len(added) > 0 && count > model.MaxGroupMembers {
+		return nil, fmt.Errorf("conversation is full")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetConversation(ctx, userID, conversationID)
+}
+
+// RemoveMember removes a member from a group. Members can remove themselves
+// to leave; the owner can remove anyone. When the owner leaves, the
+// longest-standing member becomes the owner, and the group is deleted once
+// nobody is left.
+func (s *MessageService) RemoveMember(ctx context.Context, userID, conversationID, memberID pgtype.UUID) error {
+	conversation, err := s.getMemberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if !conversation.IsGroup {
+		return fmt.Errorf("cannot leave a direct conversation")
+	}
+	if memberID != userID && conversation.Role != db.ConversationRoleOwner {
+		return fmt.Errorf("only the owner can manage members")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	removed, err := qtx.RemoveConversationMember(ctx, db.RemoveConversationMemberParams{
+		ConversationID: conversationID,
+		UserID:         memberID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove conversation member: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	if err := qtx.TransferConversationOwnership(ctx, conversationID); err != nil {
+		return fmt.Errorf("failed to transfer conversation ownership: %w", err)
+	}
+
+	if err := qtx.DeleteEmptyConversation(ctx, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SendMessage sends a message to a conversation the sender is a member of.
+// In a one-to-one conversation the other member must still accept messages
+// from the sender.
+func (s *MessageService) SendMessage(ctx context.Context, senderID, conversationID pgtype.UUID, content string) (*model.Message, error) {
+	if err := validateMessageContent(content); err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.getMemberConversation(ctx, senderID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !conversation.IsGroup {
+		recipientID := conversation.DirectUser1ID
+		if recipientID == senderID {
+			recipientID = conversation.DirectUser2ID
+		}
+		if err := s.checkCanMessage(ctx, senderID, recipientID); err != nil {
+			// The other member may have deleted their account
+			if err.Error() == "user not found" {
+				return nil, fmt.Errorf("cannot message this user")
+			}
+			return nil, err
+		}
+	}
+
+	return s.createMessage(ctx, conversationID, senderID, content, nil)
+}
+
+// SendDirectMessage sends a message to another user, starting their
+// one-to-one conversation if they don't have one yet
+func (s *MessageService) SendDirectMessage(ctx context.Context, senderID, recipientID pgtype.UUID, content string) (*model.Message, error) {
+	if err := validateMessageContent(content); err != nil {
+		return nil, err
+	}
+	if senderID == recipientID {
+		return nil, fmt.Errorf("cannot message yourself")
+	}
+
+	if err := s.checkCanMessage(ctx, senderID, recipientID); err != nil {
+		return nil, err
+	}
+
+	return s.createMessage(ctx, pgtype.UUID{}, senderID, content, &recipientID)
+}
+
+// GetMessages retrieves the messages of a conversation the user is a member
+// of, newest first
+func (s *MessageService) GetMessages(ctx context.Context, userID, conversationID pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Message], error) {
+	if _, err := s.getMemberConversation(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	return s.getMessages(ctx, conversationID, page)
+}
+
+// GetMessage retrieves a single message from a conversation the user is a
+// member of
+func (s *MessageService) GetMessage(ctx context.Context, userID, conversationID, messageID pgtype.UUID) (*model.Message, error) {
+	if _, err := s.getMemberConversation(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	dbMessage, err := s.queries.GetConversationMessage(ctx, db.GetConversationMessageParams{
+		ID:             messageID,
+		ConversationID: conversationID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("message not found")
+		}
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return dbMessageToModelMessage(dbMessage), nil
+}
+
+// GetDirectMessages retrieves the messages between a user and another user,
+// newest first
+func (s *MessageService) GetDirectMessages(ctx context.Context, userID, otherUserID pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Message], error) {
+	conversation, err := s.queries.GetDirectConversation(ctx, db.GetDirectConversationParams{
+		UserID:      userID,
+		OtherUserID: otherUserID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return &pagination.Page[*model.Message]{Data: []*model.Message{}}, nil
+		}
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	return s.getMessages(ctx, conversation.ID, page)
+}
+
+// MarkAsRead moves the user's read cursor in a conversation forward to the
+// given message, or to the latest message when messageID isn't set
+func (s *MessageService) MarkAsRead(ctx context.Context, userID, conversationID, messageID pgtype.UUID) error {
+	if _, err := s.getMemberConversation(ctx, userID, conversationID); err != nil {
+		return err
+	}
+
+	return s.markAsRead(ctx, s.queries, userID, conversationID, messageID)
+}
+
+// MarkDirectConversationAsRead marks the user's one-to-one conversation with
+// another user as read
+func (s *MessageService) MarkDirectConversationAsRead(ctx context.Context, userID, otherUserID pgtype.UUID) error {
+	conversation, err := s.queries.GetDirectConversation(ctx, db.GetDirectConversationParams{
+		UserID:      userID,
+		OtherUserID: otherUserID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	return s.markAsRead(ctx, s.queries, userID, conversation.ID, pgtype.UUID{})
+}
+
+// GetUnreadCount counts the unread messages across a user's conversations
+func (s *MessageService) GetUnreadCount(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	count, err := s.queries.GetUnreadMessageCount(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unread message count: %w", err)
+	}
+
+	return count, nil
+}
+
+// createMessage stores a message, bumps the conversation's activity and
+// moves the sender's read cursor past it. When recipientID is set the
+// message goes to the one-to-one conversation with that user, which is
+// created if needed.
+func (s *MessageService) createMessage(ctx context.Context, conversationID, senderID pgtype.UUID, content string, recipientID *pgtype.UUID) (*model.Message, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	if recipientID != nil {
+		conversation, err := qtx.GetOrCreateDirectConversation(ctx, db.GetOrCreateDirectConversationParams{
+			UserID:      senderID,
+			OtherUserID: *recipientID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversation: %w", err)
+		}
+		conversationID = conversation.ID
+
+		if _, err := qtx.AddConversationMembers(ctx, db.AddConversationMembersParams{
+			ConversationID: conversationID,
+			Role:           db.ConversationRoleMember,
+			UserIds:        []pgtype.UUID{senderID, *recipientID},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to add conversation members: %w", err)
+		}
+	}
+
+	dbMessage, err := qtx.CreateMessage(ctx, db.CreateMessageParams{
+		ConversationID: conversationID,
+		SenderID:       senderID,
+		Content:        content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	if err := qtx.TouchConversation(ctx, conversationID); err != nil {
+		return nil, fmt.Errorf("failed to update conversation: %w", err)
+	}
+
+	// The sender has read their own message
+	if err := s.markAsRead(ctx, qtx, senderID, conversationID, dbMessage.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Members load the message themselves so removed members don't get it
+	event := MessageEvent{ID: dbMessage.ID, ConversationID: dbMessage.ConversationID}
+	if err := s.hub.Publish(ctx, realtime.ConversationTopic(dbMessage.ConversationID.Bytes), realtime.EventMessage, event); err != nil {
+		log.Printf("Error publishing %s event: %v", realtime.EventMessage, err)
+	}
+
+	return dbMessageToModelMessage(dbMessage), nil
+}
+
+func (s *MessageService) getMessages(ctx context.Context, conversationID pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Message], error) {
+	dbMessages, err := s.queries.GetConversationMessages(ctx, db.GetConversationMessagesParams{
+		ConversationID:  conversationID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	return pagination.Map(pagination.NewPage(dbMessages, page, func(m db.Message) pagination.Cursor {
+		return pagination.NewCursor(m.CreatedAt, m.ID)
+	}), dbMessageToModelMessage), nil
+}
+
+func (s *MessageService) markAsRead(ctx context.Context, q *db.Queries, userID, conversationID, messageID pgtype.UUID) error {
+	var err error
+	if messageID.Valid {
+		_, err = q.UpdateReadCursor(ctx, db.UpdateReadCursorParams{
+			ConversationID: conversationID,
+			UserID:         userID,
+			MessageID:      messageID,
+		})
+	} else {
+		_, err = q.MarkConversationAsRead(ctx, db.MarkConversationAsReadParams{
+			ConversationID: conversationID,
+			UserID:         userID,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark conversation as read: %w", err)
+	}
+
+	return nil
+}
+
+// getMemberConversation loads a conversation for one of its members. Users
+// outside the conversation get "conversation not found".
+func (s *MessageService) getMemberConversation(ctx context.Context, userID, conversationID pgtype.UUID) (db.GetConversationForMemberRow, error) {
+	conversation, err := s.queries.GetConversationForMember(ctx, db.GetConversationForMemberParams{
+		ConversationID: conversationID,
+		UserID:         userID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return conversation, fmt.Errorf("conversation not found")
+		}
+		return conversation, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	return conversation, nil
+}
+
+// checkCanMessage enforces the direct message policy
+func (s *MessageService) checkCanMessage(ctx context.Context, senderID, recipientID pgtype.UUID) error {
+	canMessage, err := s.queries.CanMessageUser(ctx, db.CanMessageUserParams{
+		SenderID:    senderID,
+		RecipientID: recipientID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("user not found")
+		}
+		return fmt.Errorf("error checking message permission: %w", err)
+	}
+	if !canMessage {
+		return fmt.Errorf("cannot message this user")
+	}
+	return nil
+}
+
+// resolveMembers looks up the users to add to a group on behalf of userID,
+// skipping userID itself. Every user must exist and accept messages from
+// userID.
+func (s *MessageService) resolveMembers(ctx context.Context, userID pgtype.UUID, usernames []string) ([]pgtype.UUID, error) {
+	wanted := make([]string, 0, len(usernames))
+	seen := make(map[string]bool)
+	for _, username := range usernames {
+		username = strings.ToLower(strings.TrimSpace(username))
+		if username != "" && !seen[username] {
+			seen[username] = true
+			wanted = append(wanted, username)
+		}
+	}
+	if len(wanted) > model.MaxGroupMembers {
+		return nil, fmt.Errorf("conversation is full")
+	}
+
+	users, err := s.queries.GetMessageableUsers(ctx, db.GetMessageableUsersParams{
+		UserID:    userID,
+		Usernames: wanted,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up members: %w", err)
+	}
+	if len(users) != len(wanted) {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	memberIDs := make([]pgtype.UUID, 0, len(users))
+	for _, u := range users {
+		if u.ID == userID {
+			continue
+		}
+		if !u.CanMessage {
+			return nil, fmt.Errorf("cannot add this user")
+		}
+		memberIDs = append(memberIDs, u.ID)
+	}
+
+	return memberIDs, nil
+}
+
+// loadMembers fills in the members of a page of conversations in one query
+func (s *MessageService) loadMembers(ctx context.Context, conversations []*model.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]pgtype.UUID, len(conversations))
+	byID := make(map[[16]byte]*model.Conversation, len(conversations))
+	for i, c := range conversations {
+		ids[i] = c.ID
+		byID[c.ID.Bytes] = c
+	}
+
+	members, err := s.queries.GetConversationMembers(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation members: %w", err)
+	}
+
+	for _, m := range members {
+		c := byID[m.ConversationID.Bytes]
+		c.Members = append(c.Members, model.ConversationMember{
+			ID:                m.UserID,
+			Username:          m.Username,
+			DisplayName:       m.DisplayName,
+			AvatarURL:         m.AvatarUrl,
+			Role:              model.ConversationRole(m.Role),
+			JoinedAt:          m.JoinedAt,
+			LastReadMessageID: m.LastReadMessageID,
+			LastReadAt:        m.LastReadAt,
+		})
+	}
+
+	return nil
+}
+
+func validateMessageContent(content string) error {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("message content is required")
+	}
+	if utf8.RuneCountInString(content) > model.MaxMessageLength {
+		return fmt.Errorf("message is too long")
+	}
+	return nil
+}
+
+// validateConversationTitle trims a group title; an empty title clears it
+func validateConversationTitle(title string) (pgtype.Text, error) {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > model.MaxConversationTitleLength {
+		return pgtype.Text{}, fmt.Errorf("title is too long")
+	}
+	return pgtype.Text{String: title, Valid: title != ""}, nil
+}
+
+func dbConversationToModelConversation(c db.GetConversationsRow) *model.Conversation {
+	conversation := &model.Conversation{
+		ID:             c.ID,
+		IsGroup:        c.IsGroup,
+		Title:          c.Title,
+		Role:           model.ConversationRole(c.Role),
+		Members:        []model.ConversationMember{},
+		UnreadCount:    c.UnreadCount,
+		CreatedAt:      c.CreatedAt,
+		LastActivityAt: c.LastActivityAt,
+	}
+	if c.LastMessageID.Valid {
+		conversation.LastMessage = &model.Message{
+			ID:             c.LastMessageID,
+			ConversationID: c.ID,
+			SenderID:       c.LastMessageSenderID,
+			Content:        c.LastMessageContent.String,
+			CreatedAt:      c.LastMessageCreatedAt,
+		}
+	}
+	return conversation
+}
+
+func dbMessageToModelMessage(m db.Message) *model.Message {
+	return &model.Message{
+		ID:             m.ID,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Content:        m.Content,
+		CreatedAt:      m.CreatedAt,
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"horizon-backend/internal/db"
+	"horizon-backend/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MuteService handles muted accounts and threads. Muted accounts' posts are
+// left out of the home and hashtag timelines, and replies in muted threads
+// don't send notifications; both are enforced in the queries.
+type MuteService struct {
+	queries *db.Queries
+}
+
+func NewMuteService(queries *db.Queries) *MuteService {
+	return &MuteService{
+		queries: queries,
+	}
+}
+
+type MutedUser struct {
+	ID          pgtype.UUID        `json:"id"`
+	Username    string             `json:"username"`
+	DisplayName pgtype.Text        `json:"display_name"`
+	AvatarURL   pgtype.Text        `json:"avatar_url"`
+	IsPrivate   bool               `json:"is_private"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	MutedAt     pgtype.Timestamptz `json:"muted_at"`
+}
+
+// MuteUser mutes an account until expiresAt, or until it's unmuted when
+// expiresAt is nil. Muting an account again replaces the expiry.
+func (s *MuteService) MuteUser(ctx context.Context, muterID, mutedID pgtype.UUID, expiresAt *time.Time) error {
+	if muterID == mutedID {
+		return fmt.Errorf("cannot mute yourself")
+	}
+
+	var expires pgtype.Timestamptz
+	if expiresAt != nil {
+		if !expiresAt.After(time.Now()) {
+			return fmt.Errorf("mute expiry must be in the future")
+		}
+		expires = pgtype.Timestamptz{Time: *expiresAt, Valid: true}
+	}
+
+	_, err := s.queries.MuteUser(ctx, db.MuteUserParams{
+		MuterID:   muterID,
+		MutedID:   mutedID,
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mute user: %w", err)
+	}
+
+	return nil
+}
+
+// UnmuteUser lifts a mute before it expires
+func (s *MuteService) UnmuteUser(ctx context.Context, muterID, mutedID pgtype.UUID) error {
+	deleted, err := s.queries.UnmuteUser(ctx, db.UnmuteUserParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unmute user: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("mute not found")
+	}
+
+	return nil
+}
+
+// GetMutedUsers gets the accounts a user has muted, most recently muted
+// first. Expired mutes are left out.
+func (s *MuteService) GetMutedUsers(ctx context.Context, userID pgtype.UUID, page pagination.Params) (*pagination.Page[MutedUser], error) {
+	muted, err := s.queries.GetMutedUsers(ctx, db.GetMutedUsersParams{
+		MuterID:         userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get muted users: %w", err)
+	}
+
+	rows := pagination.NewPage(muted, page, func(m db.GetMutedUsersRow) pagination.Cursor {
+		return pagination.NewCursor(m.MutedAt, m.ID)
+	})
+
+	return pagination.Map(rows, func(m db.GetMutedUsersRow) MutedUser {
+		return MutedUser{
+			ID:          m.ID,
+			Username:    m.Username,
+			DisplayName: m.DisplayName,
+			AvatarURL:   m.AvatarUrl,
+			IsPrivate:   m.IsPrivate,
+			ExpiresAt:   m.ExpiresAt,
+			MutedAt:     m.MutedAt,
+		}
+	}), nil
+}
+
+// MuteConversation mutes the thread a post belongs to. Any post in the
+// thread can be given.
+func (s *MuteService) MuteConversation(ctx context.Context, userID, postID pgtype.UUID) error {
+	rootID, err := s.conversationRoot(ctx, userID, postID)
+	if err != nil {
+		return err
+	}
+
+	err = s.queries.MuteConversation(ctx, db.MuteConversationParams{
+		UserID:         userID,
+		ConversationID: rootID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mute conversation: %w", err)
+	}
+
+	return nil
+}
+
+// UnmuteConversation unmutes the thread a post belongs to
+func (s *MuteService) UnmuteConversation(ctx context.Context, userID, postID pgtype.UUID) error {
+	rootID, err := s.conversationRoot(ctx, userID, postID)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.queries.UnmuteConversation(ctx, db.UnmuteConversationParams{
+		UserID:         userID,
+		ConversationID: rootID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unmute conversation: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("mute not found")
+	}
+
+	return nil
+}
+
+// conversationRoot finds the root of the thread a post the user can see
+// belongs to
+func (s *MuteService) conversationRoot(ctx context.Context, userID, postID pgtype.UUID) (pgtype.UUID, error) {
+	post, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{ID: postID, ViewerID: userID})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return pgtype.UUID{}, fmt.Errorf("post not found")
+		}
+		return pgtype.UUID{}, fmt.Errorf("error getting post: %w", err)
+	}
+
+	// Posts from before threads were tracked are their own root
+	if post.ConversationID.Valid {
+		return post.ConversationID, nil
+	}
+	return post.ID, nil
+}
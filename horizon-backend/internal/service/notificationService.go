@@ -3,23 +3,47 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
+	"time"
 
 	"horizon-backend/internal/db"
 	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/realtime"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type NotificationService struct {
-	queries *db.Queries
+	queries     *db.Queries
+	db          *pgxpool.Pool
+	hub         *realtime.Hub
+	webhooks    *WebhookService
+	filters     *FilterService
+	groupWindow time.Duration
 }
 
-func NewNotificationService(queries *db.Queries) *NotificationService {
+// NewNotificationService creates a new notification service. Likes, reposts,
+// follows and follow requests are grouped with others of the same kind for groupWindow after
+// the first one.
+func NewNotificationService(queries *db.Queries, pool *pgxpool.Pool, hub *realtime.Hub, webhooks *WebhookService, filters *FilterService, groupWindow time.Duration) *NotificationService {
 	return &NotificationService{
-		queries: queries,
+		queries:     queries,
+		db:          pool,
+		hub:         hub,
+		webhooks:    webhooks,
+		filters:     filters,
+		groupWindow: groupWindow,
 	}
 }
 
+// CreateNotification notifies a user, following their notification settings.
+// It returns nil without an error when the user's settings turn the
+// notification off, when there's a block between the two users, or when it's
+// a reply in a thread the user muted.
 func (s *NotificationService) CreateNotification(ctx context.Context, userID, actorID [16]byte, postID, parentPostID *[16]byte, notificationType model.NotificationType) (*model.Notification, error) {
 	// Convert IDs to pgtype.UUID
 	user := pgtype.UUID{Bytes: userID, Valid: true}
@@ -33,18 +57,65 @@ func (s *NotificationService) CreateNotification(ctx context.Context, userID, ac
 		parentPost = pgtype.UUID{Bytes: *parentPostID, Valid: true}
 	}
 
+	// Check the user's settings for this type, and their muted threads
+	policy, err := s.queries.GetNotificationPolicy(ctx, db.GetNotificationPolicyParams{
+		UserID:           user,
+		ActorID:          actor,
+		NotificationType: db.NotificationType(notificationType),
+		PostID:           post,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	if !policy.Allowed {
+		return nil, nil
+	}
+
+	// Start a transaction so the notification and its group are written
+	// together
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	// Muted notifications arrive read
+	group, joined, err := s.findOrCreateGroup(ctx, qtx, user, post, notificationType, policy.Muted)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create notification
-	dbNotif, err := s.queries.CreateNotification(ctx, db.CreateNotificationParams{
+	dbNotif, err := qtx.CreateNotification(ctx, db.CreateNotificationParams{
 		UserID:           user,
 		ActorID:          actor,
 		PostID:           post,
 		ParentPostID:     parentPost,
 		NotificationType: db.NotificationType(notificationType),
+		GroupID:          group.ID,
+		Read:             policy.Muted,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create notification: %w", err)
 	}
 
+	if joined {
+		err := qtx.TouchNotificationGroup(ctx, db.TouchNotificationGroupParams{
+			ID:   group.ID,
+			Read: policy.Muted,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update notification group: %w", err)
+		}
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Convert to model
 	notification := &model.Notification{
 		ID:           dbNotif.ID,
@@ -57,59 +128,173 @@ func (s *NotificationService) CreateNotification(ctx context.Context, userID, ac
 		CreatedAt:    dbNotif.CreatedAt,
 		UpdatedAt:    dbNotif.UpdatedAt,
 		DeletedAt:    dbNotif.DeletedAt,
+		GroupID:      dbNotif.GroupID,
+	}
+
+	// Let the user's open streams and webhooks know; streams load the
+	// notification themselves
+	if !policy.Muted {
+		s.publish(ctx, userID, realtime.EventNotification, map[string]pgtype.UUID{"id": dbNotif.ID})
+		s.webhooks.Emit(ctx, userID, model.WebhookEventNotificationCreated, notification)
 	}
 
 	return notification, nil
 }
 
-func (s *NotificationService) GetNotifications(ctx context.Context, userID [16]byte, limit, offset int32) ([]*model.Notification, error) {
+// findOrCreateGroup returns the group a new notification belongs to: the
+// open group with the same key, or a new one. joined reports whether the
+// group already existed. New groups start out read if the notification is.
+func (s *NotificationService) findOrCreateGroup(ctx context.Context, q *db.Queries, userID, postID pgtype.UUID, notificationType model.NotificationType, read bool) (group db.NotificationGroup, joined bool, err error) {
+	key := notificationGroupKey(notificationType, postID)
+	if key.Valid {
+		group, err = q.GetOpenNotificationGroup(ctx, db.GetOpenNotificationGroupParams{
+			UserID:      userID,
+			GroupKey:    key,
+			OpenedAfter: pgtype.Timestamptz{Time: time.Now().Add(-s.groupWindow), Valid: true},
+		})
+		if err == nil {
+			return group, true, nil
+		}
+		if err != pgx.ErrNoRows {
+			return group, false, fmt.Errorf("failed to get notification group: %w", err)
+		}
+	}
+
+	group, err = q.CreateNotificationGroup(ctx, db.CreateNotificationGroupParams{
+		UserID:           userID,
+		NotificationType: db.NotificationType(notificationType),
+		PostID:           postID,
+		GroupKey:         key,
+		Read:             read,
+	})
+	if err != nil {
+		return group, false, fmt.Errorf("failed to create notification group: %w", err)
+	}
+
+	return group, false, nil
+}
+
+// notificationGroupKey identifies the notifications that are grouped
+// together: likes and reposts of the same post, follows, and follow
+// requests. Other types have no key and are never grouped.
+func notificationGroupKey(notificationType model.NotificationType, postID pgtype.UUID) pgtype.Text {
+	switch notificationType {
+	case model.NotificationTypeLike, model.NotificationTypeRepost:
+		if postID.Valid {
+			return pgtype.Text{String: string(notificationType) + ":" + uuid.UUID(postID.Bytes).String(), Valid: true}
+		}
+	case model.NotificationTypeFollow, model.NotificationTypeFollowRequest:
+		return pgtype.Text{String: string(notificationType), Valid: true}
+	}
+	return pgtype.Text{}
+}
+
+// GetNotifications retrieves a user's notification groups, most recently
+// active first
+func (s *NotificationService) GetNotifications(ctx context.Context, userID [16]byte, page pagination.Params) (*pagination.Page[*model.NotificationGroup], error) {
 	// Convert userID to pgtype.UUID
 	id := pgtype.UUID{Bytes: userID, Valid: true}
 
-	// Get notifications
-	dbNotifs, err := s.queries.GetNotifications(ctx, db.GetNotificationsParams{
-		UserID: id,
-		Limit:  limit,
-		Offset: offset,
+	// Get notification groups
+	dbGroups, err := s.queries.GetNotificationGroups(ctx, db.GetNotificationGroupsParams{
+		UserID:          id,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("database error getting notifications: %w", err)
 	}
 
-	// Convert to model notifications
-	notifications := make([]*model.Notification, len(dbNotifs))
-	for i, dbNotif := range dbNotifs {
-		// Check if required fields are valid
-		if !dbNotif.ID.Valid {
-			return nil, fmt.Errorf("invalid notification ID at index %d", i)
+	groups := pagination.Map(pagination.NewPage(dbGroups, page, func(g db.GetNotificationGroupsRow) pagination.Cursor {
+		return pagination.NewCursor(g.UpdatedAt, g.ID)
+	}), func(g db.GetNotificationGroupsRow) *model.NotificationGroup {
+		group := &model.NotificationGroup{
+			ID:                   g.ID,
+			Type:                 model.NotificationType(g.Type),
+			PostID:               g.PostID,
+			Read:                 g.Read,
+			Actors:               []model.NotificationActor{},
+			ActorCount:           g.ActorCount,
+			LatestNotificationID: g.LatestNotificationID,
+			ParentPostID:         g.ParentPostID,
+			PostContent:          g.PostContent,
+			ParentPostContent:    g.ParentPostContent,
+			CreatedAt:            g.CreatedAt,
+			UpdatedAt:            g.UpdatedAt,
 		}
-		if !dbNotif.UserID.Valid {
-			return nil, fmt.Errorf("invalid user ID at index %d", i)
+
+		// Only return the posts the type is about
+		if !group.Type.HasPost() {
+			group.PostID = pgtype.UUID{}
+			group.PostContent = pgtype.Text{}
 		}
-		if !dbNotif.ActorID.Valid {
-			return nil, fmt.Errorf("invalid actor ID at index %d", i)
+		if !group.Type.HasParentPost() {
+			group.ParentPostID = pgtype.UUID{}
+			group.ParentPostContent = pgtype.Text{}
 		}
 
-		notifications[i] = &model.Notification{
-			ID:                dbNotif.ID,
-			UserID:            dbNotif.UserID,
-			ActorID:           dbNotif.ActorID,
-			PostID:            dbNotif.PostID,
-			ParentPostID:      dbNotif.ParentPostID,
-			Type:              model.NotificationType(dbNotif.Type),
-			Read:              dbNotif.Read,
-			CreatedAt:         dbNotif.CreatedAt,
-			UpdatedAt:         dbNotif.UpdatedAt,
-			DeletedAt:         dbNotif.DeletedAt,
-			ActorUsername:     dbNotif.ActorUsername.String,
-			ActorDisplayName:  dbNotif.ActorDisplayName,
-			ActorAvatarURL:    dbNotif.ActorAvatarUrl,
-			PostContent:       dbNotif.PostContent,
-			ParentPostContent: dbNotif.ParentPostContent,
+		return group
+	})
+
+	// Filter the page after the cursors are set, so hidden groups don't
+	// shift the pages around them
+	matcher, err := s.filters.Matcher(ctx, userID, model.FilterScopeNotifications)
+	if err != nil {
+		return nil, err
+	}
+	kept := groups.Data[:0]
+	for _, group := range groups.Data {
+		result, keep := filterNotification(matcher, group.Type, group.PostContent)
+		if keep {
+			group.Filtered = result
+			kept = append(kept, group)
 		}
 	}
+	groups.Data = kept
 
-	return notifications, nil
+	if err := s.loadActors(ctx, groups.Data); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// loadActors fills in the actor previews of a page of groups in one query
+func (s *NotificationService) loadActors(ctx context.Context, groups []*model.NotificationGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	ids := make([]pgtype.UUID, len(groups))
+	byID := make(map[[16]byte]*model.NotificationGroup, len(groups))
+	for i, group := range groups {
+		ids[i] = group.ID
+		byID[group.ID.Bytes] = group
+	}
+
+	actors, err := s.queries.GetNotificationGroupActors(ctx, db.GetNotificationGroupActorsParams{
+		GroupIds:     ids,
+		PreviewLimit: model.NotificationGroupActorPreviews,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get notification actors: %w", err)
+	}
+
+	for _, actor := range actors {
+		group := byID[actor.GroupID.Bytes]
+		group.Actors = append(group.Actors, model.NotificationActor{
+			ID:          actor.ID,
+			Username:    actor.Username,
+			DisplayName: actor.DisplayName,
+			AvatarURL:   actor.AvatarUrl,
+		})
+	}
+
+	return nil
 }
 
 func (s *NotificationService) GetUnreadCount(ctx context.Context, userID [16]byte) (int64, error) {
@@ -125,16 +310,22 @@ func (s *NotificationService) GetUnreadCount(ctx context.Context, userID [16]byt
 	return count, nil
 }
 
-func (s *NotificationService) MarkAsRead(ctx context.Context, notificationID [16]byte) error {
-	// Convert notificationID to pgtype.UUID
-	id := pgtype.UUID{Bytes: notificationID, Valid: true}
-
+// MarkAsRead marks one of a user's notification groups as read
+func (s *NotificationService) MarkAsRead(ctx context.Context, userID, groupID [16]byte) error {
 	// Mark as read
-	_, err := s.queries.MarkNotificationAsRead(ctx, id)
+	_, err := s.queries.MarkNotificationGroupAsRead(ctx, db.MarkNotificationGroupAsReadParams{
+		ID:     pgtype.UUID{Bytes: groupID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("notification not found")
+		}
 		return fmt.Errorf("failed to mark notification as read: %w", err)
 	}
 
+	s.publish(ctx, userID, realtime.EventUnreadCount, nil)
+
 	return nil
 }
 
@@ -148,6 +339,8 @@ func (s *NotificationService) MarkAllAsRead(ctx context.Context, userID [16]byte
 		return fmt.Errorf("failed to mark all notifications as read: %w", err)
 	}
 
+	s.publish(ctx, userID, realtime.EventUnreadCount, nil)
+
 	return nil
 }
 
@@ -163,3 +356,189 @@ func (s *NotificationService) DeleteNotification(ctx context.Context, notificati
 
 	return nil
 }
+
+// DeleteFollowRequestNotifications removes the follow request notifications
+// an actor sent a user, for when the request is rejected or withdrawn
+func (s *NotificationService) DeleteFollowRequestNotifications(ctx context.Context, userID, actorID [16]byte) error {
+	deleted, err := s.queries.DeleteFollowRequestNotifications(ctx, db.DeleteFollowRequestNotificationsParams{
+		UserID:  pgtype.UUID{Bytes: userID, Valid: true},
+		ActorID: pgtype.UUID{Bytes: actorID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete follow request notifications: %w", err)
+	}
+
+	if deleted > 0 {
+		s.publish(ctx, userID, realtime.EventUnreadCount, nil)
+	}
+
+	return nil
+}
+
+// GetSettings retrieves a user's notification settings for every type
+func (s *NotificationService) GetSettings(ctx context.Context, userID [16]byte) (model.NotificationSettings, error) {
+	dbSettings, err := s.queries.GetNotificationSettings(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	settings := make(model.NotificationSettings, len(model.NotificationTypes))
+	for _, notificationType := range model.NotificationTypes {
+		settings[notificationType] = model.DefaultNotificationSetting
+	}
+	for _, setting := range dbSettings {
+		settings[model.NotificationType(setting.Type)] = model.NotificationSetting{
+			Enabled:           setting.Enabled,
+			OnlyFromFollowing: setting.OnlyFromFollowing,
+			Muted:             setting.Muted,
+		}
+	}
+
+	return settings, nil
+}
+
+// UpdateSettings saves a user's settings for the given types, leaving the
+// other types as they were, and returns the settings for every type
+func (s *NotificationService) UpdateSettings(ctx context.Context, userID [16]byte, settings model.NotificationSettings) (model.NotificationSettings, error) {
+	params := db.UpsertNotificationSettingsParams{
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	}
+	for notificationType, setting := range settings {
+		if !notificationType.IsValid() {
+			return nil, fmt.Errorf("invalid notification type: %s", notificationType)
+		}
+		params.Types = append(params.Types, string(notificationType))
+		params.Enabled = append(params.Enabled, setting.Enabled)
+		params.OnlyFromFollowing = append(params.OnlyFromFollowing, setting.OnlyFromFollowing)
+		params.Muted = append(params.Muted, setting.Muted)
+	}
+
+	if len(params.Types) > 0 {
+		if err := s.queries.UpsertNotificationSettings(ctx, params); err != nil {
+			return nil, fmt.Errorf("failed to update notification settings: %w", err)
+		}
+	}
+
+	return s.GetSettings(ctx, userID)
+}
+
+// Subscribe starts receiving a user's realtime notification events
+func (s *NotificationService) Subscribe(userID [16]byte) *realtime.Subscription {
+	return s.hub.Subscribe(realtime.UserTopic(userID))
+}
+
+// GetNotification retrieves one of a user's notifications. Notifications the
+// user's filters hide aren't found.
+func (s *NotificationService) GetNotification(ctx context.Context, userID, notificationID [16]byte) (*model.Notification, error) {
+	dbNotif, err := s.queries.GetNotificationForUser(ctx, db.GetNotificationForUserParams{
+		ID:     pgtype.UUID{Bytes: notificationID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("notification not found")
+		}
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	notification := dbNotificationToModelNotification(dbNotif)
+
+	matcher, err := s.filters.Matcher(ctx, userID, model.FilterScopeNotifications)
+	if err != nil {
+		return nil, err
+	}
+	result, keep := filterNotification(matcher, notification.Type, notification.PostContent)
+	if !keep {
+		return nil, fmt.Errorf("notification not found")
+	}
+	notification.Filtered = result
+
+	return notification, nil
+}
+
+// GetNotificationsSince retrieves up to limit of a user's notifications
+// created after cursor, oldest first, less the ones the user's filters hide.
+// more reports whether there were more than limit, hidden ones included.
+func (s *NotificationService) GetNotificationsSince(ctx context.Context, userID [16]byte, cursor pagination.Cursor, limit int32) (notifications []*model.Notification, more bool, err error) {
+	dbNotifs, err := s.queries.GetNotificationsSince(ctx, db.GetNotificationsSinceParams{
+		UserID:         pgtype.UUID{Bytes: userID, Valid: true},
+		AfterCreatedAt: pgtype.Timestamptz{Time: cursor.CreatedAt, Valid: true},
+		AfterID:        pgtype.UUID{Bytes: cursor.ID, Valid: true},
+		PageLimit:      limit + 1,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	if len(dbNotifs) > int(limit) {
+		dbNotifs = dbNotifs[:limit]
+		more = true
+	}
+
+	matcher, err := s.filters.Matcher(ctx, userID, model.FilterScopeNotifications)
+	if err != nil {
+		return nil, false, err
+	}
+
+	notifications = make([]*model.Notification, 0, len(dbNotifs))
+	for _, dbNotif := range dbNotifs {
+		notification := dbNotificationToModelNotification(db.GetNotificationForUserRow(dbNotif))
+		result, keep := filterNotification(matcher, notification.Type, notification.PostContent)
+		if keep {
+			notification.Filtered = result
+			notifications = append(notifications, notification)
+		}
+	}
+
+	return notifications, more, nil
+}
+
+// filterNotification checks the post of a notification against the user's
+// filters. Only posts the actor wrote are checked, not the user's own posts
+// that were liked or reposted.
+func filterNotification(matcher *FilterMatcher, notificationType model.NotificationType, postContent pgtype.Text) (*model.FilterResult, bool) {
+	if !notificationType.IsFromActor() || !postContent.Valid {
+		return nil, true
+	}
+	return matcher.Check(postContent.String)
+}
+
+// publish sends a realtime event to a user. Failures are logged; realtime
+// delivery never fails the operation that caused it.
+func (s *NotificationService) publish(ctx context.Context, userID [16]byte, eventType string, data any) {
+	if err := s.hub.Publish(ctx, realtime.UserTopic(userID), eventType, data); err != nil {
+		log.Printf("Error publishing %s event: %v", eventType, err)
+	}
+}
+
+func dbNotificationToModelNotification(n db.GetNotificationForUserRow) *model.Notification {
+	notification := &model.Notification{
+		ID:                n.ID,
+		UserID:            n.UserID,
+		ActorID:           n.ActorID,
+		PostID:            n.PostID,
+		ParentPostID:      n.ParentPostID,
+		Type:              model.NotificationType(n.Type),
+		Read:              n.Read,
+		CreatedAt:         n.CreatedAt,
+		UpdatedAt:         n.UpdatedAt,
+		DeletedAt:         n.DeletedAt,
+		GroupID:           n.GroupID,
+		ActorUsername:     n.ActorUsername,
+		ActorDisplayName:  n.ActorDisplayName,
+		ActorAvatarURL:    n.ActorAvatarUrl,
+		PostContent:       n.PostContent,
+		ParentPostContent: n.ParentPostContent,
+	}
+
+	// Only return the posts the type is about
+	if !notification.Type.HasPost() {
+		notification.PostID = pgtype.UUID{}
+		notification.PostContent = pgtype.Text{}
+	}
+	if !notification.Type.HasParentPost() {
+		notification.ParentPostID = pgtype.UUID{}
+		notification.ParentPostContent = pgtype.Text{}
+	}
+
+	return notification
+}
@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"horizon-backend/internal/db"
+	"horizon-backend/internal/hashtag"
+	"horizon-backend/internal/mention"
 	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+	"horizon-backend/internal/realtime"
 
 	"bytes"
 
@@ -24,24 +29,80 @@ type PostService struct {
 	db                  *pgxpool.Pool
 	userService         AuthService
 	notificationService *NotificationService
+	filters             *FilterService
+	hub                 *realtime.Hub
+	webhooks            *WebhookService
+	editWindow          time.Duration
 }
 
-// NewPostService creates a new post service
-func NewPostService(queries *db.Queries, pool *pgxpool.Pool, userService AuthService, notificationService *NotificationService) *PostService {
+// NewPostService creates a new post service. Posts can be edited for
+// editWindow after they're created, or at any time when it's zero.
+func NewPostService(queries *db.Queries, pool *pgxpool.Pool, userService AuthService, notificationService *NotificationService, filters *FilterService, hub *realtime.Hub, webhooks *WebhookService, editWindow time.Duration) *PostService {
 	return &PostService{
 		queries:             queries,
 		db:                  pool,
 		userService:         userService,
 		notificationService: notificationService,
+		filters:             filters,
+		hub:                 hub,
+		webhooks:            webhooks,
+		editWindow:          editWindow,
 	}
 }
 
+// PostEvent is the data of the realtime events announcing a post in its
+// author's or reposter's followers' home timelines
+type PostEvent struct {
+	ID         pgtype.UUID       `json:"id"`
+	RepostedBy *model.RepostedBy `json:"reposted_by,omitempty"`
+}
+
 // CreatePost creates a new post
 func (s *PostService) CreatePost(ctx context.Context, post *model.Post) (*model.Post, error) {
 	if post.Content == "" {
 		return nil, fmt.Errorf("content is required")
 	}
 
+	if post.ReplyPolicy == "" {
+		post.ReplyPolicy = model.ReplyPolicyEveryone
+	}
+	if !post.ReplyPolicy.IsValid() {
+		return nil, fmt.Errorf("invalid reply policy")
+	}
+
+	// Replies have to be allowed by the parent post's reply policy
+	if post.ReplyToPostID.Valid {
+		canReply, err := s.queries.CanUserReplyToPost(ctx, db.CanUserReplyToPostParams{
+			UserID: post.UserID,
+			PostID: post.ReplyToPostID,
+		})
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, fmt.Errorf("parent post not found")
+			}
+			return nil, fmt.Errorf("failed to check reply policy: %w", err)
+		}
+		if !canReply {
+			return nil, fmt.Errorf("replies to this post are restricted")
+		}
+	}
+
+	// A quote has to point at a post the author can see
+	var quotedPost *db.GetQuotedPostsRow
+	if post.QuotedPostID.Valid {
+		quoted, err := s.queries.GetQuotedPosts(ctx, db.GetQuotedPostsParams{
+			ViewerID: post.UserID,
+			PostIds:  []pgtype.UUID{post.QuotedPostID},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get quoted post: %w", err)
+		}
+		if len(quoted) == 0 || !quoted[0].IsVisible {
+			return nil, fmt.Errorf("quoted post not found")
+		}
+		quotedPost = &quoted[0]
+	}
+
 	// Create the post in the database
 	params := db.CreatePostParams{
 		UserID:        post.UserID,
@@ -49,14 +110,67 @@ func (s *PostService) CreatePost(ctx context.Context, post *model.Post) (*model.
 		IsPrivate:     post.IsPrivate,
 		ReplyToPostID: post.ReplyToPostID,
 		MediaUrls:     post.MediaUrls,
+		QuotedPostID:  post.QuotedPostID,
+		ReplyPolicy:   db.ReplyPolicy(post.ReplyPolicy),
 	}
 
-	dbPost, err := s.queries.CreatePost(ctx, params)
+	// Start a transaction so the post and its hashtags are written together
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	dbPost, err := qtx.CreatePost(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
-	return s.dbPostToModelPost(dbPost), nil
+	if err := s.syncHashtags(ctx, qtx, dbPost.ID, dbPost.Content); err != nil {
+		return nil, err
+	}
+
+	mentioned, err := s.syncMentions(ctx, qtx, dbPost.ID, dbPost.UserID, dbPost.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	createdPost := s.dbPostToModelPost(dbPost)
+	if err := s.attachQuotedPosts(ctx, s.queries, post.UserID, []*model.Post{createdPost}); err != nil {
+		return nil, err
+	}
+
+	// Notify the author of the quoted post
+	if quotedPost != nil && !bytes.Equal(quotedPost.UserID.Bytes[:], post.UserID.Bytes[:]) {
+		quoteID := createdPost.ID.Bytes
+		quotedID := quotedPost.ID.Bytes
+		_, err = s.notificationService.CreateNotification(ctx, quotedPost.UserID.Bytes, post.UserID.Bytes, &quoteID, &quotedID, model.NotificationTypeQuote)
+		if err != nil {
+			// Log error but don't fail the post creation
+			log.Printf("Error creating quote notification: %v", err)
+		}
+	}
+
+	if post.ReplyToPostID.Valid {
+		s.notifyReply(ctx, createdPost)
+	}
+
+	s.notifyMentions(ctx, createdPost.ID.Bytes, post.UserID.Bytes, mentioned)
+
+	s.publish(ctx, realtime.AuthorTopic(post.UserID.Bytes), realtime.EventPost, PostEvent{ID: createdPost.ID})
+	if post.ReplyToPostID.Valid {
+		s.publishCounts(ctx, post.ReplyToPostID)
+	}
+	s.webhooks.Emit(ctx, post.UserID.Bytes, model.WebhookEventPostCreated, createdPost)
+
+	return createdPost, nil
 }
 
 // UpdatePostContent updates the content of a post
@@ -66,14 +180,48 @@ func (s *PostService) UpdatePostContent(ctx context.Context, postId, userId pgty
 	}
 
 	// Verify post exists and belongs to user attempting to update
-	dbPost, err := s.queries.GetPostByID(ctx, postId)
+	dbPost, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userId})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
 		return nil, fmt.Errorf("failed to find post: %w", err)
 	}
 
 	// Check if post belongs to user
 	if dbPost.UserID != userId {
-		return nil, fmt.Errorf("unauthorized to update this post, post doesn't belong to you")
+		return nil, fmt.Errorf("unauthorized: post doesn't belong to you")
+	}
+
+	if s.editWindow > 0 && time.Since(dbPost.CreatedAt.Time) > s.editWindow {
+		return nil, fmt.Errorf("edit window has expired")
+	}
+
+	// Nothing to edit; don't record an empty revision
+	if dbPost.Content == content {
+		post := s.dbPostToModelPost(dbPost)
+		if err := s.hydrateViewerState(ctx, s.queries, userId, []*model.Post{post}); err != nil {
+			return nil, err
+		}
+		return post, nil
+	}
+
+	// Start a transaction so the content, its revision and its hashtags
+	// change together
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	// Keep the content being replaced
+	if _, err := qtx.CreatePostRevision(ctx, postId); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("failed to save post revision: %w", err)
 	}
 
 	// Update the post content
@@ -83,12 +231,185 @@ func (s *PostService) UpdatePostContent(ctx context.Context, postId, userId pgty
 		Content: content,
 	}
 
-	updatedDbPost, err := s.queries.UpdatePostContent(ctx, params)
+	updatedDbPost, err := qtx.UpdatePostContent(ctx, params)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
 		return nil, fmt.Errorf("failed to update post: %w", err)
 	}
 
-	return s.dbPostToModelPost(updatedDbPost), nil
+	if err := s.syncHashtags(ctx, qtx, updatedDbPost.ID, updatedDbPost.Content); err != nil {
+		return nil, err
+	}
+
+	// Only users who weren't mentioned before the edit are notified
+	mentioned, err := s.syncMentions(ctx, qtx, updatedDbPost.ID, updatedDbPost.UserID, updatedDbPost.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.notifyMentions(ctx, postId.Bytes, userId.Bytes, mentioned)
+
+	post := s.dbPostToModelPost(updatedDbPost)
+	if err := s.hydrateViewerState(ctx, s.queries, userId, []*model.Post{post}); err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// GetPostRevisions retrieves the earlier versions of a post, newest first.
+// Revisions are visible to whoever can see the post.
+func (s *PostService) GetPostRevisions(ctx context.Context, postId pgtype.UUID, page pagination.Params) (*pagination.Page[model.PostRevision], error) {
+	viewerID, _ := ctx.Value("user_id").(pgtype.UUID)
+
+	if _, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: viewerID}); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("failed to find post: %w", err)
+	}
+
+	dbRevisions, err := s.queries.GetPostRevisions(ctx, db.GetPostRevisionsParams{
+		PostID:          postId,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post revisions: %w", err)
+	}
+
+	return pagination.Map(pagination.NewPage(dbRevisions, page, func(r db.PostRevision) pagination.Cursor {
+		return pagination.NewCursor(r.CreatedAt, r.ID)
+	}), func(r db.PostRevision) model.PostRevision {
+		return model.PostRevision{
+			ID:        r.ID,
+			PostID:    r.PostID,
+			Content:   r.Content,
+			CreatedAt: r.CreatedAt,
+		}
+	}), nil
+}
+
+// syncHashtags makes the stored hashtags of a post match its content
+func (s *PostService) syncHashtags(ctx context.Context, q *db.Queries, postID pgtype.UUID, content string) error {
+	tags := hashtag.Extract(content)
+	if tags == nil {
+		tags = []string{}
+	}
+
+	if err := q.DeleteStalePostHashtags(ctx, db.DeleteStalePostHashtagsParams{
+		PostID:   postID,
+		Hashtags: tags,
+	}); err != nil {
+		return fmt.Errorf("failed to remove hashtags: %w", err)
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if err := q.AddPostHashtags(ctx, db.AddPostHashtagsParams{
+		PostID:   postID,
+		Hashtags: tags,
+	}); err != nil {
+		return fmt.Errorf("failed to save hashtags: %w", err)
+	}
+
+	return nil
+}
+
+// syncMentions makes the stored mentions of a post match its content and
+// returns the newly mentioned users who can see the post. Users on either
+// side of a block with the author aren't recorded.
+func (s *PostService) syncMentions(ctx context.Context, q *db.Queries, postID, authorID pgtype.UUID, content string) ([]pgtype.UUID, error) {
+	usernames := mention.Extract(content)
+	if usernames == nil {
+		usernames = []string{}
+	}
+
+	if err := q.DeleteStalePostMentions(ctx, db.DeleteStalePostMentionsParams{
+		PostID:    postID,
+		Usernames: usernames,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to remove mentions: %w", err)
+	}
+
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	mentioned, err := q.AddPostMentions(ctx, db.AddPostMentionsParams{
+		PostID:    postID,
+		Usernames: usernames,
+		AuthorID:  authorID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save mentions: %w", err)
+	}
+
+	return mentioned, nil
+}
+
+// notifyMentions sends a mention notification to each mentioned user other
+// than the author
+func (s *PostService) notifyMentions(ctx context.Context, postID, authorID [16]byte, mentioned []pgtype.UUID) {
+	for _, userID := range mentioned {
+		if bytes.Equal(userID.Bytes[:], authorID[:]) {
+			continue
+		}
+		_, err := s.notificationService.CreateNotification(ctx, userID.Bytes, authorID, &postID, nil, model.NotificationTypeMention)
+		if err != nil {
+			// Log error but don't fail the post operation
+			log.Printf("Error creating mention notification: %v", err)
+		}
+	}
+}
+
+// UpdateReplyPolicy changes who may reply to a post. Only the author can change it.
+func (s *PostService) UpdateReplyPolicy(ctx context.Context, postId, userId pgtype.UUID, policy model.ReplyPolicy) (*model.Post, error) {
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("invalid reply policy")
+	}
+
+	// Verify post exists and belongs to user attempting to update
+	dbPost, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userId})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("failed to find post: %w", err)
+	}
+
+	if dbPost.UserID != userId {
+		return nil, fmt.Errorf("unauthorized: post doesn't belong to you")
+	}
+
+	updatedDbPost, err := s.queries.UpdatePostReplyPolicy(ctx, db.UpdatePostReplyPolicyParams{
+		ID:          postId,
+		ReplyPolicy: db.ReplyPolicy(policy),
+		UserID:      userId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reply policy: %w", err)
+	}
+
+	post := s.dbPostToModelPost(updatedDbPost)
+	if err := s.hydrateViewerState(ctx, s.queries, userId, []*model.Post{post}); err != nil {
+		return nil, err
+	}
+
+	return post, nil
 }
 
 // HasLiked checks if a user has liked a post
@@ -106,8 +427,8 @@ func (s *PostService) HasLiked(ctx context.Context, postId, userId pgtype.UUID)
 
 	qtx := db.New(tx)
 
-	// Check if the post exists first
-	_, err = qtx.GetPostByID(ctx, postId)
+	// Check if the post exists and is visible to the user first
+	_, err = qtx.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userId})
 	if err != nil {
 		return false, fmt.Errorf("failed to find post: %w", err)
 	}
@@ -129,16 +450,157 @@ func (s *PostService) HasLiked(ctx context.Context, postId, userId pgtype.UUID)
 	return hasLiked, nil
 }
 
+// hydrateViewerState fills in reply counts and the viewer's like, bookmark and
+// repost state for a list of posts using a single query. An invalid viewerID
+// (anonymous request) leaves all viewer flags false.
+func (s *PostService) hydrateViewerState(ctx context.Context, q *db.Queries, viewerID pgtype.UUID, posts []*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIDs := make([]pgtype.UUID, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	states, err := q.GetPostViewerState(ctx, db.GetPostViewerStateParams{
+		ViewerID: viewerID,
+		PostIds:  postIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get viewer state: %w", err)
+	}
+
+	stateByID := make(map[[16]byte]db.GetPostViewerStateRow, len(states))
+	for _, state := range states {
+		stateByID[state.PostID.Bytes] = state
+	}
+
+	for _, post := range posts {
+		state := stateByID[post.ID.Bytes]
+		post.HasLiked = state.HasLiked
+		post.HasBookmarked = state.HasBookmarked
+		post.HasReposted = state.HasReposted
+		post.ReplyCount = int32(state.ReplyCount)
+	}
+
+	return s.attachQuotedPosts(ctx, q, viewerID, posts)
+}
+
+// attachQuotedPosts embeds the quoted post in each quote post of the list.
+// Quoted posts the viewer can't see are embedded as tombstones.
+func (s *PostService) attachQuotedPosts(ctx context.Context, q *db.Queries, viewerID pgtype.UUID, posts []*model.Post) error {
+	var quotedIDs []pgtype.UUID
+	for _, post := range posts {
+		if post.QuotedPostID.Valid {
+			quotedIDs = append(quotedIDs, post.QuotedPostID)
+		}
+	}
+	if len(quotedIDs) == 0 {
+		return nil
+	}
+
+	quoted, err := q.GetQuotedPosts(ctx, db.GetQuotedPostsParams{
+		ViewerID: viewerID,
+		PostIds:  quotedIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get quoted posts: %w", err)
+	}
+
+	quotedByID := make(map[[16]byte]db.GetQuotedPostsRow, len(quoted))
+	for _, qp := range quoted {
+		quotedByID[qp.ID.Bytes] = qp
+	}
+
+	for _, post := range posts {
+		if !post.QuotedPostID.Valid {
+			continue
+		}
+
+		qp, ok := quotedByID[post.QuotedPostID.Bytes]
+		if !ok || !qp.IsVisible {
+			post.QuotedPost = &model.QuotedPost{ID: post.QuotedPostID, Unavailable: true}
+			continue
+		}
+
+		post.QuotedPost = &model.QuotedPost{
+			ID:          qp.ID,
+			UserID:      qp.UserID,
+			Content:     qp.Content,
+			CreatedAt:   qp.CreatedAt,
+			MediaUrls:   qp.MediaUrls,
+			Username:    qp.Username,
+			DisplayName: qp.DisplayName,
+			AvatarUrl:   qp.AvatarUrl,
+		}
+	}
+
+	return nil
+}
+
+// applyFilters runs a list of posts through the viewer's filters for scope,
+// leaving out or marking the posts that match. The viewer's own posts and
+// anonymous requests aren't filtered.
+func (s *PostService) applyFilters(ctx context.Context, viewerID pgtype.UUID, scope model.FilterScope, posts []*model.Post) ([]*model.Post, error) {
+	matcher, err := s.filterMatcher(ctx, viewerID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := posts[:0]
+	for _, post := range posts {
+		if filterPost(matcher, viewerID, post) {
+			kept = append(kept, post)
+		}
+	}
+	return kept, nil
+}
+
+func (s *PostService) filterMatcher(ctx context.Context, viewerID pgtype.UUID, scope model.FilterScope) (*FilterMatcher, error) {
+	if !viewerID.Valid {
+		return nil, nil
+	}
+	return s.filters.Matcher(ctx, viewerID.Bytes, scope)
+}
+
+// filterPost checks a post against the viewer's filters, marking it if it
+// matched and is kept. It reports whether the post is kept.
+func filterPost(matcher *FilterMatcher, viewerID pgtype.UUID, post *model.Post) bool {
+	if post.UserID == viewerID {
+		return true
+	}
+
+	result, keep := matcher.Check(post.Content)
+	post.Filtered = result
+	return keep
+}
+
 // LikePost likes a post
 func (s *PostService) LikePost(ctx context.Context, postID, userID [16]byte) error {
-	// Get post to check owner
-	post, err := s.queries.GetPostByID(ctx, pgtype.UUID{Bytes: postID, Valid: true})
+	// Get post to check owner; posts the user can't see can't be liked
+	post, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{
+		ID:       pgtype.UUID{Bytes: postID, Valid: true},
+		ViewerID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("post not found")
+		}
 		return fmt.Errorf("error getting post: %w", err)
 	}
 
+	// Start a transaction so the like and counter stay in sync
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
 	// Create like
-	_, err = s.queries.LikePost(ctx, db.LikePostParams{
+	_, err = qtx.LikePost(ctx, db.LikePostParams{
 		UserID: pgtype.UUID{Bytes: userID, Valid: true},
 		PostID: pgtype.UUID{Bytes: postID, Valid: true},
 	})
@@ -146,6 +608,23 @@ func (s *PostService) LikePost(ctx context.Context, postID, userID [16]byte) err
 		return fmt.Errorf("error creating post like: %w", err)
 	}
 
+	// Increment the counter
+	_, err = qtx.IncrementLikeCount(ctx, pgtype.UUID{Bytes: postID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to increment like count: %w", err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.publishCounts(ctx, post.ID)
+	s.webhooks.Emit(ctx, post.UserID.Bytes, model.WebhookEventPostLiked, PostLikedData{
+		PostID: post.ID,
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+
 	// Create notification for post owner
 	if !bytes.Equal(post.UserID.Bytes[:], userID[:]) { // Don't notify if user likes their own post
 		_, err = s.notificationService.CreateNotification(ctx, post.UserID.Bytes, userID, &postID, nil, model.NotificationTypeLike)
@@ -170,17 +649,21 @@ func (s *PostService) UnlikePost(ctx context.Context, postId, userId pgtype.UUID
 	qtx := db.New(tx)
 
 	// Delete the like
-	err = qtx.UnlikePost(ctx, db.UnlikePostParams{
+	rows, err := qtx.UnlikePost(ctx, db.UnlikePostParams{
 		UserID: userId,
 		PostID: postId,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to unlike post: %w", err)
 	}
+	if rows == 0 {
+		// The post wasn't liked; leave the counter alone
+		return nil
+	}
 
 	// Decrement the counter
 	_, err = qtx.DecrementLikeCount(ctx, postId)
-	if err != nil {
+	if err != nil && err != pgx.ErrNoRows {
 		return fmt.Errorf("failed to decrement like count: %w", err)
 	}
 
@@ -189,6 +672,8 @@ func (s *PostService) UnlikePost(ctx context.Context, postId, userId pgtype.UUID
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	s.publishCounts(ctx, postId)
+
 	return nil
 }
 
@@ -203,32 +688,22 @@ func (s *PostService) GetPostById(ctx context.Context, postId pgtype.UUID) (*mod
 
 	qtx := db.New(tx)
 
-	// Get the post
-	dbPost, err := qtx.GetPostByID(ctx, postId)
+	// Get the post if the viewer is allowed to see it
+	userID, _ := ctx.Value("user_id").(pgtype.UUID)
+	dbPost, err := qtx.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userID})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
 		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
 
 	// Convert to model post
 	post := s.dbPostToModelPost(dbPost)
 
-	// Get the user ID from context
-	userID, ok := ctx.Value("user_id").(pgtype.UUID)
-	if ok && userID.Valid {
-		// Check if the user has liked the post
-		hasLiked, err := s.HasLiked(ctx, postId, userID)
-		if err == nil {
-			post.HasLiked = hasLiked
-		}
-
-		// Check if the user has bookmarked the post
-		var hasBookmarked bool
-		err = tx.QueryRow(ctx,
-			"SELECT EXISTS (SELECT 1 FROM bookmarks WHERE post_id = $1 AND user_id = $2)",
-			postId, userID).Scan(&hasBookmarked)
-		if err == nil {
-			post.HasBookmarked = hasBookmarked
-		}
+	// Fill in the viewer's state for the post
+	if err := s.hydrateViewerState(ctx, qtx, userID, []*model.Post{post}); err != nil {
+		return nil, err
 	}
 
 	// Commit the transaction
@@ -239,8 +714,44 @@ func (s *PostService) GetPostById(ctx context.Context, postId pgtype.UUID) (*mod
 	return post, nil
 }
 
-// GetPosts retrieves a paginated list of posts
-func (s *PostService) GetPosts(ctx context.Context, limit, offset int32) ([]*model.Post, error) {
+// GetHomeTimelinePost gets a post announced live to a user's home timeline.
+// Like GetHomeTimeline, it leaves out posts whose author or reposter the user
+// muted and posts their home filters hide, returning nil for them.
+func (s *PostService) GetHomeTimelinePost(ctx context.Context, userID pgtype.UUID, event PostEvent) (*model.Post, error) {
+	post, err := s.GetPostById(ctx, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []pgtype.UUID{post.UserID}
+	if event.RepostedBy != nil {
+		accounts = append(accounts, event.RepostedBy.ID)
+	}
+	muted, err := s.queries.IsAnyAccountMuted(ctx, db.IsAnyAccountMutedParams{
+		AccountIds: accounts,
+		UserID:     userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check mutes: %w", err)
+	}
+	if muted {
+		return nil, nil
+	}
+
+	post.RepostedBy = event.RepostedBy
+	kept, err := s.applyFilters(ctx, userID, model.FilterScopeHome, []*model.Post{post})
+	if err != nil {
+		return nil, err
+	}
+	if len(kept) == 0 {
+		return nil, nil
+	}
+
+	return post, nil
+}
+
+// GetPosts retrieves a page of posts
+func (s *PostService) GetPosts(ctx context.Context, page pagination.Params) (*pagination.Page[*model.Post], error) {
 	// Start a transaction since we want to ensure consistency
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -250,10 +761,16 @@ func (s *PostService) GetPosts(ctx context.Context, limit, offset int32) ([]*mod
 
 	qtx := db.New(tx)
 
-	// Call database to get a list of posts with pagination
+	// Call database to get a page of posts the viewer can see
+	userID, _ := ctx.Value("user_id").(pgtype.UUID)
 	params := db.GetAllPostsParams{
-		Limit:  limit,
-		Offset: offset,
+		ViewerID:        userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	}
 
 	dbPosts, err := qtx.GetAllPosts(ctx, params)
@@ -262,33 +779,70 @@ func (s *PostService) GetPosts(ctx context.Context, limit, offset int32) ([]*mod
 	}
 
 	// Convert to model posts
-	posts := make([]*model.Post, 0, len(dbPosts))
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetAllPostsRow) pagination.Cursor {
+		return pagination.NewCursor(p.CreatedAt, p.ID)
+	}), func(p db.GetAllPostsRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
 
-	// Get the user ID from context
-	userID, ok := ctx.Value("user_id").(pgtype.UUID)
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, qtx, userID, posts.Data); err != nil {
+		return nil, err
+	}
 
-	for _, dbPost := range dbPosts {
-		post := s.dbPostToModelPost(dbPost)
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
-		// If user is authenticated, check if they've liked and bookmarked each post
-		if ok && userID.Valid {
-			// Check likes
-			hasLiked, err := s.HasLiked(ctx, dbPost.ID, userID)
-			if err == nil {
-				post.HasLiked = hasLiked
-			}
+	return posts, nil
+}
 
-			// Check bookmarks using direct query
-			var hasBookmarked bool
-			err = tx.QueryRow(ctx,
-				"SELECT EXISTS (SELECT 1 FROM bookmarks WHERE post_id = $1 AND user_id = $2)",
-				dbPost.ID, userID).Scan(&hasBookmarked)
-			if err == nil {
-				post.HasBookmarked = hasBookmarked
-			}
-		}
+// GetHomeTimeline retrieves the home timeline for a user: their own posts plus
+// posts from accounts they follow with an accepted follow
+func (s *PostService) GetHomeTimeline(ctx context.Context, userId pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Post], error) {
+	// Start a transaction since we want to ensure consistency
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	// Call database to get the feed for the user
+	params := db.GetUserFeedParams{
+		UserID:          userId,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	}
+
+	dbPosts, err := qtx.GetUserFeed(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home timeline: %w", err)
+	}
+
+	// Convert to model posts
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetUserFeedRow) pagination.Cursor {
+		return pagination.NewCursor(p.ActivityAt, p.ID)
+	}), func(p db.GetUserFeedRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
+
+	// Filter the page after the cursors are set, so hidden posts don't
+	// shift the pages around them
+	posts.Data, err = s.applyFilters(ctx, userId, model.FilterScopeHome, posts.Data)
+	if err != nil {
+		return nil, err
+	}
 
-		posts = append(posts, post)
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, qtx, userId, posts.Data); err != nil {
+		return nil, err
 	}
 
 	// Commit the transaction
@@ -300,7 +854,7 @@ func (s *PostService) GetPosts(ctx context.Context, limit, offset int32) ([]*mod
 }
 
 // GetUserPosts retrieves posts by a specific user
-func (s *PostService) GetUserPosts(ctx context.Context, userId pgtype.UUID, limit, offset int32) ([]*model.Post, error) {
+func (s *PostService) GetUserPosts(ctx context.Context, userId pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Post], error) {
 	// Start a transaction since we want to ensure consistency
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -310,11 +864,17 @@ func (s *PostService) GetUserPosts(ctx context.Context, userId pgtype.UUID, limi
 
 	qtx := db.New(tx)
 
-	// Call database to get posts for a specific user
+	// Call database to get the user's posts the viewer can see
+	currentUserID, _ := ctx.Value("user_id").(pgtype.UUID)
 	params := db.GetPostsByUserIDParams{
-		UserID: userId,
-		Limit:  limit,
-		Offset: offset,
+		UserID:          userId,
+		ViewerID:        currentUserID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	}
 
 	dbPosts, err := qtx.GetPostsByUserID(ctx, params)
@@ -323,46 +883,160 @@ func (s *PostService) GetUserPosts(ctx context.Context, userId pgtype.UUID, limi
 	}
 
 	// Convert to model posts
-	posts := make([]*model.Post, 0, len(dbPosts))
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetPostsByUserIDRow) pagination.Cursor {
+		return pagination.NewCursor(p.ActivityAt, p.ID)
+	}), func(p db.GetPostsByUserIDRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
+
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, qtx, currentUserID, posts.Data); err != nil {
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return posts, nil
+}
+
+// GetPostReplies retrieves replies for a specific post
+func (s *PostService) GetPostReplies(ctx context.Context, postId pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Post], error) {
+	// Start a transaction since we want to ensure consistency
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	// Replies are only listed under posts the viewer can see
+	userID, _ := ctx.Value("user_id").(pgtype.UUID)
+	if _, err := qtx.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userID}); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("error getting post: %w", err)
+	}
+
+	// Call database to get the replies the viewer can see
+	params := db.GetPostRepliesParams{
+		ReplyToPostID:   postId,
+		ViewerID:        userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	}
+
+	dbPosts, err := qtx.GetPostReplies(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post replies: %w", err)
+	}
+
+	// Convert to model posts
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetPostRepliesRow) pagination.Cursor {
+		return pagination.NewCursor(p.CreatedAt, p.ID)
+	}), func(p db.GetPostRepliesRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
+
+	posts.Data, err = s.applyFilters(ctx, userID, model.FilterScopeReplies, posts.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, qtx, userID, posts.Data); err != nil {
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return posts, nil
+}
+
+// GetPostQuotes retrieves the posts quoting a specific post
+func (s *PostService) GetPostQuotes(ctx context.Context, postId pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Post], error) {
+	// Make sure the post exists and the viewer can see it
+	userID, _ := ctx.Value("user_id").(pgtype.UUID)
+	if _, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userID}); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("error getting post: %w", err)
+	}
+
+	// Call database to get the quotes of the post the viewer can see
+	dbPosts, err := s.queries.GetPostQuotes(ctx, db.GetPostQuotesParams{
+		QuotedPostID:    postId,
+		ViewerID:        userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post quotes: %w", err)
+	}
+
+	// Convert to model posts
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetPostQuotesRow) pagination.Cursor {
+		return pagination.NewCursor(p.CreatedAt, p.ID)
+	}), func(p db.GetPostQuotesRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
 
-	// Get the user ID from context
-	currentUserID, ok := ctx.Value("user_id").(pgtype.UUID)
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, s.queries, userID, posts.Data); err != nil {
+		return nil, err
+	}
 
-	for _, dbPost := range dbPosts {
-		post := s.dbPostToModelPost(dbPost)
+	return posts, nil
+}
 
-		// If user is authenticated, check if they've liked and bookmarked each post
-		if ok && currentUserID.Valid {
-			// Check likes
-			hasLiked, err := s.HasLiked(ctx, dbPost.ID, currentUserID)
-			if err == nil {
-				post.HasLiked = hasLiked
-			}
+const (
+	// threadMaxAncestors guards the ancestor walk against runaway chains
+	threadMaxAncestors int32 = 200
 
-			// Check bookmarks
-			var hasBookmarked bool
-			err = tx.QueryRow(ctx,
-				"SELECT EXISTS (SELECT 1 FROM bookmarks WHERE post_id = $1 AND user_id = $2)",
-				dbPost.ID, currentUserID).Scan(&hasBookmarked)
-			if err == nil {
-				post.HasBookmarked = hasBookmarked
-			}
-		}
+	// threadReplyDepth is how many levels of replies a thread includes
+	threadReplyDepth int32 = 3
 
-		posts = append(posts, post)
-	}
+	// threadSiblingLimit is how many replies are loaded per post below the
+	// first level; the rest are fetched from the post's replies endpoint
+	threadSiblingLimit int32 = 3
+)
 
-	// Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
-	}
+// ThreadReply is a reply in a thread along with the replies loaded below it
+type ThreadReply struct {
+	*model.Post
+	Replies []*ThreadReply `json:"replies"`
+	// Cursor for GET /posts/:id/replies when more replies exist than were loaded
+	MoreRepliesCursor *string `json:"more_replies_cursor"`
+}
 
-	return posts, nil
+// Thread is the conversation around a post: its ancestors up to the root and
+// a depth-limited tree of replies with paginated direct replies
+type Thread struct {
+	ConversationID pgtype.UUID                    `json:"conversation_id"`
+	Ancestors      []*model.Post                  `json:"ancestors"`
+	Post           *model.Post                    `json:"post"`
+	Replies        *pagination.Page[*ThreadReply] `json:"replies"`
 }
 
-// GetPostReplies retrieves replies for a specific post
-func (s *PostService) GetPostReplies(ctx context.Context, postId pgtype.UUID, limit, offset int32) ([]*model.Post, error) {
-	// Start a transaction since we want to ensure consistency
+// GetThread retrieves the conversation thread around a post
+func (s *PostService) GetThread(ctx context.Context, postId pgtype.UUID, page pagination.Params) (*Thread, error) {
+	// Start a transaction so the thread is read from one snapshot
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start transaction: %w", err)
@@ -371,36 +1045,115 @@ func (s *PostService) GetPostReplies(ctx context.Context, postId pgtype.UUID, li
 
 	qtx := db.New(tx)
 
-	// Call database to get replies for the post
-	params := db.GetPostRepliesParams{
-		ReplyToPostID: postId,
-		Limit:         limit,
-		Offset:        offset,
+	// Get the post itself
+	userID, _ := ctx.Value("user_id").(pgtype.UUID)
+	dbPost, err := qtx.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userID})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
+	post := s.dbPostToModelPost(dbPost)
 
-	dbPosts, err := qtx.GetPostReplies(ctx, params)
+	// Walk up to the root
+	dbAncestors, err := qtx.GetThreadAncestors(ctx, db.GetThreadAncestorsParams{
+		PostID:   postId,
+		MaxDepth: threadMaxAncestors,
+		ViewerID: userID,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get post replies: %w", err)
+		return nil, fmt.Errorf("failed to get thread ancestors: %w", err)
+	}
+
+	ancestors := make([]*model.Post, len(dbAncestors))
+	for i, a := range dbAncestors {
+		ancestors[i] = s.dbPostToModelPost(a)
+		if ancestors[i].DeletedAt.Valid || !a.IsVisible {
+			// Keep deleted and hidden ancestors as placeholders so the chain
+			// stays intact
+			ancestors[i].Content = ""
+			ancestors[i].MediaUrls = nil
+			ancestors[i].QuotedPostID = pgtype.UUID{}
+		}
+		if !a.IsVisible {
+			ancestors[i].Username = ""
+			ancestors[i].DisplayName = pgtype.Text{}
+			ancestors[i].AvatarUrl = pgtype.Text{}
+		}
 	}
 
-	// Convert to model posts
-	posts := make([]*model.Post, 0, len(dbPosts))
+	// Load the reply tree
+	dbReplies, err := qtx.GetThreadDescendants(ctx, db.GetThreadDescendantsParams{
+		PostID:          postId,
+		ViewerID:        userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+		SiblingLimit:    threadSiblingLimit,
+		MaxDepth:        threadReplyDepth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thread replies: %w", err)
+	}
 
-	// Get the user ID from context
-	userID, ok := ctx.Value("user_id").(pgtype.UUID)
+	// Rows come ordered by depth, so every parent is seen before its replies.
+	// Look-ahead rows are dropped together with their subtrees.
+	var direct []db.GetThreadDescendantsRow
+	for _, r := range dbReplies {
+		if r.Depth == 1 {
+			direct = append(direct, r)
+		}
+	}
+	directPage := pagination.NewPage(direct, page, func(r db.GetThreadDescendantsRow) pagination.Cursor {
+		return pagination.NewCursor(r.CreatedAt, r.ID)
+	})
 
-	for _, dbPost := range dbPosts {
-		post := s.dbPostToModelPost(dbPost)
+	nodes := make(map[[16]byte]*ThreadReply, len(dbReplies))
+	replies := pagination.Map(directPage, func(r db.GetThreadDescendantsRow) *ThreadReply {
+		node := &ThreadReply{Post: s.dbPostToModelPost(r), Replies: []*ThreadReply{}}
+		nodes[r.ID.Bytes] = node
+		return node
+	})
 
-		// If user is authenticated, check if they've liked each post
-		if ok && userID.Valid {
-			hasLiked, err := s.HasLiked(ctx, dbPost.ID, userID)
-			if err == nil {
-				post.HasLiked = hasLiked
-			}
+	allPosts := append([]*model.Post{post}, ancestors...)
+	for _, node := range replies.Data {
+		allPosts = append(allPosts, node.Post)
+	}
+	for _, r := range dbReplies {
+		if r.Depth == 1 {
+			continue
+		}
+		parent, ok := nodes[r.ReplyToPostID.Bytes]
+		if !ok {
+			continue
 		}
+		if n := len(parent.Replies); n == int(threadSiblingLimit) {
+			// The look-ahead row; point clients at the rest of the replies
+			last := parent.Replies[n-1]
+			cursor := pagination.NewCursor(last.CreatedAt, last.ID).Encode(pagination.Older)
+			parent.MoreRepliesCursor = &cursor
+			continue
+		}
+		node := &ThreadReply{Post: s.dbPostToModelPost(r), Replies: []*ThreadReply{}}
+		parent.Replies = append(parent.Replies, node)
+		nodes[r.ID.Bytes] = node
+		allPosts = append(allPosts, node.Post)
+	}
+
+	// Filter the replies; a hidden reply takes the replies below it along
+	matcher, err := s.filterMatcher(ctx, userID, model.FilterScopeReplies)
+	if err != nil {
+		return nil, err
+	}
+	replies.Data = filterThreadReplies(matcher, userID, replies.Data)
 
-		posts = append(posts, post)
+	// Fill in the viewer's state for every post in the thread at once
+	if err := s.hydrateViewerState(ctx, qtx, userID, allPosts); err != nil {
+		return nil, err
 	}
 
 	// Commit the transaction
@@ -408,7 +1161,30 @@ func (s *PostService) GetPostReplies(ctx context.Context, postId pgtype.UUID, li
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return posts, nil
+	conversationID := post.ConversationID
+	if !conversationID.Valid {
+		conversationID = post.ID
+	}
+
+	return &Thread{
+		ConversationID: conversationID,
+		Ancestors:      ancestors,
+		Post:           post,
+		Replies:        replies,
+	}, nil
+}
+
+// filterThreadReplies filters a level of a thread's reply tree and the
+// levels below the replies that are kept
+func filterThreadReplies(matcher *FilterMatcher, viewerID pgtype.UUID, replies []*ThreadReply) []*ThreadReply {
+	kept := replies[:0]
+	for _, reply := range replies {
+		if filterPost(matcher, viewerID, reply.Post) {
+			reply.Replies = filterThreadReplies(matcher, viewerID, reply.Replies)
+			kept = append(kept, reply)
+		}
+	}
+	return kept
 }
 
 // DeletePost deletes a post by ID
@@ -423,7 +1199,7 @@ func (s *PostService) DeletePost(ctx context.Context, postId, userId pgtype.UUID
 	qtx := db.New(tx)
 
 	// First verify the post exists and belongs to the user
-	post, err := qtx.GetPostByID(ctx, postId)
+	post, err := qtx.GetPostByID(ctx, db.GetPostByIDParams{ID: postId, ViewerID: userId})
 	if err != nil {
 		return fmt.Errorf("failed to find post: %w", err)
 	}
@@ -446,11 +1222,106 @@ func (s *PostService) DeletePost(ctx context.Context, postId, userId pgtype.UUID
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	s.publish(ctx, realtime.PostTopic(postId.Bytes), realtime.EventPostDeleted, PostEvent{ID: postId})
+	if post.ReplyToPostID.Valid {
+		s.publishCounts(ctx, post.ReplyToPostID)
+	}
+	s.webhooks.Emit(ctx, userId.Bytes, model.WebhookEventPostDeleted, PostDeletedData{PostID: postId})
+
 	return nil
 }
 
+// GetHashtagPosts retrieves the posts tagged with a hashtag that the viewer
+// can see. The tag is matched in its normalized form.
+func (s *PostService) GetHashtagPosts(ctx context.Context, tag string, page pagination.Params) (*pagination.Page[*model.Post], error) {
+	normalized, ok := hashtag.Normalize(tag)
+	if !ok {
+		return nil, fmt.Errorf("invalid hashtag")
+	}
+
+	userID, _ := ctx.Value("user_id").(pgtype.UUID)
+	dbPosts, err := s.queries.GetPostsWithHashtag(ctx, db.GetPostsWithHashtagParams{
+		Hashtag:         normalized,
+		ViewerID:        userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hashtag posts: %w", err)
+	}
+
+	// Convert to model posts
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetPostsWithHashtagRow) pagination.Cursor {
+		return pagination.NewCursor(p.CreatedAt, p.ID)
+	}), func(p db.GetPostsWithHashtagRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
+
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, s.queries, userID, posts.Data); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+const (
+	// DefaultTrendingWindow is the usage window trends are computed over
+	DefaultTrendingWindow = 24 * time.Hour
+
+	// MinTrendingWindow and MaxTrendingWindow bound the requested window
+	MinTrendingWindow = time.Hour
+	MaxTrendingWindow = 7 * 24 * time.Hour
+
+	// trendingMinUses is how often a tag has to be used in the current window
+	// to trend, so a handful of posts can't produce a huge growth rate
+	trendingMinUses int64 = 3
+)
+
+// TrendingHashtag is a hashtag with its usage in the current and previous
+// window and the growth between them
+type TrendingHashtag struct {
+	Hashtag      string  `json:"hashtag"`
+	CurrentUses  int64   `json:"current_uses"`
+	PreviousUses int64   `json:"previous_uses"`
+	Growth       float64 `json:"growth"`
+}
+
+// GetTrendingHashtags ranks hashtags by how fast their usage grew over the
+// last window compared to the window before it
+func (s *PostService) GetTrendingHashtags(ctx context.Context, window time.Duration, limit int32) ([]TrendingHashtag, error) {
+	if window < MinTrendingWindow || window > MaxTrendingWindow {
+		return nil, fmt.Errorf("invalid trending window")
+	}
+
+	rows, err := s.queries.GetTrendingHashtags(ctx, db.GetTrendingHashtagsParams{
+		WindowSize:  pgtype.Interval{Microseconds: window.Microseconds(), Valid: true},
+		MinUses:     trendingMinUses,
+		ResultLimit: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trending hashtags: %w", err)
+	}
+
+	trending := make([]TrendingHashtag, len(rows))
+	for i, r := range rows {
+		trending[i] = TrendingHashtag{
+			Hashtag:      r.Hashtag,
+			CurrentUses:  r.CurrentUses,
+			PreviousUses: r.PreviousUses,
+			Growth:       r.Growth,
+		}
+	}
+
+	return trending, nil
+}
+
 // GetUserPostsByUsername gets posts by a user's username
-func (s *PostService) GetUserPostsByUsername(ctx context.Context, username string, limit, offset int32) ([]*model.Post, error) {
+func (s *PostService) GetUserPostsByUsername(ctx context.Context, username string, page pagination.Params) (*pagination.Page[*model.Post], error) {
 	// Start a transaction
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -469,56 +1340,32 @@ func (s *PostService) GetUserPostsByUsername(ctx context.Context, username strin
 		return nil, fmt.Errorf("error getting user by username: %w", err)
 	}
 
-	// Then get their posts
+	// Then get their posts the viewer can see
+	userID, _ := ctx.Value("user_id").(pgtype.UUID)
 	posts, err := qtx.GetPostsByUserID(ctx, db.GetPostsByUserIDParams{
-		UserID: user.ID,
-		Limit:  limit,
-		Offset: offset,
+		UserID:          user.ID,
+		ViewerID:        userID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error getting user posts: %w", err)
 	}
 
 	// Convert to model posts
-	modelPosts := make([]*model.Post, len(posts))
-	for i, post := range posts {
-		// Get post stats
-		stats, err := qtx.GetPostStats(ctx, post.ID)
-		if err != nil {
-			return nil, fmt.Errorf("error getting post stats: %w", err)
-		}
-
-		// Check if the current user has liked the post
-		hasLiked := false
-		if userID, ok := ctx.Value("user_id").(pgtype.UUID); ok && userID.Valid {
-			hasLiked, err = qtx.HasUserLikedPost(ctx, db.HasUserLikedPostParams{
-				PostID: post.ID,
-				UserID: userID,
-			})
-			if err != nil {
-				return nil, fmt.Errorf("error checking if user has liked post: %w", err)
-			}
-		}
+	modelPosts := pagination.Map(pagination.NewPage(posts, page, func(p db.GetPostsByUserIDRow) pagination.Cursor {
+		return pagination.NewCursor(p.ActivityAt, p.ID)
+	}), func(p db.GetPostsByUserIDRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
 
-		modelPosts[i] = &model.Post{
-			ID:            post.ID,
-			UserID:        post.UserID,
-			Content:       post.Content,
-			CreatedAt:     post.CreatedAt,
-			UpdatedAt:     post.UpdatedAt,
-			DeletedAt:     post.DeletedAt,
-			IsPrivate:     post.IsPrivate,
-			ReplyToPostID: post.ReplyToPostID,
-			AllowReplies:  post.AllowReplies,
-			MediaUrls:     post.MediaUrls,
-			LikeCount:     int32(stats.LikeCount),
-			RepostCount:   int32(stats.RepostCount),
-			ReplyCount:    int32(stats.ReplyCount),
-			HasLiked:      hasLiked,
-			Username:      post.Username,
-			DisplayName:   post.DisplayName,
-			AvatarUrl:     post.AvatarUrl,
-		}
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, qtx, userID, modelPosts.Data); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -529,7 +1376,7 @@ func (s *PostService) GetUserPostsByUsername(ctx context.Context, username strin
 }
 
 // GetUserReplies retrieves all replies made by a specific user
-func (s *PostService) GetUserReplies(ctx context.Context, userId pgtype.UUID, limit, offset int32) ([]*model.Post, error) {
+func (s *PostService) GetUserReplies(ctx context.Context, userId pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Post], error) {
 	// Start a transaction since we want to ensure consistency
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -539,11 +1386,17 @@ func (s *PostService) GetUserReplies(ctx context.Context, userId pgtype.UUID, li
 
 	qtx := db.New(tx)
 
-	// Call database to get replies for the user
+	// Call database to get the user's replies the viewer can see
+	currentUserID, _ := ctx.Value("user_id").(pgtype.UUID)
 	params := db.GetUserRepliesParams{
-		UserID: userId,
-		Limit:  limit,
-		Offset: offset,
+		UserID:          userId,
+		ViewerID:        currentUserID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	}
 
 	dbPosts, err := qtx.GetUserReplies(ctx, params)
@@ -552,23 +1405,15 @@ func (s *PostService) GetUserReplies(ctx context.Context, userId pgtype.UUID, li
 	}
 
 	// Convert to model posts
-	posts := make([]*model.Post, 0, len(dbPosts))
-
-	// Get the user ID from context
-	currentUserID, ok := ctx.Value("user_id").(pgtype.UUID)
-
-	for _, dbPost := range dbPosts {
-		post := s.dbPostToModelPost(dbPost)
-
-		// If user is authenticated, check if they've liked each post
-		if ok && currentUserID.Valid {
-			hasLiked, err := s.HasLiked(ctx, dbPost.ID, currentUserID)
-			if err == nil {
-				post.HasLiked = hasLiked
-			}
-		}
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetUserRepliesRow) pagination.Cursor {
+		return pagination.NewCursor(p.CreatedAt, p.ID)
+	}), func(p db.GetUserRepliesRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
 
-		posts = append(posts, post)
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, qtx, currentUserID, posts.Data); err != nil {
+		return nil, err
 	}
 
 	// Commit the transaction
@@ -579,8 +1424,38 @@ func (s *PostService) GetUserReplies(ctx context.Context, userId pgtype.UUID, li
 	return posts, nil
 }
 
+// GetUserMentions retrieves the posts that mention a user, newest first
+func (s *PostService) GetUserMentions(ctx context.Context, userId pgtype.UUID, page pagination.Params) (*pagination.Page[*model.Post], error) {
+	dbPosts, err := s.queries.GetUserMentions(ctx, db.GetUserMentionsParams{
+		UserID:          userId,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mentions: %w", err)
+	}
+
+	// Convert to model posts
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetUserMentionsRow) pagination.Cursor {
+		return pagination.NewCursor(p.CreatedAt, p.ID)
+	}), func(p db.GetUserMentionsRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
+
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, s.queries, userId, posts.Data); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
 // GetUserRepliesByUsername retrieves all replies made by a user identified by username
-func (s *PostService) GetUserRepliesByUsername(ctx context.Context, username string, limit, offset int32) ([]*model.Post, error) {
+func (s *PostService) GetUserRepliesByUsername(ctx context.Context, username string, page pagination.Params) (*pagination.Page[*model.Post], error) {
 	// Get user ID from username
 	user, err := s.userService.GetUserByUsername(ctx, username)
 	if err != nil {
@@ -588,47 +1463,43 @@ func (s *PostService) GetUserRepliesByUsername(ctx context.Context, username str
 	}
 
 	// Get replies using user ID
-	return s.GetUserReplies(ctx, user.ID, limit, offset)
+	return s.GetUserReplies(ctx, user.ID, page)
 }
 
 // GetUserLikedPosts retrieves posts liked by a specific user
-func (s *PostService) GetUserLikedPostsByUsername(ctx context.Context, username string, limit, offset int32) ([]*model.Post, error) {
+func (s *PostService) GetUserLikedPostsByUsername(ctx context.Context, username string, page pagination.Params) (*pagination.Page[*model.Post], error) {
 	// Get user by username
 	user, err := s.userService.GetUserByUsername(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
-	// Get liked posts
+	// Get the liked posts the viewer can see, paginated by when they were liked
+	viewerID, _ := ctx.Value("user_id").(pgtype.UUID)
 	dbPosts, err := s.queries.GetUserLikedPosts(ctx, db.GetUserLikedPostsParams{
-		UserID: user.ID,
-		Limit:  limit,
-		Offset: offset,
+		UserID:          user.ID,
+		ViewerID:        viewerID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get liked posts: %w", err)
 	}
 
 	// Convert to model posts
-	posts := make([]*model.Post, len(dbPosts))
-	for i, dbPost := range dbPosts {
-		post := s.dbPostToModelPost(dbPost)
-
-		// Get post stats
-		stats, err := s.queries.GetPostStats(ctx, dbPost.ID)
-		if err == nil {
-			post.ReplyCount = int32(stats.ReplyCount)
-		}
-
-		// Check if the current user has liked the post
-		if userID, ok := ctx.Value("user_id").(pgtype.UUID); ok && userID.Valid {
-			hasLiked, err := s.HasLiked(ctx, dbPost.ID, userID)
-			if err == nil {
-				post.HasLiked = hasLiked
-			}
-		}
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetUserLikedPostsRow) pagination.Cursor {
+		return pagination.NewCursor(p.LikedAt, p.ID)
+	}), func(p db.GetUserLikedPostsRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
 
-		posts[i] = post
+	// Fill in the viewer's state for the whole page
+	if err := s.hydrateViewerState(ctx, s.queries, viewerID, posts.Data); err != nil {
+		return nil, err
 	}
 
 	return posts, nil
@@ -656,10 +1527,13 @@ func (s *PostService) BookmarkPost(ctx context.Context, postID string, userID st
 
 	qtx := db.New(tx)
 
-	// Check if post exists and is not deleted
-	post, err := qtx.GetPostByID(ctx, pgtype.UUID{Bytes: postUUID, Valid: true})
+	// Check if post exists, is not deleted and is visible to the user
+	post, err := qtx.GetPostByID(ctx, db.GetPostByIDParams{
+		ID:       pgtype.UUID{Bytes: postUUID, Valid: true},
+		ViewerID: pgtype.UUID{Bytes: userUUID, Valid: true},
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if err == pgx.ErrNoRows {
 			return fmt.Errorf("post not found")
 		}
 		return fmt.Errorf("failed to check post: %v", err)
@@ -728,54 +1602,38 @@ func (s *PostService) UnbookmarkPost(ctx context.Context, postID string, userID
 }
 
 // GetUserBookmarks returns all bookmarked posts for a user
-func (s *PostService) GetUserBookmarks(ctx context.Context, userID string, limit int32, offset int32) ([]*model.Post, error) {
+func (s *PostService) GetUserBookmarks(ctx context.Context, userID string, page pagination.Params) (*pagination.Page[*model.Post], error) {
 	// Convert string ID to UUID
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID: %v", err)
 	}
 
-	// Get bookmarked posts
+	// Get bookmarked posts, paginated by when they were bookmarked
 	dbPosts, err := s.queries.GetUserBookmarkedPosts(ctx, db.GetUserBookmarkedPostsParams{
-		UserID: pgtype.UUID{Bytes: userUUID, Valid: true},
-		Limit:  limit,
-		Offset: offset,
+		UserID:          pgtype.UUID{Bytes: userUUID, Valid: true},
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookmarked posts: %v", err)
 	}
 
-	// Convert to model.Post slice and enrich with additional data
-	posts := make([]*model.Post, len(dbPosts))
-	for i, dbPost := range dbPosts {
-		post := s.dbPostToModelPost(dbPost)
-
-		// Set has_bookmarked to true since these are bookmarked posts
-		post.HasBookmarked = true
-
-		// Get post stats
-		stats, err := s.queries.GetPostStats(ctx, dbPost.ID)
-		if err == nil {
-			post.ReplyCount = int32(stats.ReplyCount)
-		}
-
-		// Get post owner information
-		postOwner, err := s.userService.GetUserByID(ctx, dbPost.UserID)
-		if err == nil && postOwner != nil {
-			post.Username = postOwner.Username
-			post.DisplayName = postOwner.DisplayName
-			post.AvatarUrl = postOwner.AvatarUrl
-		}
-
-		// Check if the current user has liked the post
-		if userUUID, ok := ctx.Value("user_id").(pgtype.UUID); ok && userUUID.Valid {
-			hasLiked, err := s.HasLiked(ctx, dbPost.ID, userUUID)
-			if err == nil {
-				post.HasLiked = hasLiked
-			}
-		}
+	// Convert to model posts
+	posts := pagination.Map(pagination.NewPage(dbPosts, page, func(p db.GetUserBookmarkedPostsRow) pagination.Cursor {
+		return pagination.NewCursor(p.BookmarkedAt, p.ID)
+	}), func(p db.GetUserBookmarkedPostsRow) *model.Post {
+		return s.dbPostToModelPost(p)
+	})
 
-		posts[i] = post
+	// Fill in the viewer's state for the whole page; the viewer is the
+	// bookmark owner
+	if err := s.hydrateViewerState(ctx, s.queries, pgtype.UUID{Bytes: userUUID, Valid: true}, posts.Data); err != nil {
+		return nil, err
 	}
 
 	return posts, nil
@@ -783,184 +1641,375 @@ func (s *PostService) GetUserBookmarks(ctx context.Context, userID string, limit
 
 // Helper function to convert db.Post to model.Post
 func (s *PostService) dbPostToModelPost(dbPost interface{}) *model.Post {
-	// Get post stats to include reply count
+	// Reply counts and viewer state are filled in by hydrateViewerState
 	var post model.Post
 
 	switch p := dbPost.(type) {
 	case db.Post:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+		}
+	case db.GetUserBookmarkedPostsRow:
+		post = model.Post{
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	case db.CreatePostRow:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
-			Username:      p.Username,
-			DisplayName:   p.DisplayName,
-			AvatarUrl:     p.AvatarUrl,
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	case db.GetPostByIDRow:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
-			Username:      p.Username,
-			DisplayName:   p.DisplayName,
-			AvatarUrl:     p.AvatarUrl,
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	case db.GetAllPostsRow:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
-			Username:      p.Username,
-			DisplayName:   p.DisplayName,
-			AvatarUrl:     p.AvatarUrl,
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	case db.GetPostsByUserIDRow:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
-			Username:      p.Username,
-			DisplayName:   p.DisplayName,
-			AvatarUrl:     p.AvatarUrl,
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
+		}
+		if p.RepostedByID.Valid {
+			post.RepostedBy = &model.RepostedBy{
+				ID:          p.RepostedByID,
+				Username:    p.RepostedByUsername.String,
+				DisplayName: p.RepostedByDisplayName,
+			}
+		}
+	case db.GetUserFeedRow:
+		post = model.Post{
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
+		}
+		if p.RepostedByID.Valid {
+			post.RepostedBy = &model.RepostedBy{
+				ID:          p.RepostedByID,
+				Username:    p.RepostedByUsername.String,
+				DisplayName: p.RepostedByDisplayName,
+			}
+		}
+	case db.GetPostQuotesRow:
+		post = model.Post{
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
+		}
+	case db.GetThreadAncestorsRow:
+		post = model.Post{
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
+		}
+	case db.GetThreadDescendantsRow:
+		post = model.Post{
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	case db.GetPostRepliesRow:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
-			Username:      p.Username,
-			DisplayName:   p.DisplayName,
-			AvatarUrl:     p.AvatarUrl,
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	case db.GetUserLikedPostsRow:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
-			Username:      p.Username,
-			DisplayName:   p.DisplayName,
-			AvatarUrl:     p.AvatarUrl,
-			HasLiked:      true, // Since this is a liked post
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	case db.GetUserRepliesRow:
 		post = model.Post{
-			ID:            p.ID,
-			UserID:        p.UserID,
-			Content:       p.Content,
-			CreatedAt:     p.CreatedAt,
-			UpdatedAt:     p.UpdatedAt,
-			DeletedAt:     p.DeletedAt,
-			IsPrivate:     p.IsPrivate,
-			ReplyToPostID: p.ReplyToPostID,
-			AllowReplies:  p.AllowReplies,
-			MediaUrls:     p.MediaUrls,
-			LikeCount:     p.LikeCount,
-			RepostCount:   p.RepostCount,
-			Username:      p.Username,
-			DisplayName:   p.DisplayName,
-			AvatarUrl:     p.AvatarUrl,
-			HasLiked:      false, // Will be updated later if needed
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
+		}
+	case db.GetPostsWithHashtagRow:
+		post = model.Post{
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
+		}
+	case db.GetUserMentionsRow:
+		post = model.Post{
+			ID:             p.ID,
+			UserID:         p.UserID,
+			Content:        p.Content,
+			CreatedAt:      p.CreatedAt,
+			UpdatedAt:      p.UpdatedAt,
+			DeletedAt:      p.DeletedAt,
+			IsPrivate:      p.IsPrivate,
+			ReplyToPostID:  p.ReplyToPostID,
+			ConversationID: p.ConversationID,
+			ReplyPolicy:    model.ReplyPolicy(p.ReplyPolicy),
+			MediaUrls:      p.MediaUrls,
+			LikeCount:      p.LikeCount,
+			RepostCount:    p.RepostCount,
+			QuotedPostID:   p.QuotedPostID,
+			EditedAt:       p.EditedAt,
+			EditCount:      p.EditCount,
+			Username:       p.Username,
+			DisplayName:    p.DisplayName,
+			AvatarUrl:      p.AvatarUrl,
 		}
 	default:
 		return nil
 	}
 
-	// Get post stats to include reply count
-	qtx := db.New(s.db)
-	stats, err := qtx.GetPostStats(context.Background(), post.ID)
-	if err == nil {
-		post.ReplyCount = int32(stats.ReplyCount)
-	}
-
 	return &post
 }
 
-// Update CreateReply to create notification
-func (s *PostService) CreateReply(ctx context.Context, reply *model.Post) (*model.Post, error) {
-	// Create reply
-	createdReply, err := s.CreatePost(ctx, reply)
-	if err != nil {
-		return nil, err
-	}
-
-	// Get parent post to notify owner
-	parentPost, err := s.queries.GetPostByID(ctx, pgtype.UUID{Bytes: reply.ReplyToPostID.Bytes, Valid: true})
+// notifyReply lets the author of the post a reply answers know about it, and
+// the thread's author when the reply is further down their thread
+func (s *PostService) notifyReply(ctx context.Context, reply *model.Post) {
+	parentPost, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{
+		ID:       reply.ReplyToPostID,
+		ViewerID: reply.UserID,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error getting parent post: %w", err)
+		if err != pgx.ErrNoRows {
+			log.Printf("Error getting parent post for notification: %v", err)
+		}
+		return
 	}
 
 	// Create notification for parent post owner
 	if !bytes.Equal(parentPost.UserID.Bytes[:], reply.UserID.Bytes[:]) { // Don't notify if user replies to their own post
-		replyID := createdReply.ID.Bytes
+		replyID := reply.ID.Bytes
 		parentID := reply.ReplyToPostID.Bytes
 		_, err = s.notificationService.CreateNotification(ctx, parentPost.UserID.Bytes, reply.UserID.Bytes, &replyID, &parentID, model.NotificationTypeReply)
 		if err != nil {
@@ -969,34 +2018,224 @@ func (s *PostService) CreateReply(ctx context.Context, reply *model.Post) (*mode
 		}
 	}
 
-	return createdReply, nil
+	// Let the thread's author know about replies further down their thread
+	s.notifyThreadReply(ctx, reply, parentPost.UserID)
+}
+
+// notifyThreadReply sends a thread_reply notification to the author of the
+// thread a reply belongs to, unless they wrote the reply or the post it
+// replies to, which already notifies them
+func (s *PostService) notifyThreadReply(ctx context.Context, reply *model.Post, parentAuthorID pgtype.UUID) {
+	rootID := reply.ConversationID
+	if !rootID.Valid || rootID.Bytes == reply.ReplyToPostID.Bytes {
+		return
+	}
+
+	root, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{
+		ID:       rootID,
+		ViewerID: reply.UserID,
+	})
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Printf("Error getting thread root for notification: %v", err)
+		}
+		return
+	}
+	if root.UserID.Bytes == reply.UserID.Bytes || root.UserID.Bytes == parentAuthorID.Bytes {
+		return
+	}
+
+	replyID := reply.ID.Bytes
+	_, err = s.notificationService.CreateNotification(ctx, root.UserID.Bytes, reply.UserID.Bytes, &replyID, &rootID.Bytes, model.NotificationTypeThreadReply)
+	if err != nil {
+		// Log error but don't fail the reply operation
+		log.Printf("Error creating thread reply notification: %v", err)
+	}
 }
 
-// Update CreateRepost to create notification
+// CreateRepost reposts a post for a user and notifies the post owner
 func (s *PostService) CreateRepost(ctx context.Context, postID, userID [16]byte) error {
-	// Get post to check owner
-	post, err := s.queries.GetPostByID(ctx, pgtype.UUID{Bytes: postID, Valid: true})
+	// Start a transaction so the repost and counter stay in sync
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	// Get post to check owner; posts the user can't see can't be reposted
+	post, err := qtx.GetPostByID(ctx, db.GetPostByIDParams{
+		ID:       pgtype.UUID{Bytes: postID, Valid: true},
+		ViewerID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return fmt.Errorf("post not found")
+		}
 		return fmt.Errorf("error getting post: %w", err)
 	}
 
+	if bytes.Equal(post.UserID.Bytes[:], userID[:]) {
+		return fmt.Errorf("cannot repost your own post")
+	}
+
 	// Create repost
-	_, err = s.queries.RepostPost(ctx, db.RepostPostParams{
+	_, err = qtx.RepostPost(ctx, db.RepostPostParams{
 		PostID:     pgtype.UUID{Bytes: postID, Valid: true},
 		ReposterID: pgtype.UUID{Bytes: userID, Valid: true},
 	})
 	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return fmt.Errorf("post already reposted")
+		}
 		return fmt.Errorf("error creating repost: %w", err)
 	}
 
+	// Increment the counter
+	_, err = qtx.IncrementRepostCount(ctx, pgtype.UUID{Bytes: postID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to increment repost count: %w", err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Create notification for post owner
-	if !bytes.Equal(post.UserID.Bytes[:], userID[:]) { // Don't notify if user reposts their own post
-		_, err = s.notificationService.CreateNotification(ctx, post.UserID.Bytes, userID, &postID, nil, model.NotificationTypeRepost)
-		if err != nil {
-			// Log error but don't fail the repost operation
-			log.Printf("Error creating repost notification: %v", err)
-		}
+	_, err = s.notificationService.CreateNotification(ctx, post.UserID.Bytes, userID, &postID, nil, model.NotificationTypeRepost)
+	if err != nil {
+		// Log error but don't fail the repost operation
+		log.Printf("Error creating repost notification: %v", err)
+	}
+
+	s.publishCounts(ctx, post.ID)
+
+	// Put the repost in the reposter's followers' timelines
+	reposter, err := s.queries.GetUserByID(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		log.Printf("Error getting reposter: %v", err)
+		return nil
+	}
+	s.publish(ctx, realtime.AuthorTopic(userID), realtime.EventPost, PostEvent{
+		ID: post.ID,
+		RepostedBy: &model.RepostedBy{
+			ID:          reposter.ID,
+			Username:    reposter.Username,
+			DisplayName: reposter.DisplayName,
+		},
+	})
+
+	return nil
+}
+
+// DeleteRepost removes a user's repost of a post
+func (s *PostService) DeleteRepost(ctx context.Context, postID, userID pgtype.UUID) error {
+	// Start a transaction so the repost and counter stay in sync
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	// Delete the repost
+	rows, err := qtx.UnrepostPost(ctx, db.UnrepostPostParams{
+		PostID:     postID,
+		ReposterID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete repost: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("repost not found")
+	}
+
+	// Decrement the counter
+	_, err = qtx.DecrementRepostCount(ctx, postID)
+	if err != nil && err != pgx.ErrNoRows {
+		return fmt.Errorf("failed to decrement repost count: %w", err)
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	s.publishCounts(ctx, postID)
+
 	return nil
 }
+
+// publish sends a realtime event. Failures are logged; realtime delivery never
+// fails the operation that caused it.
+func (s *PostService) publish(ctx context.Context, topic, eventType string, data any) {
+	if err := s.hub.Publish(ctx, topic, eventType, data); err != nil {
+		log.Printf("Error publishing %s event: %v", eventType, err)
+	}
+}
+
+// publishCounts sends a post's current engagement counts to its subscribers
+func (s *PostService) publishCounts(ctx context.Context, postID pgtype.UUID) {
+	counts, err := s.queries.GetPostCounts(ctx, postID)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Printf("Error getting post counts: %v", err)
+		}
+		return
+	}
+	s.publish(ctx, realtime.PostTopic(postID.Bytes), realtime.EventPostCounts, counts)
+}
+
+// PostReposter is a user who reposted a post
+type PostReposter struct {
+	ID          pgtype.UUID        `json:"id"`
+	Username    string             `json:"username"`
+	DisplayName pgtype.Text        `json:"display_name"`
+	AvatarURL   pgtype.Text        `json:"avatar_url"`
+	IsPrivate   bool               `json:"is_private"`
+	RepostedAt  pgtype.Timestamptz `json:"reposted_at"`
+}
+
+// GetPostReposters gets the users who reposted a post, most recent first
+func (s *PostService) GetPostReposters(ctx context.Context, postID pgtype.UUID, page pagination.Params) (*pagination.Page[PostReposter], error) {
+	// Make sure the post exists and the viewer can see it
+	viewerID, _ := ctx.Value("user_id").(pgtype.UUID)
+	if _, err := s.queries.GetPostByID(ctx, db.GetPostByIDParams{ID: postID, ViewerID: viewerID}); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("error getting post: %w", err)
+	}
+
+	reposters, err := s.queries.GetPostReposters(ctx, db.GetPostRepostersParams{
+		PostID:          postID,
+		ViewerID:        viewerID,
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting reposters: %w", err)
+	}
+
+	rows := pagination.NewPage(reposters, page, func(r db.GetPostRepostersRow) pagination.Cursor {
+		return pagination.NewCursor(r.RepostedAt, r.ID)
+	})
+
+	return pagination.Map(rows, func(r db.GetPostRepostersRow) PostReposter {
+		return PostReposter{
+			ID:          r.ID,
+			Username:    r.Username,
+			DisplayName: r.DisplayName,
+			AvatarURL:   r.AvatarUrl,
+			IsPrivate:   r.IsPrivate,
+			RepostedAt:  r.RepostedAt,
+		}
+	}), nil
+}
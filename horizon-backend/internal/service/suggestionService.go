@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"horizon-backend/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// suggestionLimit is how many suggestions are kept for each user
+	suggestionLimit int32 = 50
+
+	// suggestionReasonUsers is how many followed accounts a reason names
+	suggestionReasonUsers int32 = 2
+
+	// suggestionActiveWindow is how far back posts count as recent activity
+	suggestionActiveWindow = 14 * 24 * time.Hour
+
+	// suggestionBatchSize is how many users' suggestions are recomputed per
+	// claimed batch
+	suggestionBatchSize int32 = 100
+)
+
+// SuggestionService recommends accounts to follow. Suggestions are computed
+// from the follows graph by a background job and stored, so reading them is
+// a single query.
+type SuggestionService struct {
+	queries      *db.Queries
+	db           *pgxpool.Pool
+	refreshAfter time.Duration
+}
+
+// NewSuggestionService creates a suggestion service. Each user's suggestions
+// are recomputed once they're older than refreshAfter.
+func NewSuggestionService(queries *db.Queries, pool *pgxpool.Pool, refreshAfter time.Duration) *SuggestionService {
+	return &SuggestionService{
+		queries:      queries,
+		db:           pool,
+		refreshAfter: refreshAfter,
+	}
+}
+
+// SuggestionReasonType is why an account is suggested
+type SuggestionReasonType string
+
+const (
+	// SuggestionReasonFollowedBy accounts are followed by accounts the user
+	// follows
+	SuggestionReasonFollowedBy SuggestionReasonType = "followed_by"
+
+	// SuggestionReasonFollowedByFollowers accounts are followed by the
+	// user's followers
+	SuggestionReasonFollowedByFollowers SuggestionReasonType = "followed_by_followers"
+
+	// SuggestionReasonPopular accounts are among the most followed, for
+	// users the follows graph has nothing for yet
+	SuggestionReasonPopular SuggestionReasonType = "popular"
+)
+
+// SuggestionReason explains a suggestion. Users names a few of the Count
+// accounts behind a followed_by reason; Text reads e.g. "Followed by X and Y".
+type SuggestionReason struct {
+	Type  SuggestionReasonType   `json:"type"`
+	Text  string                 `json:"text"`
+	Users []SuggestionReasonUser `json:"users"`
+	Count int32                  `json:"count"`
+}
+
+type SuggestionReasonUser struct {
+	ID          pgtype.UUID `json:"id"`
+	Username    string      `json:"username"`
+	DisplayName pgtype.Text `json:"display_name"`
+	AvatarURL   pgtype.Text `json:"avatar_url"`
+}
+
+type FollowSuggestion struct {
+	ID          pgtype.UUID      `json:"id"`
+	Username    string           `json:"username"`
+	DisplayName pgtype.Text      `json:"display_name"`
+	AvatarURL   pgtype.Text      `json:"avatar_url"`
+	Bio         pgtype.Text      `json:"bio"`
+	IsPrivate   bool             `json:"is_private"`
+	Reason      SuggestionReason `json:"reason"`
+}
+
+// GetSuggestions gets up to limit accounts for a user to follow, best first.
+// Users the job hasn't reached yet, such as new accounts, have theirs
+// computed on the spot.
+func (s *SuggestionService) GetSuggestions(ctx context.Context, userID pgtype.UUID, limit int32) ([]FollowSuggestion, error) {
+	refreshed, err := s.queries.HasFollowSuggestionsRefreshed(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check follow suggestions: %w", err)
+	}
+	if !refreshed {
+		if err := s.Refresh(ctx, userID); err != nil {
+			return nil, err
+		}
+		err := s.queries.MarkFollowSuggestionsRefreshed(ctx, db.MarkFollowSuggestionsRefreshedParams{
+			UserIds:     []pgtype.UUID{userID},
+			RefreshedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark follow suggestions refreshed: %w", err)
+		}
+	}
+
+	rows, err := s.queries.GetFollowSuggestions(ctx, db.GetFollowSuggestionsParams{
+		UserID:    userID,
+		PageLimit: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow suggestions: %w", err)
+	}
+
+	// Load the accounts named in the reasons in one query
+	var reasonIDs []pgtype.UUID
+	for _, row := range rows {
+		reasonIDs = append(reasonIDs, row.MutualIds...)
+	}
+	reasonUsers := make(map[[16]byte]SuggestionReasonUser, len(reasonIDs))
+	if len(reasonIDs) > 0 {
+		users, err := s.queries.GetSuggestionReasonUsers(ctx, db.GetSuggestionReasonUsersParams{
+			UserIds:  reasonIDs,
+			ViewerID: userID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get suggestion reasons: %w", err)
+		}
+		for _, u := range users {
+			reasonUsers[u.ID.Bytes] = SuggestionReasonUser{
+				ID:          u.ID,
+				Username:    u.Username,
+				DisplayName: u.DisplayName,
+				AvatarURL:   u.AvatarUrl,
+			}
+		}
+	}
+
+	suggestions := make([]FollowSuggestion, len(rows))
+	for i, row := range rows {
+		suggestions[i] = FollowSuggestion{
+			ID:          row.ID,
+			Username:    row.Username,
+			DisplayName: row.DisplayName,
+			AvatarURL:   row.AvatarUrl,
+			Bio:         row.Bio,
+			IsPrivate:   row.IsPrivate,
+			Reason:      suggestionReason(row, reasonUsers),
+		}
+	}
+
+	return suggestions, nil
+}
+
+// Refresh recomputes a user's suggestions
+func (s *SuggestionService) Refresh(ctx context.Context, userID pgtype.UUID) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	if err := qtx.DeleteFollowSuggestions(ctx, userID); err != nil {
+		return fmt.Errorf("failed to clear follow suggestions: %w", err)
+	}
+
+	err = qtx.InsertFollowSuggestions(ctx, db.InsertFollowSuggestionsParams{
+		UserID:          userID,
+		ReasonUserLimit: suggestionReasonUsers,
+		SuggestionLimit: suggestionLimit,
+		ActiveSince:     pgtype.Timestamptz{Time: time.Now().Add(-suggestionActiveWindow), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compute follow suggestions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Run recomputes stale suggestions every interval until ctx is done
+func (s *SuggestionService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RefreshStale(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error refreshing follow suggestions: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshStale recomputes every user's suggestions that are due and returns
+// how many users were refreshed. Users are marked refreshed before their
+// suggestions are computed, so servers running this at the same time don't
+// repeat the work; a user whose refresh fails keeps their old suggestions
+// until the next round.
+func (s *SuggestionService) RefreshStale(ctx context.Context) (int, error) {
+	refreshed := 0
+	for {
+		userIDs, err := s.claimStale(ctx)
+		if err != nil {
+			return refreshed, err
+		}
+
+		for _, userID := range userIDs {
+			if err := s.Refresh(ctx, userID); err != nil {
+				log.Printf("Error refreshing follow suggestions for user %x: %v", userID.Bytes, err)
+				continue
+			}
+			refreshed++
+		}
+
+		if len(userIDs) < int(suggestionBatchSize) {
+			return refreshed, nil
+		}
+	}
+}
+
+// claimStale locks a batch of users with stale suggestions and marks them
+// refreshed
+func (s *SuggestionService) claimStale(ctx context.Context) ([]pgtype.UUID, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := db.New(tx)
+
+	now := time.Now()
+	userIDs, err := qtx.GetStaleFollowSuggestionUsers(ctx, db.GetStaleFollowSuggestionUsersParams{
+		StaleBefore: pgtype.Timestamptz{Time: now.Add(-s.refreshAfter), Valid: true},
+		BatchLimit:  suggestionBatchSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale follow suggestions: %w", err)
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	err = qtx.MarkFollowSuggestionsRefreshed(ctx, db.MarkFollowSuggestionsRefreshedParams{
+		UserIds:     userIDs,
+		RefreshedAt: pgtype.Timestamptz{Time: now, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark follow suggestions refreshed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// suggestionReason explains a suggestion by its strongest signal. Accounts
+// named in it that the user can no longer see are left out of the names but
+// still counted.
+func suggestionReason(row db.GetFollowSuggestionsRow, reasonUsers map[[16]byte]SuggestionReasonUser) SuggestionReason {
+	switch {
+	case row.MutualCount > 0:
+		users := []SuggestionReasonUser{}
+		for _, id := range row.MutualIds {
+			if u, ok := reasonUsers[id.Bytes]; ok {
+				users = append(users, u)
+			}
+		}
+		return SuggestionReason{
+			Type:  SuggestionReasonFollowedBy,
+			Text:  followedByText(users, row.MutualCount),
+			Users: users,
+			Count: row.MutualCount,
+		}
+
+	case row.SharedFollowerCount > 0:
+		return SuggestionReason{
+			Type:  SuggestionReasonFollowedByFollowers,
+			Text:  "Followed by people who follow you",
+			Users: []SuggestionReasonUser{},
+			Count: row.SharedFollowerCount,
+		}
+
+	default:
+		return SuggestionReason{
+			Type:  SuggestionReasonPopular,
+			Text:  "Popular on Horizon",
+			Users: []SuggestionReasonUser{},
+		}
+	}
+}
+
+// followedByText reads e.g. "Followed by X", "Followed by X and Y" or
+// "Followed by X, Y and 3 others"
+func followedByText(users []SuggestionReasonUser, count int32) string {
+	if len(users) == 0 {
+		if count == 1 {
+			return "Followed by 1 account you follow"
+		}
+		return fmt.Sprintf("Followed by %d accounts you follow", count)
+	}
+
+	names := make([]string, len(users))
+	for i, u := range users {
+		names[i] = nameOrUsername(u.DisplayName, u.Username)
+	}
+
+	switch others := int(count) - len(names); {
+	case others <= 0:
+		return "Followed by " + strings.Join(names, " and ")
+	case others == 1:
+		return "Followed by " + strings.Join(names, ", ") + " and 1 other"
+	default:
+		return fmt.Sprintf("Followed by %s and %d others", strings.Join(names, ", "), others)
+	}
+}
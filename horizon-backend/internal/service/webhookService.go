@@ -0,0 +1,486 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"horizon-backend/internal/db"
+	"horizon-backend/internal/model"
+	"horizon-backend/internal/pagination"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// webhookBatchSize is how many due deliveries are claimed at a time
+	webhookBatchSize = 20
+
+	// webhookWorkers is how many deliveries are attempted at once
+	webhookWorkers = 4
+
+	// webhookTimeout bounds a single attempt
+	webhookTimeout = 10 * time.Second
+
+	// webhookLease is how long a claimed delivery is kept from other
+	// servers; longer than an attempt can take
+	webhookLease = time.Minute
+
+	// webhookMaxAttempts is how many times a delivery is tried before it's
+	// marked failed
+	webhookMaxAttempts = 8
+
+	// Retries wait webhookRetryDelay, doubling after each attempt up to
+	// webhookMaxRetryDelay
+	webhookRetryDelay    = time.Minute
+	webhookMaxRetryDelay = 6 * time.Hour
+
+	// webhookMaxErrorLength caps the error kept in the delivery log
+	webhookMaxErrorLength = 500
+)
+
+var errWebhookPrivateAddress = errors.New("webhook address is not public")
+
+// WebhookService manages users' webhooks and delivers their events. Events
+// are queued in the database and sent by Run, which retries failed
+// deliveries with backoff.
+type WebhookService struct {
+	queries *db.Queries
+	client  *http.Client
+}
+
+// NewWebhookService creates a webhook service. Unless allowPrivateNetworks
+// is set, deliveries to loopback, private and link-local addresses are
+// refused so webhooks can't reach internal services.
+func NewWebhookService(queries *db.Queries, allowPrivateNetworks bool) *WebhookService {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	if !allowPrivateNetworks {
+		// Checked on the resolved address, so hostnames pointing at
+		// internal addresses are caught too
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+				ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+				return errWebhookPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &WebhookService{
+		queries: queries,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   webhookTimeout,
+			// Redirects aren't followed; a 3xx counts as a failed attempt
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// WebhookPayload is the body of a webhook delivery
+type WebhookPayload struct {
+	Event     model.WebhookEvent `json:"event"`
+	CreatedAt time.Time          `json:"created_at"`
+	Data      any                `json:"data"`
+}
+
+// PostDeletedData is the data of post.deleted events
+type PostDeletedData struct {
+	PostID pgtype.UUID `json:"post_id"`
+}
+
+// PostLikedData is the data of post.liked events
+type PostLikedData struct {
+	PostID pgtype.UUID `json:"post_id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// UserFollowedData is the data of user.followed events
+type UserFollowedData struct {
+	FollowerID pgtype.UUID `json:"follower_id"`
+	FollowedID pgtype.UUID `json:"followed_id"`
+	IsAccepted bool        `json:"is_accepted"`
+}
+
+// Emit queues an event for delivery to the webhooks of the user it concerns.
+// Failures are logged; webhooks never fail the action that caused them.
+func (s *WebhookService) Emit(ctx context.Context, userID [16]byte, event model.WebhookEvent, data any) {
+	payload, err := json.Marshal(WebhookPayload{
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Error encoding %s webhook payload: %v", event, err)
+		return
+	}
+
+	_, err = s.queries.EnqueueWebhookDeliveries(ctx, db.EnqueueWebhookDeliveriesParams{
+		Event:   string(event),
+		Payload: payload,
+		UserID:  pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		log.Printf("Error queueing %s webhook deliveries: %v", event, err)
+	}
+}
+
+// CreateWebhook registers a webhook for a user. The returned webhook carries
+// the signing secret, which isn't shown again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID [16]byte, webhookURL string, events []model.WebhookEvent) (*model.Webhook, error) {
+	if err := validateWebhook(webhookURL, events); err != nil {
+		return nil, err
+	}
+
+	user := pgtype.UUID{Bytes: userID, Valid: true}
+	count, err := s.queries.CountWebhooks(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if count >= model.MaxWebhooks {
+		return nil, fmt.Errorf("too many webhooks")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	dbWebhook, err := s.queries.CreateWebhook(ctx, db.CreateWebhookParams{
+		UserID: user,
+		Url:    webhookURL,
+		Secret: "whsec_" + hex.EncodeToString(secret),
+		Events: webhookEventStrings(events),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	webhook := dbWebhookToModel(dbWebhook)
+	webhook.Secret = dbWebhook.Secret
+	return webhook, nil
+}
+
+// GetWebhooks retrieves a user's webhooks, newest first
+func (s *WebhookService) GetWebhooks(ctx context.Context, userID [16]byte) ([]*model.Webhook, error) {
+	dbWebhooks, err := s.queries.GetWebhooks(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	webhooks := make([]*model.Webhook, len(dbWebhooks))
+	for i, w := range dbWebhooks {
+		webhooks[i] = dbWebhookToModel(w)
+	}
+	return webhooks, nil
+}
+
+// GetWebhook retrieves one of a user's webhooks
+func (s *WebhookService) GetWebhook(ctx context.Context, userID, webhookID [16]byte) (*model.Webhook, error) {
+	dbWebhook, err := s.queries.GetWebhook(ctx, db.GetWebhookParams{
+		ID:     pgtype.UUID{Bytes: webhookID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return dbWebhookToModel(dbWebhook), nil
+}
+
+// UpdateWebhook changes the URL, events and active state of a user's webhook.
+// Inactive webhooks aren't sent new events.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userID, webhookID [16]byte, webhookURL string, events []model.WebhookEvent, active bool) (*model.Webhook, error) {
+	if err := validateWebhook(webhookURL, events); err != nil {
+		return nil, err
+	}
+
+	dbWebhook, err := s.queries.UpdateWebhook(ctx, db.UpdateWebhookParams{
+		Url:    webhookURL,
+		Events: webhookEventStrings(events),
+		Active: active,
+		ID:     pgtype.UUID{Bytes: webhookID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return dbWebhookToModel(dbWebhook), nil
+}
+
+// DeleteWebhook removes a user's webhook along with its deliveries
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, webhookID [16]byte) error {
+	rows, err := s.queries.DeleteWebhook(ctx, db.DeleteWebhookParams{
+		ID:     pgtype.UUID{Bytes: webhookID, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+	return nil
+}
+
+// GetDeliveries retrieves the delivery log of a user's webhook, newest first
+func (s *WebhookService) GetDeliveries(ctx context.Context, userID, webhookID [16]byte, page pagination.Params) (*pagination.Page[*model.WebhookDelivery], error) {
+	if _, err := s.GetWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.queries.GetWebhookDeliveries(ctx, db.GetWebhookDeliveriesParams{
+		WebhookID:       pgtype.UUID{Bytes: webhookID, Valid: true},
+		BeforeCreatedAt: page.BeforeCreatedAt(),
+		BeforeID:        page.BeforeID(),
+		AfterCreatedAt:  page.AfterCreatedAt(),
+		AfterID:         page.AfterID(),
+		Ascending:       page.Ascending(),
+		PageLimit:       page.QueryLimit(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	rows := pagination.NewPage(deliveries, page, func(d db.WebhookDelivery) pagination.Cursor {
+		return pagination.NewCursor(d.CreatedAt, d.ID)
+	})
+
+	return pagination.Map(rows, func(d db.WebhookDelivery) *model.WebhookDelivery {
+		return &model.WebhookDelivery{
+			ID:             d.ID,
+			WebhookID:      d.WebhookID,
+			Event:          model.WebhookEvent(d.Event),
+			Payload:        d.Payload,
+			Status:         model.WebhookDeliveryStatus(d.Status),
+			Attempts:       d.Attempts,
+			NextAttemptAt:  d.NextAttemptAt,
+			LastAttemptAt:  d.LastAttemptAt,
+			ResponseStatus: d.ResponseStatus,
+			Error:          d.Error,
+			CreatedAt:      d.CreatedAt,
+		}
+	}), nil
+}
+
+// Run delivers queued events every interval until ctx is done
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error delivering webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is due. Servers can run it at the
+// same time; each delivery is claimed by one of them.
+func (s *WebhookService) DeliverDue(ctx context.Context) error {
+	for {
+		now := time.Now()
+		deliveries, err := s.queries.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+			LeaseUntil: pgtype.Timestamptz{Time: now.Add(webhookLease), Valid: true},
+			DueAt:      pgtype.Timestamptz{Time: now, Valid: true},
+			BatchLimit: webhookBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+
+		var wg sync.WaitGroup
+		workers := make(chan struct{}, webhookWorkers)
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			workers <- struct{}{}
+			go func(delivery db.ClaimWebhookDeliveriesRow) {
+				defer wg.Done()
+				defer func() { <-workers }()
+				s.attempt(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// attempt sends a claimed delivery and records the outcome, scheduling a
+// retry if it failed and attempts remain
+func (s *WebhookService) attempt(ctx context.Context, delivery db.ClaimWebhookDeliveriesRow) {
+	status, err := s.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// Shutting down; the delivery is retried when its lease ends
+		return
+	}
+
+	params := db.UpdateWebhookDeliveryParams{
+		ID:             delivery.ID,
+		Status:         db.WebhookDeliveryStatusSucceeded,
+		NextAttemptAt:  pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		ResponseStatus: pgtype.Int4{Int32: int32(status), Valid: status != 0},
+	}
+	if err != nil {
+		message := err.Error()
+		if len(message) > webhookMaxErrorLength {
+			message = message[:webhookMaxErrorLength]
+		}
+		params.Error = pgtype.Text{String: message, Valid: true}
+
+		if delivery.Attempts >= webhookMaxAttempts {
+			params.Status = db.WebhookDeliveryStatusFailed
+		} else {
+			params.Status = db.WebhookDeliveryStatusPending
+			params.NextAttemptAt.Time = time.Now().Add(webhookRetryDelayAfter(delivery.Attempts))
+		}
+	}
+
+	if err := s.queries.UpdateWebhookDelivery(ctx, params); err != nil {
+		log.Printf("Error recording webhook delivery: %v", err)
+	}
+}
+
+// send posts a delivery to its webhook and returns the response status, or
+// 0 if there was no response
+func (s *WebhookService) send(ctx context.Context, delivery db.ClaimWebhookDeliveriesRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Horizon-Webhooks")
+	req.Header.Set("X-Horizon-Event", delivery.Event)
+	req.Header.Set("X-Horizon-Delivery", uuid.UUID(delivery.ID.Bytes).String())
+	req.Header.Set("X-Horizon-Timestamp", timestamp)
+	req.Header.Set("X-Horizon-Signature", "sha256="+SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload computes the hex HMAC-SHA256 of "<timestamp>.<payload>"
+// with a webhook's secret, as sent in X-Horizon-Signature
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelayAfter is how long to wait before retrying a delivery that
+// has been attempted the given number of times
+func webhookRetryDelayAfter(attempts int32) time.Duration {
+	delay := webhookRetryDelay
+	for i := int32(1); i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxRetryDelay {
+		delay = webhookMaxRetryDelay
+	}
+	return delay
+}
+
+func validateWebhook(webhookURL string, events []model.WebhookEvent) error {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook url")
+	}
+
+	if len(events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return fmt.Errorf("invalid webhook event: %s", event)
+		}
+	}
+
+	return nil
+}
+
+func webhookEventStrings(events []model.WebhookEvent) []string {
+	// Duplicates would only be stored, never sent twice, but keep the list
+	// tidy
+	seen := make(map[model.WebhookEvent]bool, len(events))
+	out := make([]string, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			out = append(out, string(event))
+		}
+	}
+	return out
+}
+
+func dbWebhookToModel(w db.Webhook) *model.Webhook {
+	events := make([]model.WebhookEvent, len(w.Events))
+	for i, event := range w.Events {
+		events[i] = model.WebhookEvent(event)
+	}
+
+	return &model.Webhook{
+		ID:        w.ID,
+		URL:       w.Url,
+		Events:    events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"event":"post.created","data":{}}`)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   []byte
+		want      string
+	}{
+		{"payload", "whsec_test", "1700000000", payload, "839a9e348a99903447548535cae2071ae07e06b74523bde7e489da4d6fbbf809"},
+		{"empty payload", "whsec_test", "1700000000", nil, "5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc"},
+		{"other secret", "other", "1700000000", payload, "4b4f99f21bc3a23aaa4c7a39fee6e7eb72544b51c900ca339fa8f5aa4f9148c0"},
+		{"other timestamp", "whsec_test", "1700000001", payload, "b463e9e6db47bfffbddc7126c4ef82cd7b88853f6946b02697978c0bad085ae6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhookPayload(tt.secret, tt.timestamp, tt.payload); got != tt.want {
+				t.Errorf("SignWebhookPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookRetryDelayAfter(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{5, 16 * time.Minute},
+		{8, 128 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, webhookMaxRetryDelay},
+		{1000, webhookMaxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		if got := webhookRetryDelayAfter(tt.attempts); got != tt.want {
+			t.Errorf("webhookRetryDelayAfter(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
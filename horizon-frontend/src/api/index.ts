@@ -2,6 +2,7 @@ import axios from 'axios';
 import { 
   User, 
   Post, 
+  Page,
   CreatePostRequest, 
   LikePostRequest, 
   CreateUserRequest,
@@ -132,9 +133,11 @@ export const postApi = {
     };
   },
   
-  getReplies: async (postId: string): Promise<Post[]> => {
-    const response = await api.get<Post[]>(`/posts/${postId}/replies`);
-    return response.data.map(post => {
+  getReplies: async (postId: string, cursor?: string): Promise<Page<Post>> => {
+    const response = await api.get<Page<Post>>(`/posts/${postId}/replies`, {
+      params: { cursor }
+    });
+    return { ...response.data, data: response.data.data.map(post => {
       const { username, display_name, avatar_url, ...postData } = post;
       return {
         ...postData,
@@ -153,12 +156,14 @@ export const postApi = {
           email_verified: false
         }
       };
-    });
+    }) };
   },
   
-  getFeedPosts: async (): Promise<Post[]> => {
-    const response = await api.get<Post[]>('/posts');
-    return response.data.map(post => {
+  getFeedPosts: async (cursor?: string): Promise<Page<Post>> => {
+    const response = await api.get<Page<Post>>('/posts', {
+      params: { cursor }
+    });
+    return { ...response.data, data: response.data.data.map(post => {
       const { username, display_name, avatar_url, ...postData } = post;
       return {
         ...postData,
@@ -177,12 +182,14 @@ export const postApi = {
           email_verified: false
         }
       };
-    });
+    }) };
   },
   
-  getUserPosts: async (username: string): Promise<Post[]> => {
-    const response = await api.get<Post[]>(`/users/${username}/posts`);
-    return response.data.map(post => {
+  getUserPosts: async (username: string, cursor?: string): Promise<Page<Post>> => {
+    const response = await api.get<Page<Post>>(`/users/${username}/posts`, {
+      params: { cursor }
+    });
+    return { ...response.data, data: response.data.data.map(post => {
       const { username, display_name, avatar_url, ...postData } = post;
       return {
         ...postData,
@@ -201,12 +208,14 @@ export const postApi = {
           email_verified: false
         }
       };
-    });
+    }) };
   },
   
-  getUserReplies: async (username: string): Promise<{ reply: Post; parentPost: Post }[]> => {
-    const response = await api.get<{ reply: Post; parentPost: Post }[]>(`/users/${username}/replies`);
-    return response.data.map(({ reply, parentPost }) => ({
+  getUserReplies: async (username: string, cursor?: string): Promise<Page<{ reply: Post; parentPost: Post }>> => {
+    const response = await api.get<Page<{ reply: Post; parentPost: Post }>>(`/users/${username}/replies`, {
+      params: { cursor }
+    });
+    return { ...response.data, data: response.data.data.map(({ reply, parentPost }) => ({
       reply: {
         ...reply,
         reply_count: reply.reply_count || 0,
@@ -241,12 +250,14 @@ export const postApi = {
           email_verified: false
         }
       }
-    }));
+    })) };
   },
   
-  getUserLikes: async (username: string): Promise<Post[]> => {
-    const response = await api.get<Post[]>(`/users/${username}/likes`);
-    return response.data.map(post => {
+  getUserLikes: async (username: string, cursor?: string): Promise<Page<Post>> => {
+    const response = await api.get<Page<Post>>(`/users/${username}/likes`, {
+      params: { cursor }
+    });
+    return { ...response.data, data: response.data.data.map(post => {
       const { username, display_name, avatar_url, ...postData } = post;
       return {
         ...postData,
@@ -264,7 +275,7 @@ export const postApi = {
           email_verified: false
         }
       };
-    });
+    }) };
   },
   
   likePost: async (postId: string): Promise<void> => {
@@ -332,9 +343,11 @@ export const postApi = {
     }
   },
 
-  getUserBookmarks: async (): Promise<Post[]> => {
-    const response = await api.get<Post[]>('/users/me/bookmarks');
-    return response.data.map(post => {
+  getUserBookmarks: async (cursor?: string): Promise<Page<Post>> => {
+    const response = await api.get<Page<Post>>('/users/me/bookmarks', {
+      params: { cursor }
+    });
+    return { ...response.data, data: response.data.data.map(post => {
       const { username, display_name, avatar_url, ...postData } = post;
       return {
         ...postData,
@@ -352,7 +365,7 @@ export const postApi = {
           email_verified: false
         }
       };
-    });
+    }) };
   },
 };
 
@@ -1,20 +1,44 @@
 import { api } from './index';
+import { Page } from '../types';
 
+export interface NotificationActor {
+  id: string;
+  username: string;
+  display_name: string | null;
+  avatar_url: string | null;
+}
+
+export type NotificationType =
+  | 'like'
+  | 'repost'
+  | 'reply'
+  | 'follow'
+  | 'quote'
+  | 'mention'
+  | 'follow_request'
+  | 'follow_accepted'
+  | 'thread_reply';
+
+// Notification is a group of notifications of one type about the same post,
+// or about the user for follows. actors holds the most recent actors, newest
+// first; actor_count counts all of them.
 export interface Notification {
   id: string;
-  user_id: string;
-  actor_id: string;
-  post_id?: string;
-  parent_post_id?: string;
-  type: 'like' | 'repost' | 'reply' | 'follow';
+  type: NotificationType;
+  post_id: string | null;
   read: boolean;
+  actors: NotificationActor[];
+  actor_count: number;
+  latest_notification_id: string;
+  parent_post_id: string | null;
+  post_content: string | null;
+  parent_post_content: string | null;
   created_at: string;
   updated_at: string;
-  actor_username: string;
-  actor_display_name: string | null;
-  actor_avatar_url: string | null;
-  post_content?: string;
-  parent_post_content?: string;
+  filtered?: {
+    filter_ids: string[];
+    phrases: string[];
+  };
 }
 
 interface UnreadCountResponse {
@@ -23,14 +47,14 @@ interface UnreadCountResponse {
 
 export const notificationApi = {
   // Get notifications
-  getNotifications: async (limit: number = 20, offset: number = 0): Promise<Notification[]> => {
+  getNotifications: async (limit: number = 20, cursor?: string): Promise<Page<Notification>> => {
     try {
-      const response = await api.get<Notification[]>('/notifications', {
-        params: { limit, offset }
+      const response = await api.get<Page<Notification>>('/notifications', {
+        params: { limit, cursor }
       });
-      // If we get a 204 No Content, return an empty array
+      // If we get a 204 No Content, return an empty page
       if (response.status === 204) {
-        return [];
+        return { data: [], next_cursor: null, prev_cursor: null };
       }
       return response.data;
     } catch (error) {
@@ -54,10 +78,10 @@ export const notificationApi = {
     }
   },
 
-  // Mark notification as read
-  markAsRead: async (notificationId: string): Promise<void> => {
+  // Mark a notification group as read
+  markAsRead: async (groupId: string): Promise<void> => {
     try {
-      await api.put(`/notifications/${notificationId}/read`);
+      await api.put(`/notifications/${groupId}/read`);
     } catch (error) {
       // Let the global error handler handle 401s for token refresh
       throw error;
@@ -1,5 +1,5 @@
 import { api } from './index';
-import { Post, CreatePostRequest } from '@/types';
+import { Post, Page, CreatePostRequest } from '@/types';
 
 interface PresignedURLResponse {
   uploadURL: string;
@@ -7,11 +7,11 @@ interface PresignedURLResponse {
 }
 
 export const postApi = {
-  getPosts: async (limit: number = 20, offset: number = 0): Promise<Post[]> => {
-    const response = await api.get<Post[]>('/posts', {
-      params: { limit, offset }
+  getPosts: async (limit: number = 20, cursor?: string): Promise<Page<Post>> => {
+    const response = await api.get<Page<Post>>('/posts', {
+      params: { limit, cursor }
     });
-    return response.data.map(post => {
+    return { ...response.data, data: response.data.data.map(post => {
       const { username, display_name, avatar_url, ...postData } = post;
       return {
         ...postData,
@@ -30,7 +30,7 @@ export const postApi = {
           email_verified: false
         }
       };
-    });
+    }) };
   },
 
   getPost: async (postId: string): Promise<Post> => {
@@ -59,16 +59,16 @@ export const postApi = {
     await api.delete(`/posts/${postId}`);
   },
 
-  getReplies: async (postId: string, limit: number = 20, offset: number = 0): Promise<Post[]> => {
-    const response = await api.get<Post[]>(`/posts/${postId}/replies`, {
-      params: { limit, offset }
+  getReplies: async (postId: string, limit: number = 20, cursor?: string): Promise<Page<Post>> => {
+    const response = await api.get<Page<Post>>(`/posts/${postId}/replies`, {
+      params: { limit, cursor }
     });
     return response.data;
   },
 
-  getUserReplies: async (username: string, limit: number = 20, offset: number = 0): Promise<{ reply: Post, parentPost: Post }[]> => {
-    const response = await api.get<{ reply: Post, parentPost: Post }[]>(`/users/${username}/replies`, {
-      params: { limit, offset }
+  getUserReplies: async (username: string, limit: number = 20, cursor?: string): Promise<Page<{ reply: Post, parentPost: Post }>> => {
+    const response = await api.get<Page<{ reply: Post, parentPost: Post }>>(`/users/${username}/replies`, {
+      params: { limit, cursor }
     });
     return response.data;
   },
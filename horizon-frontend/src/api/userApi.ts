@@ -1,5 +1,5 @@
 import { api } from './index';
-import { CreateUserRequest, LoginRequest, LoginResponse, User, FollowResponse } from '../types';
+import { CreateUserRequest, LoginRequest, LoginResponse, User, FollowResponse, Page } from '../types';
 import { useAuthStore } from '../store/authStore';
 
 interface FollowUser {
@@ -78,17 +78,17 @@ export const userApi = {
   },
 
   // Get user's followers
-  getFollowers: async (username: string, limit: number = 20, offset: number = 0): Promise<User[]> => {
-    const response = await api.get<User[]>(`/users/${username}/followers`, {
-      params: { limit, offset }
+  getFollowers: async (username: string, limit: number = 20, cursor?: string): Promise<Page<User>> => {
+    const response = await api.get<Page<User>>(`/users/${username}/followers`, {
+      params: { limit, cursor }
     });
     return response.data;
   },
 
   // Get users that a user is following
-  getFollowing: async (username: string, limit: number = 20, offset: number = 0): Promise<User[]> => {
-    const response = await api.get<User[]>(`/users/${username}/following`, {
-      params: { limit, offset }
+  getFollowing: async (username: string, limit: number = 20, cursor?: string): Promise<Page<User>> => {
+    const response = await api.get<Page<User>>(`/users/${username}/following`, {
+      params: { limit, cursor }
     });
     return response.data;
   },
@@ -32,10 +32,10 @@ export function FollowListModal({ isOpen, onClose, username, type }: FollowListM
       setError(null);
       
       try {
-        const data = type === 'followers' 
+        const page = type === 'followers' 
           ? await userApi.getFollowers(username)
           : await userApi.getFollowing(username);
-        setUsers(data);
+        setUsers(page.data);
       } catch (err) {
         console.error('Failed to fetch users:', err);
         setError('Failed to load users');
@@ -58,10 +58,10 @@ export function FollowListModal({ isOpen, onClose, username, type }: FollowListM
       }
 
       // Refresh the list
-      const data = type === 'followers' 
+      const page = type === 'followers' 
         ? await userApi.getFollowers(username)
         : await userApi.getFollowing(username);
-      setUsers(data);
+      setUsers(page.data);
     } catch (err) {
       console.error('Failed to toggle follow:', err);
     }
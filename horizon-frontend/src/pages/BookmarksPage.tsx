@@ -14,8 +14,8 @@ export function BookmarksPage() {
     const fetchBookmarks = async () => {
       try {
         setIsLoading(true);
-        const posts = await postApi.getUserBookmarks();
-        setBookmarkedPosts(posts);
+        const page = await postApi.getUserBookmarks();
+        setBookmarkedPosts(page.data);
         setError(null);
       } catch (err) {
         console.error('Failed to fetch bookmarks:', err);
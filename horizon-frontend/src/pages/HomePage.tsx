@@ -18,22 +18,24 @@ export function HomePage() {
   const [posts, setPosts] = useState<PostType[]>([]);
   const [loading, setLoading] = useState(true);
   const [hasMore, setHasMore] = useState(true);
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
 
   // Load initial posts
   useEffect(() => {
     if (isAuthenticated) {
-      loadPosts(0);
+      loadPosts();
     }
   }, [activeTab, isAuthenticated]); // Reload when tab changes or auth state changes
 
-  // Load posts
-  const loadPosts = async (offset: number) => {
+  // Load posts, from the top when no cursor is given
+  const loadPosts = async (cursor?: string) => {
     try {
       setLoading(true);
       // For now, we'll use getPosts for both tabs since we haven't implemented following feed yet
-      const newPosts = await postApi.getPosts(POSTS_PER_PAGE, offset);
+      const page = await postApi.getPosts(POSTS_PER_PAGE, cursor);
+      const newPosts = page.data;
       
-      if (offset === 0) {
+      if (!cursor) {
         setPosts(newPosts);
       } else {
         setPosts(prev => {
@@ -43,7 +45,8 @@ export function HomePage() {
           return [...prev, ...uniqueNewPosts];
         });
       }
-      setHasMore(newPosts.length === POSTS_PER_PAGE);
+      setNextCursor(page.next_cursor);
+      setHasMore(page.next_cursor !== null);
     } catch (error) {
       console.error('Failed to load posts:', error);
     } finally {
@@ -53,8 +56,8 @@ export function HomePage() {
 
   // Load more posts when scrolling
   const loadMore = () => {
-    if (!loading && hasMore && isAuthenticated) {
-      loadPosts(posts.length);
+    if (!loading && hasMore && nextCursor && isAuthenticated) {
+      loadPosts(nextCursor);
     }
   };
 
@@ -63,7 +66,7 @@ export function HomePage() {
 
   // Handle post creation success
   const handlePostSuccess = () => {
-    loadPosts(0); // Reload posts from the beginning
+    loadPosts(); // Reload posts from the beginning
   };
 
   // Show loading state while checking auth
@@ -1,7 +1,7 @@
 import { useEffect, useState } from 'react';
 import { Link, Navigate } from 'react-router-dom';
 import { formatDistanceToNow } from 'date-fns';
-import { Bell, Heart, Repeat, MessageCircle, UserPlus } from 'lucide-react';
+import { AtSign, Bell, Heart, MessageCircle, MessagesSquare, Quote, Repeat, UserCheck, UserPlus } from 'lucide-react';
 import { toast } from 'sonner';
 
 import { notificationApi, Notification } from '../api/notificationApi';
@@ -22,27 +22,29 @@ export default function NotificationsPage() {
   const [notifications, setNotifications] = useState<Notification[]>([]);
   const [loading, setLoading] = useState(true);
   const [hasMore, setHasMore] = useState(true);
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
   const [error, setError] = useState<string | null>(null);
 
   // Load initial notifications
   useEffect(() => {
     // Only load notifications if authenticated and not in loading state
     if (isAuthenticated && !authLoading) {
-      loadNotifications(0);
+      loadNotifications();
     }
   }, [isAuthenticated, authLoading]); // Add dependencies to ensure effect runs when auth state changes
 
-  // Load notifications
-  const loadNotifications = async (offset: number) => {
+  // Load notifications, from the top when no cursor is given
+  const loadNotifications = async (cursor?: string) => {
     try {
       setError(null);
-      const newNotifications = await notificationApi.getNotifications(NOTIFICATIONS_PER_PAGE, offset);
-      if (offset === 0) {
-        setNotifications(newNotifications);
+      const page = await notificationApi.getNotifications(NOTIFICATIONS_PER_PAGE, cursor);
+      if (!cursor) {
+        setNotifications(page.data);
       } else {
-        setNotifications(prev => [...prev, ...newNotifications]);
+        setNotifications(prev => [...prev, ...page.data]);
       }
-      setHasMore(newNotifications.length === NOTIFICATIONS_PER_PAGE);
+      setNextCursor(page.next_cursor);
+      setHasMore(page.next_cursor !== null);
     } catch (error: any) {
       console.error('Failed to load notifications:', error);
       // Only set error if it's not a 401 (which will be handled by the auth interceptor)
@@ -57,8 +59,8 @@ export default function NotificationsPage() {
 
   // Load more notifications when scrolling
   const loadMore = () => {
-    if (!loading && hasMore && !error && isAuthenticated) {
-      loadNotifications(notifications.length);
+    if (!loading && hasMore && nextCursor && !error && isAuthenticated) {
+      loadNotifications(nextCursor);
     }
   };
 
@@ -87,14 +89,14 @@ export default function NotificationsPage() {
     }
   };
 
-  // Mark a single notification as read
-  const handleMarkAsRead = async (notificationId: string) => {
+  // Mark a notification group as read
+  const handleMarkAsRead = async (groupId: string) => {
     if (!isAuthenticated) return;
     
     try {
-      await notificationApi.markAsRead(notificationId);
+      await notificationApi.markAsRead(groupId);
       setNotifications(prev =>
-        prev.map(n => (n.id === notificationId ? { ...n, read: true } : n))
+        prev.map(n => (n.id === groupId ? { ...n, read: true } : n))
       );
       // Refresh the unread count
       loadUnreadCount();
@@ -120,7 +122,16 @@ export default function NotificationsPage() {
       case 'reply':
         return <MessageCircle className="h-5 w-5 text-blue-500" />;
       case 'follow':
+      case 'follow_request':
         return <UserPlus className="h-5 w-5 text-purple-500" />;
+      case 'follow_accepted':
+        return <UserCheck className="h-5 w-5 text-purple-500" />;
+      case 'mention':
+        return <AtSign className="h-5 w-5 text-blue-500" />;
+      case 'quote':
+        return <Quote className="h-5 w-5 text-green-500" />;
+      case 'thread_reply':
+        return <MessagesSquare className="h-5 w-5 text-blue-500" />;
     }
   };
 
@@ -135,9 +146,26 @@ export default function NotificationsPage() {
         return 'replied to your post';
       case 'follow':
         return 'followed you';
+      case 'follow_request':
+        return 'requested to follow you';
+      case 'follow_accepted':
+        return 'accepted your follow request';
+      case 'mention':
+        return 'mentioned you';
+      case 'quote':
+        return 'quoted your post';
+      case 'thread_reply':
+        return 'replied in a thread you follow';
     }
   };
 
+  // Name the group's latest actor and count the rest, e.g. "Ann and 2 others"
+  const getOthersText = (notification: Notification) => {
+    const others = notification.actor_count - 1;
+    if (others <= 0) return null;
+    return others === 1 ? 'and 1 other' : `and ${others} others`;
+  };
+
   // Wait for auth check to complete
   if (authLoading) {
     return (
@@ -167,7 +195,7 @@ export default function NotificationsPage() {
       return (
         <div className="flex flex-col items-center gap-4 py-8">
           <p className="text-destructive">{error}</p>
-          <Button onClick={() => loadNotifications(0)} variant="outline">
+          <Button onClick={() => loadNotifications()} variant="outline">
             Try Again
           </Button>
         </div>
@@ -199,31 +227,53 @@ export default function NotificationsPage() {
             <div className="flex-shrink-0">{getNotificationIcon(notification.type)}</div>
 
             <div className="flex flex-col gap-1">
-              <div className="flex items-center gap-2">
-                {notification.actor_username ? (
-                  <Link
-                    to={`/profile/${notification.actor_username}`}
-                    className="flex items-center gap-2 font-medium hover:underline"
-                  >
+              {notification.actors.length > 1 && (
+                <div className="flex items-center -space-x-2">
+                  {notification.actors.map(actor => (
                     <Avatar
-                      src={notification.actor_avatar_url || undefined}
-                      alt={notification.actor_username}
+                      key={actor.id}
+                      src={actor.avatar_url || undefined}
+                      alt={actor.username}
                       size="sm"
                     />
-                    <span>{notification.actor_display_name || notification.actor_username}</span>
+                  ))}
+                </div>
+              )}
+
+              <div className="flex flex-wrap items-center gap-2">
+                {notification.actors[0] ? (
+                  <Link
+                    to={`/profile/${notification.actors[0].username}`}
+                    className="flex items-center gap-2 font-medium hover:underline"
+                  >
+                    {notification.actors.length === 1 && (
+                      <Avatar
+                        src={notification.actors[0].avatar_url || undefined}
+                        alt={notification.actors[0].username}
+                        size="sm"
+                      />
+                    )}
+                    <span>{notification.actors[0].display_name || notification.actors[0].username}</span>
                   </Link>
                 ) : (
                   <span>A user</span>
                 )}
+                {getOthersText(notification) && (
+                  <span className="text-sm font-medium">{getOthersText(notification)}</span>
+                )}
                 <span className="text-sm text-muted-foreground">
                   {getNotificationText(notification)}
                 </span>
                 <span className="text-sm text-muted-foreground">
-                  {formatDistanceToNow(new Date(notification.created_at), { addSuffix: true })}
+                  {formatDistanceToNow(new Date(notification.updated_at), { addSuffix: true })}
                 </span>
               </div>
 
-              {notification.post_content && (
+              {notification.filtered ? (
+                <p className="rounded bg-muted/5 p-2 text-sm italic text-muted-foreground">
+                  Filtered: {notification.filtered.phrases.join(', ')}
+                </p>
+              ) : notification.post_content && (
                 <Link
                   to={`/post/${notification.post_id}`}
                   className="rounded bg-muted/5 p-2 text-sm text-muted-foreground hover:bg-muted/10"
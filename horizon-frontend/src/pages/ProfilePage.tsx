@@ -118,7 +118,7 @@ export function ProfilePage() {
       try {
         if (activeTab === 'posts') {
           const userPosts = await postApi.getUserPosts(username);
-          setPosts(userPosts.map(post => ({
+          setPosts(userPosts.data.map(post => ({
             ...post,
             user: {
               id: post.user?.id || post.user_id,
@@ -130,12 +130,12 @@ export function ProfilePage() {
         } else if (activeTab === 'replies') {
           setIsLoadingReplies(true);
           const userReplies = await postApi.getUserReplies(username);
-          setReplies(userReplies);
+          setReplies(userReplies.data);
           setIsLoadingReplies(false);
         } else if (activeTab === 'likes') {
           setIsLoadingLikes(true);
           const likes = await postApi.getUserLikes(username);
-          setLikedPosts(likes);
+          setLikedPosts(likes.data);
           setIsLoadingLikes(false);
         }
       } catch (err) {
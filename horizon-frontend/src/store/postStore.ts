@@ -32,8 +32,8 @@ export const usePostStore = create<PostState>((set, get) => ({
   fetchFeed: async () => {
     set({ isLoading: true, error: null });
     try {
-      const posts = await postApi.getFeedPosts();
-      set({ posts, isLoading: false });
+      const page = await postApi.getFeedPosts();
+      set({ posts: page.data, isLoading: false });
     } catch (error) {
       set({ error: 'Failed to fetch feed', isLoading: false });
     }
@@ -42,8 +42,8 @@ export const usePostStore = create<PostState>((set, get) => ({
   fetchUserPosts: async (username) => {
     set({ isLoading: true, error: null });
     try {
-      const userPosts = await postApi.getUserPosts(username);
-      set({ userPosts, isLoading: false });
+      const page = await postApi.getUserPosts(username);
+      set({ userPosts: page.data, isLoading: false });
     } catch (error) {
       set({ error: 'Failed to fetch user posts', isLoading: false });
     }
@@ -72,8 +72,8 @@ export const usePostStore = create<PostState>((set, get) => ({
 
   fetchReplies: async (postId) => {
     try {
-      const replies = await postApi.getReplies(postId);
-      set({ replies });
+      const page = await postApi.getReplies(postId);
+      set({ replies: page.data });
     } catch (error) {
       console.error('Failed to fetch replies:', error);
     }
@@ -36,6 +36,14 @@ export interface Post {
   parent_post?: Post;
 }
 
+// Page is what list endpoints return. next_cursor continues the list towards
+// older items and is null on the last page; prev_cursor fetches what's newer.
+export interface Page<T> {
+  data: T[];
+  next_cursor: string | null;
+  prev_cursor: string | null;
+}
+
 export interface CreatePostRequest {
   content: string;
   is_private?: boolean;